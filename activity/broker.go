@@ -0,0 +1,86 @@
+package activity
+
+import (
+	db "practice-docker/db/sqlc"
+	"sync"
+)
+
+// subscriberBuffer is how many activities a subscriber may fall behind before it is dropped.
+const subscriberBuffer = 16
+
+// Broker fans out the activity of accounts to the subscribers of each account.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[chan db.AccountActivity]struct{}
+}
+
+// NewBroker creates a broker without subscribers.
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: make(map[int64]map[chan db.AccountActivity]struct{}),
+	}
+}
+
+// Subscribe returns a channel of the activity of the account and a function to unsubscribe.
+// The channel is closed when the subscriber falls behind or activity may have been missed,
+// so the subscriber should read the account again before subscribing again.
+func (broker *Broker) Subscribe(accountID int64) (<-chan db.AccountActivity, func()) {
+	ch := make(chan db.AccountActivity, subscriberBuffer)
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	if broker.subscribers[accountID] == nil {
+		broker.subscribers[accountID] = make(map[chan db.AccountActivity]struct{})
+	}
+	broker.subscribers[accountID][ch] = struct{}{}
+
+	unsubscribe := func() {
+		broker.mu.Lock()
+		defer broker.mu.Unlock()
+
+		broker.remove(accountID, ch)
+	}
+
+	return ch, unsubscribe
+}
+
+// Publish sends the activity to the subscribers of its account without waiting for them.
+func (broker *Broker) Publish(activity db.AccountActivity) {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	for ch := range broker.subscribers[activity.AccountID] {
+		select {
+		case ch <- activity:
+		default:
+			broker.remove(activity.AccountID, ch)
+		}
+	}
+}
+
+// CloseAll drops every subscriber, e.g. after notifications may have been lost.
+func (broker *Broker) CloseAll() {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	for accountID, subscribers := range broker.subscribers {
+		for ch := range subscribers {
+			broker.remove(accountID, ch)
+		}
+	}
+}
+
+// remove closes the channel of a subscriber that is still subscribed. The caller holds the lock.
+func (broker *Broker) remove(accountID int64, ch chan db.AccountActivity) {
+	subscribers := broker.subscribers[accountID]
+	if _, ok := subscribers[ch]; !ok {
+		return
+	}
+
+	delete(subscribers, ch)
+	close(ch)
+	if len(subscribers) == 0 {
+		delete(broker.subscribers, accountID)
+	}
+}
@@ -0,0 +1,76 @@
+package activity
+
+import (
+	"encoding/json"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	db "practice-docker/db/sqlc"
+	"testing"
+)
+
+func TestBroker_Publish(t *testing.T) {
+	broker := NewBroker()
+
+	ch1, unsubscribe1 := broker.Subscribe(1)
+	ch2, unsubscribe2 := broker.Subscribe(2)
+	defer unsubscribe2()
+
+	activity := db.AccountActivity{AccountID: 1, Balance: 100}
+	broker.Publish(activity)
+
+	require.Equal(t, activity, <-ch1)
+	require.Empty(t, ch2)
+
+	// unsubscribing closes the channel and is safe to repeat
+	unsubscribe1()
+	unsubscribe1()
+	_, ok := <-ch1
+	require.False(t, ok)
+
+	broker.Publish(activity)
+	require.Empty(t, broker.subscribers[1])
+}
+
+func TestBroker_SlowSubscriber(t *testing.T) {
+	broker := NewBroker()
+
+	ch, unsubscribe := broker.Subscribe(1)
+	defer unsubscribe()
+
+	// a subscriber that falls behind is dropped instead of blocking the others
+	for i := 0; i <= subscriberBuffer; i++ {
+		broker.Publish(db.AccountActivity{AccountID: 1, Balance: int64(i)})
+	}
+
+	n := 0
+	for range ch {
+		n++
+	}
+	require.Equal(t, subscriberBuffer, n)
+}
+
+func TestHandleNotification(t *testing.T) {
+	broker := NewBroker()
+
+	ch, unsubscribe := broker.Subscribe(7)
+	defer unsubscribe()
+
+	activity := db.AccountActivity{
+		AccountID: 7,
+		Entry:     db.Entries{ID: 3, Amount: -10},
+		Balance:   90,
+	}
+	payload, err := json.Marshal(activity)
+	require.NoError(t, err)
+
+	handleNotification(broker, &pq.Notification{Channel: db.AccountActivityChannel, Extra: "not json"})
+	require.Empty(t, ch)
+
+	handleNotification(broker, &pq.Notification{Channel: db.AccountActivityChannel, Extra: string(payload)})
+	require.Equal(t, activity, <-ch)
+
+	// after a reconnect the subscribers have to start over
+	handleNotification(broker, nil)
+	_, ok := <-ch
+	require.False(t, ok)
+}
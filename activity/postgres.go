@@ -0,0 +1,63 @@
+package activity
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/lib/pq"
+	"log"
+	db "practice-docker/db/sqlc"
+	"time"
+)
+
+const (
+	// minReconnectInterval and maxReconnectInterval bound the wait before the listener reconnects.
+	minReconnectInterval = time.Second
+	maxReconnectInterval = time.Minute
+	// pingInterval is how long the listener waits for a notification before it checks the connection.
+	pingInterval = 90 * time.Second
+)
+
+// ListenPostgres publishes the notifications on db.AccountActivityChannel to the broker until the
+// context is done. Every replica listens on its own connection, so each one sees every transfer.
+func ListenPostgres(ctx context.Context, dataSource string, broker *Broker) error {
+	listener := pq.NewListener(dataSource, minReconnectInterval, maxReconnectInterval, func(_ pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Println("Account activity listener: ", err)
+		}
+	})
+	defer listener.Close()
+
+	err := listener.Listen(db.AccountActivityChannel)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification := <-listener.Notify:
+			handleNotification(broker, notification)
+		case <-time.After(pingInterval):
+			go listener.Ping()
+		}
+	}
+}
+
+// handleNotification publishes a notification to the broker.
+// A nil notification means the connection was re-established and notifications may have been lost.
+func handleNotification(broker *Broker, notification *pq.Notification) {
+	if notification == nil {
+		broker.CloseAll()
+		return
+	}
+
+	var activity db.AccountActivity
+	err := json.Unmarshal([]byte(notification.Extra), &activity)
+	if err != nil {
+		log.Println("Invalid account activity notification: ", err)
+		return
+	}
+
+	broker.Publish(activity)
+}
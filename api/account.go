@@ -1,13 +1,12 @@
 package api
 
 import (
-	"database/sql"
-	"errors"
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
 	"net/http"
 	db "practice-docker/db/sqlc"
+	"practice-docker/service"
 	"practice-docker/token"
+	"practice-docker/util"
 )
 
 type createAccountRequest struct {
@@ -28,31 +27,14 @@ func (server *Server) createAccount(ctx *gin.Context) {
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
-	arg := db.CreateAccountsParams{
-		Owner:    authPayload.Username,
-		Currency: req.Currency,
-		Balance:  0,
-	}
-
 	// Create a new account in the database.
-	account, err := server.store.CreateAccounts(ctx, arg)
-
+	account, err := server.bank.CreateAccount(ctx, authPayload.Username, req.Currency)
 	if err != nil {
-		// If the account is not found, return a 404 Not Found.
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-			// error code 23503, 23505
-			case "foreign_key_violation", "unique_violation":
-				ctx.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			}
-		}
-
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, server.newAccountResponse(ctx, account))
 }
 
 type getAccountRequest struct {
@@ -70,33 +52,23 @@ func (server *Server) getAccount(ctx *gin.Context) {
 		return
 	}
 
-	// Get the account from the database.
-	account, err := server.store.GetAccount(ctx, req.ID)
-
-	if err != nil {
-		// If the account is not found, return a 404 Not Found.
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
-	}
-
+	// Get the account of the user from the database.
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if account.Owner != authPayload.Username {
-		err := errors.New("account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	account, err := server.bank.GetAccount(ctx, authPayload.Username, req.ID)
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, server.newAccountResponse(ctx, account))
 }
 
+// listAccountsRequest supports offset paging with page_id and keyset paging with cursor.
+// Without page_id the response is a page with a next_cursor.
 type listAccountsRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=1,max=100"`
+	PageID   int32  `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor   string `form:"cursor"`
 }
 
 // GET /accounts
@@ -110,20 +82,99 @@ func (server *Server) listAccounts(ctx *gin.Context) {
 		return
 	}
 
+	err = validatePaging(req.PageID, req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	arg := db.GetAccountsParams{
-		Owner:  authPayload.Username,
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+
+	if req.PageID == 0 {
+		server.listAccountsAfter(ctx, authPayload.Username, req)
+		return
 	}
 
 	// Get the accounts from the database.
-	accounts, err := server.store.GetAccounts(ctx, arg)
+	accounts, err := server.bank.ListAccounts(ctx, service.ListAccountsParams{
+		Owner:    authPayload.Username,
+		PageID:   req.PageID,
+		PageSize: req.PageSize,
+	})
 
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, server.newAccountsResponse(ctx, accounts))
+}
+
+// listAccountsAfter returns the page of accounts after the cursor.
+func (server *Server) listAccountsAfter(ctx *gin.Context, owner string, req listAccountsRequest) {
+	cursor, err := decodeCursor(req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Fetch one extra row to know if there is a next page.
+	accounts, err := server.bank.ListAccountsAfter(ctx, service.ListAccountsAfterParams{
+		Owner:           owner,
+		CursorCreatedAt: cursor.CreatedAt,
+		CursorID:        cursor.ID,
+		Limit:           req.PageSize + 1,
+	})
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	var nextCursor string
+	if len(accounts) > int(req.PageSize) {
+		accounts = accounts[:req.PageSize]
+		last := accounts[len(accounts)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	ctx.JSON(http.StatusOK, pageResponse{
+		Items:      server.newAccountsResponse(ctx, accounts),
+		NextCursor: nextCursor,
+	})
+}
+
+type updateAccountStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active frozen closed"`
+}
+
+// PATCH /accounts/:id/status
+func (server *Server) updateAccountStatus(ctx *gin.Context) {
+	var uri getAccountRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateAccountStatusRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Admins can change the status of any account.
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	account, err := server.bank.ChangeAccountStatus(ctx, service.ChangeAccountStatusParams{
+		Username:  authPayload.Username,
+		IsAdmin:   authPayload.Role == util.AdminRole,
+		AccountID: uri.ID,
+		Status:    db.AccountStatus(req.Status),
+	})
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, accounts)
+	ctx.JSON(http.StatusOK, server.newAccountResponse(ctx, account))
 }
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	db "practice-docker/db/sqlc"
+	"practice-docker/reconcile"
+	"practice-docker/service"
+	"practice-docker/token"
+)
+
+type adminListRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=1,max=100"`
+}
+
+// GET /admin/users
+func (server *Server) adminListUsers(ctx *gin.Context) {
+	var req adminListRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	users, err := server.bank.ListUsers(ctx, service.ListUsersParams{
+		PageID:   req.PageID,
+		PageSize: req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	// The hashed passwords are never returned, not even to admins.
+	rsp := make([]userResponse, len(users))
+	for i, user := range users {
+		rsp[i] = newUserResponse(user)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// GET /admin/accounts
+func (server *Server) adminListAccounts(ctx *gin.Context) {
+	var req adminListRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	accounts, err := server.bank.ListAllAccounts(ctx, service.ListAllAccountsParams{
+		PageID:   req.PageID,
+		PageSize: req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, server.newAccountsResponse(ctx, accounts))
+}
+
+// POST /admin/accounts/:id/freeze
+func (server *Server) adminFreezeAccount(ctx *gin.Context) {
+	server.setAccountStatus(ctx, db.AccountStatusFrozen)
+}
+
+// POST /admin/accounts/:id/unfreeze
+func (server *Server) adminUnfreezeAccount(ctx *gin.Context) {
+	server.setAccountStatus(ctx, db.AccountStatusActive)
+}
+
+// setAccountStatus changes the status of the account in the URI as an admin.
+func (server *Server) setAccountStatus(ctx *gin.Context, status db.AccountStatus) {
+	var req getAccountRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	account, err := server.bank.ChangeAccountStatus(ctx, service.ChangeAccountStatusParams{
+		Username:  authPayload.Username,
+		IsAdmin:   true,
+		AccountID: req.ID,
+		Status:    status,
+	})
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, server.newAccountResponse(ctx, account))
+}
+
+type setOverdraftLimitRequest struct {
+	// OverdraftLimit is how far below zero the balance may go, in minor units of the currency of the account.
+	OverdraftLimit *int64 `json:"overdraft_limit" binding:"required,min=0"`
+}
+
+// PUT /admin/accounts/:id/overdraft_limit
+func (server *Server) adminSetOverdraftLimit(ctx *gin.Context) {
+	var uri getAccountRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req setOverdraftLimitRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.bank.SetOverdraftLimit(ctx, uri.ID, *req.OverdraftLimit)
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, server.newAccountResponse(ctx, account))
+}
+
+// GET /admin/transfers/:id
+func (server *Server) adminGetTransfer(ctx *gin.Context) {
+	var req getTransferRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Admins can see any transfer, the accounts are only read for their currencies.
+	result, err := server.bank.FindTransfer(ctx, req.ID)
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, server.newTransferResponse(ctx, result.Transfer, result.FromAccount.Currency, result.ToAccount.Currency))
+}
+
+// GET /admin/reconciliation
+func (server *Server) adminReconcile(ctx *gin.Context) {
+	server.reconcile(ctx, false)
+}
+
+// POST /admin/reconciliation/fix
+func (server *Server) adminFixReconciliation(ctx *gin.Context) {
+	server.reconcile(ctx, true)
+}
+
+// reconcile reports where the ledger does not add up, and corrects the account drift in fix mode.
+func (server *Server) reconcile(ctx *gin.Context, fix bool) {
+	report, err := reconcile.Run(ctx, server.store, fix)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	mockDB "practice-docker/db/mock"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// openAPIDocument is the part of the OpenAPI document that the tests check.
+type openAPIDocument struct {
+	OpenAPI    string                                `json:"openapi"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Required []string `json:"required"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+func loadOpenAPIDocument(t *testing.T) openAPIDocument {
+	var doc openAPIDocument
+	err := json.Unmarshal(openAPISpec, &doc)
+	require.NoError(t, err)
+	require.Equal(t, "3.0.3", doc.OpenAPI)
+	return doc
+}
+
+var ginPathParam = regexp.MustCompile(`:(\w+)`)
+
+// TestOpenAPISpecCoversRoutes fails when a route is added to setupRouter without documenting it, or the other way round.
+func TestOpenAPISpecCoversRoutes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockDB.NewMockStore(ctrl))
+	doc := loadOpenAPIDocument(t)
+
+	routes := map[string]bool{}
+	for _, route := range server.router.Routes() {
+		path := ginPathParam.ReplaceAllString(route.Path, "{$1}")
+		operation := strings.ToLower(route.Method) + " " + path
+		routes[operation] = true
+
+		operations, ok := doc.Paths[path]
+		require.Truef(t, ok, "%s %s is not documented in api/openapi.json", route.Method, route.Path)
+		_, ok = operations[strings.ToLower(route.Method)]
+		require.Truef(t, ok, "%s %s is not documented in api/openapi.json", route.Method, route.Path)
+	}
+
+	for path, operations := range doc.Paths {
+		for method := range operations {
+			if method == "parameters" {
+				continue
+			}
+			require.Truef(t, routes[method+" "+path], "%s %s is documented but not routed", method, path)
+		}
+	}
+}
+
+// TestOpenAPISpecRequiredFields checks the required fields of each request body against its binding tags.
+func TestOpenAPISpecRequiredFields(t *testing.T) {
+	doc := loadOpenAPIDocument(t)
+
+	requests := map[string]interface{}{
+		"CreateUserRequest":              createUserRequest{},
+		"LoginUserRequest":               loginUserRequest{},
+		"LogoutUserRequest":              logoutUserRequest{},
+		"ChangePasswordRequest":          changePasswordRequest{},
+		"RequestPasswordResetRequest":    requestPasswordResetRequest{},
+		"ConfirmPasswordResetRequest":    confirmPasswordResetRequest{},
+		"RenewAccessTokenRequest":        renewAccessTokenRequest{},
+		"CreateAccountRequest":           createAccountRequest{},
+		"UpdateAccountStatusRequest":     updateAccountStatusRequest{},
+		"TransferRequest":                transferRequest{},
+		"ReverseTransferRequest":         reverseTransferRequest{},
+		"CreateScheduledTransferRequest": createScheduledTransferRequest{},
+		"UpdateScheduledTransferRequest": updateScheduledTransferRequest{},
+		"CreateWebhookRequest":           createWebhookRequest{},
+	}
+
+	for name, req := range requests {
+		schema, ok := doc.Components.Schemas[name]
+		require.Truef(t, ok, "schema %s is missing", name)
+
+		var required []string
+		reqType := reflect.TypeOf(req)
+		for i := 0; i < reqType.NumField(); i++ {
+			field := reqType.Field(i)
+			if strings.Contains(field.Tag.Get("binding"), "required") {
+				required = append(required, strings.Split(field.Tag.Get("json"), ",")[0])
+			}
+		}
+
+		sort.Strings(required)
+		documented := append([]string(nil), schema.Required...)
+		sort.Strings(documented)
+		require.Equalf(t, required, documented, "required fields of %s", name)
+	}
+}
+
+func TestServer_getOpenAPISpec(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockDB.NewMockStore(ctrl))
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/openapi.json", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	require.JSONEq(t, string(openAPISpec), recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/docs", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), `url: "/openapi.json"`)
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/csv"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	db "practice-docker/db/sqlc"
+	"practice-docker/service"
+	"practice-docker/token"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const mimeTextCSV = "text/csv"
+
+// listAccountEntriesRequest supports offset paging with page_id and keyset paging with cursor.
+// Without page_id the response is a page with a next_cursor.
+type listAccountEntriesRequest struct {
+	FromTime time.Time `form:"from_time"`
+	ToTime   time.Time `form:"to_time"`
+	PageID   int32     `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32     `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor   string    `form:"cursor"`
+}
+
+// GET /accounts/:id/entries
+func (server *Server) listAccountEntries(ctx *gin.Context) {
+	var uri getAccountRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// from_time and to_time are optional and use the RFC 3339 format.
+	var req listAccountEntriesRequest
+	err = ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	err = validatePaging(req.PageID, req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if req.PageID == 0 {
+		server.listAccountEntriesAfter(ctx, authPayload.Username, uri.ID, req)
+		return
+	}
+
+	// Get the entries with their running balance.
+	statement, err := server.bank.ListAccountStatement(ctx, service.ListAccountStatementParams{
+		Username:  authPayload.Username,
+		AccountID: uri.ID,
+		FromTime:  req.FromTime,
+		ToTime:    req.ToTime,
+		PageID:    req.PageID,
+		PageSize:  req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	// Accountants can ask for a CSV export instead of JSON.
+	if strings.Contains(ctx.GetHeader("Accept"), mimeTextCSV) {
+		writeEntriesCSV(ctx, statement.Entries)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, server.newStatementResponse(ctx, statement.Account.Currency, statement.Entries))
+}
+
+// listAccountEntriesAfter returns the page of the account statement after the cursor.
+func (server *Server) listAccountEntriesAfter(ctx *gin.Context, username string, accountID int64, req listAccountEntriesRequest) {
+	cursor, err := decodeCursor(req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Fetch one extra row to know if there is a next page.
+	statement, err := server.bank.ListAccountStatementAfter(ctx, service.ListAccountStatementAfterParams{
+		Username:        username,
+		AccountID:       accountID,
+		FromTime:        req.FromTime,
+		ToTime:          req.ToTime,
+		CursorCreatedAt: cursor.CreatedAt,
+		CursorID:        cursor.ID,
+		Limit:           req.PageSize + 1,
+	})
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	entries := statement.Entries
+	var nextCursor string
+	if len(entries) > int(req.PageSize) {
+		entries = entries[:req.PageSize]
+		last := entries[len(entries)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	if strings.Contains(ctx.GetHeader("Accept"), mimeTextCSV) {
+		ctx.Header(nextCursorHeader, nextCursor)
+		writeEntriesCSV(ctx, entries)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pageResponse{
+		Items:      server.newStatementResponse(ctx, statement.Account.Currency, entries),
+		NextCursor: nextCursor,
+	})
+}
+
+// writeEntriesCSV writes the account statement as a CSV file.
+func writeEntriesCSV(ctx *gin.Context, entries []db.ListAccountStatementRow) {
+	ctx.Header("Content-Type", mimeTextCSV)
+	ctx.Status(http.StatusOK)
+
+	writer := csv.NewWriter(ctx.Writer)
+	_ = writer.Write([]string{"id", "account_id", "amount", "running_balance", "created_at"})
+	for _, entry := range entries {
+		_ = writer.Write([]string{
+			strconv.FormatInt(entry.ID, 10),
+			strconv.FormatInt(entry.AccountID.Int64, 10),
+			strconv.FormatInt(entry.Amount, 10),
+			strconv.FormatInt(entry.RunningBalance, 10),
+			entry.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		_ = ctx.Error(err)
+	}
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+	"practice-docker/service"
+)
+
+// serviceErrorStatus maps an error of the service layer to an HTTP status code.
+// A resource of another user is a 401 over HTTP, like every ownership check of the API.
+func serviceErrorStatus(err error) int {
+	switch service.ErrorCode(err) {
+	case service.CodeInvalidArgument, service.CodeCurrencyMismatch:
+		return http.StatusBadRequest
+	case service.CodeNotFound:
+		return http.StatusNotFound
+	case service.CodeUnauthenticated, service.CodeForbidden:
+		return http.StatusUnauthorized
+	case service.CodeAlreadyExists, service.CodeFailedPrecondition:
+		return http.StatusForbidden
+	case service.CodeConflict:
+		return http.StatusConflict
+	case service.CodeInsufficientFunds:
+		return http.StatusUnprocessableEntity
+	case service.CodeResourceExhausted:
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
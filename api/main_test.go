@@ -1,27 +1,107 @@
 package api
 
 import (
+	"context"
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	"net"
 	"os"
+	"practice-docker/activity"
+	mockDB "practice-docker/db/mock"
 	db "practice-docker/db/sqlc"
+	"practice-docker/fx"
+	"practice-docker/mail"
+	"practice-docker/ratelimit"
+	"practice-docker/service"
+	"practice-docker/throttle"
+	"practice-docker/token"
 	"practice-docker/util"
+	"strings"
 	"testing"
 	"time"
 )
 
+// testExchangeRates has no rate for CAD, so transfers into CAD accounts fail.
+var testExchangeRates = map[string]string{
+	util.USD + "/" + util.EUR: "0.9",
+	util.USD + "/" + util.JPY: "150",
+}
+
+// testCurrencies mirrors the seeded currencies table. KWD is disabled.
+var testCurrencies = []util.Currency{
+	{Code: util.USD, Exponent: 2, Enabled: true},
+	{Code: util.EUR, Exponent: 2, Enabled: true},
+	{Code: util.CAD, Exponent: 2, Enabled: true},
+	{Code: util.JPY, Exponent: 0, Enabled: true},
+	{Code: util.KWD, Exponent: 3, Enabled: false},
+}
+
 func newTestServer(t *testing.T, store db.Store) *Server {
+	return newTestServerWithMailer(t, store, mail.NewMemoryMailer())
+}
+
+// newTestServerWithMailer creates a test server whose mails can be read from mailer.
+func newTestServerWithMailer(t *testing.T, store db.Store, mailer *mail.MemoryMailer) *Server {
 	config := util.Config{
-		TokenSymmetricKey:   util.RandomString(32),
-		AccessTokenLifetime: time.Minute,
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenLifetime:  time.Minute,
+		RefreshTokenLifetime: time.Hour,
 	}
 
-	server, err := NewServer(config, store)
+	rates, err := fx.NewStaticProvider(testExchangeRates)
+	require.NoError(t, err)
+
+	server, err := NewServer(config, Dependencies{
+		Dependencies: service.Dependencies{
+			Store:       allowAuthentication(t, store),
+			Revocations: token.NewMemoryRevocationStore(),
+			Rates:       rates,
+			Currencies:  util.NewStaticCurrencyRegistry(testCurrencies...),
+			Mailer:      mailer,
+			Logins:      newTestLoginThrottle(),
+			Resolver:    testResolver{},
+		},
+		Activity: activity.NewBroker(),
+		Limiter:  ratelimit.NewLimiter(ratelimit.NewMemoryStore(), 0),
+	})
 	require.NoErrorf(t, err, "failed to create server: %v", err)
 
 	return server
 }
 
+// allowAuthentication lets every access token of the test through the password change check,
+// unless the test expects its own calls of GetUserPasswordChangedAt first.
+// Without a store, the server gets a mock that expects nothing else.
+func allowAuthentication(t *testing.T, store db.Store) db.Store {
+	if store == nil {
+		store = mockDB.NewMockStore(gomock.NewController(t))
+	}
+
+	if mockStore, ok := store.(*mockDB.MockStore); ok {
+		mockStore.EXPECT().
+			GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(time.Time{}, nil)
+	}
+	return store
+}
+
+// testResolver resolves hosts under .internal to a private address and every other host to a public one.
+type testResolver struct{}
+
+func (testResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	if strings.HasSuffix(host, ".internal") {
+		return []net.IPAddr{{IP: net.ParseIP("10.0.0.1")}}, nil
+	}
+	return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+}
+
+// newTestLoginThrottle throttles failed logins with the default policy.
+func newTestLoginThrottle() *throttle.LoginThrottle {
+	return throttle.NewLoginThrottle(throttle.NewMemoryAttemptStore(), throttle.Policy{})
+}
+
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
 	os.Exit(m.Run())
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"practice-docker/service"
 	"practice-docker/token"
 	"strings"
 )
@@ -16,7 +17,7 @@ const (
 	authorizationPayloadKey = "authorization_payload"
 )
 
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+func authMiddleware(bank *service.Bank) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// Get the access token from the authorization header.
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
@@ -45,11 +46,10 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 			return
 		}
 
-		// Parse the access token.
-		accessToken := fields[1]
-		payload, err := tokenMaker.VerifyToken(accessToken)
+		// Verify the access token and check that it was neither revoked nor issued before a password change.
+		payload, err := bank.Authenticate(ctx, fields[1])
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			ctx.AbortWithStatusJSON(serviceErrorStatus(err), errorResponse(err))
 			return
 		}
 
@@ -57,3 +57,21 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// roleMiddleware only lets through requests whose access token has one of the given roles.
+// It must run after authMiddleware.
+func roleMiddleware(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+		for _, role := range roles {
+			if payload.Role == role {
+				ctx.Next()
+				return
+			}
+		}
+
+		err := fmt.Errorf("role %q is not allowed to access this resource", payload.Role)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+	}
+}
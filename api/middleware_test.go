@@ -1,12 +1,16 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
+	mockDB "practice-docker/db/mock"
 	"practice-docker/token"
+	"practice-docker/util"
 	"testing"
 	"time"
 )
@@ -17,9 +21,10 @@ func addAuthorization(
 	tokenMaker token.Maker,
 	authorizationType string,
 	username string,
+	role string,
 	duration time.Duration,
 ) {
-	createToken, err := tokenMaker.CreateToken(username, duration)
+	createToken, _, err := tokenMaker.CreateToken(username, role, token.TokenTypeAccess, duration)
 	require.NoErrorf(t, err, "failed to create access token: %v", err)
 
 	authorizationHeader := fmt.Sprintf("%s %s", authorizationType, createToken)
@@ -35,7 +40,7 @@ func TestServer_authMiddleware(t *testing.T) {
 		{
 			name: "OK",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "username", time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "username", util.UserRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -53,7 +58,7 @@ func TestServer_authMiddleware(t *testing.T) {
 		{
 			name: "InvalidAuthorizationHeader",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, "Invalid", "username", time.Minute)
+				addAuthorization(t, request, tokenMaker, "Invalid", "username", util.UserRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -62,7 +67,18 @@ func TestServer_authMiddleware(t *testing.T) {
 		{
 			name: "InvalidAuthorizationType",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, "", "username", time.Minute)
+				addAuthorization(t, request, tokenMaker, "", "username", util.UserRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "RefreshToken",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				refreshToken, _, err := tokenMaker.CreateToken("username", util.UserRole, token.TokenTypeRefresh, time.Hour)
+				require.NoError(t, err)
+				request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, refreshToken))
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -71,7 +87,7 @@ func TestServer_authMiddleware(t *testing.T) {
 		{
 			name: "ExpiredToken",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "username", -time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "username", util.UserRole, -time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -90,7 +106,7 @@ func TestServer_authMiddleware(t *testing.T) {
 		url := fmt.Sprintf("/auth")
 		server.router.GET(
 			url,
-			authMiddleware(server.tokenMaker),
+			authMiddleware(server.bank),
 			func(ctx *gin.Context) {
 				ctx.JSON(http.StatusOK, gin.H{})
 			},
@@ -115,3 +131,125 @@ func TestServer_authMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_authMiddlewareRevokedToken(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	url := "/auth"
+	server.router.GET(
+		url,
+		authMiddleware(server.bank),
+		func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{})
+		},
+	)
+
+	accessToken, payload, err := server.tokenMaker.CreateToken("username", util.UserRole, token.TokenTypeAccess, time.Minute)
+	require.NoErrorf(t, err, "failed to create access token: %v", err)
+
+	err = server.bank.Logout(context.Background(), payload, "")
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoErrorf(t, err, "failed to create request: %v", err)
+	request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestServer_authMiddlewarePasswordChanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockDB.NewMockStore(ctrl)
+
+	// the password is changed after the token was issued
+	store.EXPECT().
+		GetUserPasswordChangedAt(gomock.Any(), gomock.Eq("username")).
+		Times(1).
+		DoAndReturn(func(_ interface{}, _ string) (time.Time, error) {
+			return time.Now(), nil
+		})
+
+	server := newTestServer(t, store)
+
+	url := "/auth"
+	server.router.GET(
+		url,
+		authMiddleware(server.bank),
+		func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{})
+		},
+	)
+
+	accessToken, _, err := server.tokenMaker.CreateToken("username", util.UserRole, token.TokenTypeAccess, time.Minute)
+	require.NoErrorf(t, err, "failed to create access token: %v", err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoErrorf(t, err, "failed to create request: %v", err)
+	request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestServer_roleMiddleware(t *testing.T) {
+	testCases := []struct {
+		name          string
+		role          string
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Allowed",
+			role: util.AdminRole,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Forbidden",
+			role: util.UserRole,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "MissingRole",
+			role: "",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		server := newTestServer(t, nil)
+
+		url := "/admin-only"
+		server.router.GET(
+			url,
+			authMiddleware(server.bank),
+			roleMiddleware(util.AdminRole),
+			func(ctx *gin.Context) {
+				ctx.JSON(http.StatusOK, gin.H{})
+			},
+		)
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoErrorf(t, err, "failed to create request: %v", err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "username", tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
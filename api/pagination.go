@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// nextCursorHeader carries the next cursor of responses that have no JSON envelope, e.g. CSV exports.
+const nextCursorHeader = "Next-Cursor"
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor is the (created_at, id) position of the last row of a page.
+// Clients only see it as an opaque string.
+type pageCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        int64     `json:"id"`
+}
+
+// pageResponse is returned by list endpoints when they are paged with a cursor.
+// NextCursor is empty on the last page.
+type pageResponse struct {
+	Items      interface{} `json:"items"`
+	NextCursor string      `json:"next_cursor"`
+}
+
+// encodeCursor returns the opaque cursor pointing after the given row.
+func encodeCursor(createdAt time.Time, id int64) string {
+	data, _ := json.Marshal(pageCursor{
+		CreatedAt: createdAt,
+		ID:        id,
+	})
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor returned by encodeCursor.
+// An empty cursor points before the first row.
+func decodeCursor(cursor string) (pageCursor, error) {
+	var c pageCursor
+	if cursor == "" {
+		return c, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return c, errInvalidCursor
+	}
+
+	err = json.Unmarshal(data, &c)
+	if err != nil || c.ID <= 0 {
+		return pageCursor{}, errInvalidCursor
+	}
+
+	return c, nil
+}
+
+// validatePaging checks that offset and cursor paging are not mixed.
+func validatePaging(pageID int32, cursor string) error {
+	if pageID > 0 && cursor != "" {
+		return errors.New("page_id and cursor cannot be used together")
+	}
+
+	return nil
+}
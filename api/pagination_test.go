@@ -0,0 +1,25 @@
+package api
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCursor(t *testing.T) {
+	createdAt := time.Now().UTC()
+	id := int64(42)
+
+	cursor, err := decodeCursor(encodeCursor(createdAt, id))
+	require.NoError(t, err)
+	require.True(t, createdAt.Equal(cursor.CreatedAt))
+	require.Equal(t, id, cursor.ID)
+
+	// an empty cursor starts at the first row
+	cursor, err = decodeCursor("")
+	require.NoError(t, err)
+	require.Zero(t, cursor)
+
+	_, err = decodeCursor("not a cursor")
+	require.ErrorIs(t, err, errInvalidCursor)
+}
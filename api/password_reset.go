@@ -0,0 +1,55 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type requestPasswordResetRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// POST /users/password-reset
+func (server *Server) requestPasswordReset(ctx *gin.Context) {
+	var req requestPasswordResetRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Mail a reset token if a user has the email.
+	err = server.bank.RequestPasswordReset(ctx, req.Email)
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	// The response is the same for unknown emails.
+	ctx.Status(http.StatusAccepted)
+}
+
+type confirmPasswordResetRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// POST /users/password-reset/confirm
+func (server *Server) confirmPasswordReset(ctx *gin.Context) {
+	var req confirmPasswordResetRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Use the token to replace the password.
+	user, err := server.bank.ConfirmPasswordReset(ctx, req.Token, req.NewPassword)
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	rsp := newUserResponse(user)
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log"
+	"math"
+	"net/http"
+	"practice-docker/token"
+	"strconv"
+	"time"
+)
+
+// Requests per RateLimitPeriod of the route groups whose limit is not configured.
+const (
+	defaultUsersRateLimit     int32 = 30
+	defaultAccountsRateLimit  int32 = 120
+	defaultTransfersRateLimit int32 = 30
+	defaultWebhooksRateLimit  int32 = 30
+	defaultAdminRateLimit     int32 = 120
+)
+
+// rateLimitMiddleware allows a client limit requests to the routes of the group per period of the limiter,
+// or defaultLimit requests when the limit is not configured.
+// Authenticated requests are counted per user, the others per client IP.
+// When the limiter fails, the request is let through unless RateLimitFailClosed is set, then it fails with a 500.
+func (server *Server) rateLimitMiddleware(group string, limit int32, defaultLimit int32) gin.HandlerFunc {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	return func(ctx *gin.Context) {
+		key := group + ":ip:" + ctx.ClientIP()
+		if payload, ok := ctx.Get(authorizationPayloadKey); ok {
+			key = group + ":user:" + payload.(*token.Payload).Username
+		}
+
+		result, err := server.limiter.Allow(ctx, key, limit)
+		if err != nil {
+			if server.config.RateLimitFailClosed {
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+
+			log.Println("Failed to check the rate limit, letting the request through: ", err)
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("RateLimit-Limit", strconv.Itoa(int(result.Limit)))
+		ctx.Header("RateLimit-Remaining", strconv.Itoa(int(result.Remaining)))
+		ctx.Header("RateLimit-Reset", seconds(result.Reset))
+
+		if !result.Allowed {
+			ctx.Header("Retry-After", seconds(result.RetryAfter))
+			err := errors.New("rate limit exceeded")
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, errorResponse(err))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// seconds formats the duration for a header in whole seconds, rounded up so the client doesn't retry too early.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"practice-docker/ratelimit"
+	"practice-docker/util"
+	"testing"
+	"time"
+)
+
+func TestServer_rateLimitMiddleware(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	// two requests per minute, so a request is refilled every 30 seconds
+	server.router.GET(
+		"/limited",
+		authMiddleware(server.bank),
+		server.rateLimitMiddleware("test", 2, defaultUsersRateLimit),
+		func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{})
+		},
+	)
+
+	send := func(username string) *httptest.ResponseRecorder {
+		request, err := http.NewRequest(http.MethodGet, "/limited", nil)
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, username, util.UserRole, time.Minute)
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	for _, remaining := range []string{"1", "0"} {
+		recorder := send("alice")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "2", recorder.Header().Get("RateLimit-Limit"))
+		require.Equal(t, remaining, recorder.Header().Get("RateLimit-Remaining"))
+		require.Empty(t, recorder.Header().Get("Retry-After"))
+	}
+
+	recorder := send("alice")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "0", recorder.Header().Get("RateLimit-Remaining"))
+	require.Equal(t, "60", recorder.Header().Get("RateLimit-Reset"))
+	require.Equal(t, "30", recorder.Header().Get("Retry-After"))
+
+	// every user has their own budget
+	recorder = send("bob")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "1", recorder.Header().Get("RateLimit-Remaining"))
+}
+
+func TestServer_rateLimitMiddlewareClientIP(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	// without an access token the requests are counted per client IP
+	server.router.GET(
+		"/limited",
+		server.rateLimitMiddleware("test", 0, 1),
+		func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{})
+		},
+	)
+
+	send := func(remoteAddr string) *httptest.ResponseRecorder {
+		request, err := http.NewRequest(http.MethodGet, "/limited", nil)
+		require.NoError(t, err)
+		request.RemoteAddr = remoteAddr
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// the limit is not configured, so the default of one request applies
+	require.Equal(t, http.StatusOK, send("10.0.0.1:1234").Code)
+	require.Equal(t, http.StatusTooManyRequests, send("10.0.0.1:4321").Code)
+	require.Equal(t, http.StatusOK, send("10.0.0.2:1234").Code)
+}
+
+// failingBucketStore fails like a bucket store whose database is down.
+type failingBucketStore struct{}
+
+func (failingBucketStore) Take(ctx context.Context, key string, budget ratelimit.Budget, now time.Time) (ratelimit.Bucket, bool, error) {
+	return ratelimit.Bucket{}, false, sql.ErrConnDone
+}
+
+func (failingBucketStore) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	return 0, sql.ErrConnDone
+}
+
+func TestServer_rateLimitMiddlewareStoreError(t *testing.T) {
+	testCases := []struct {
+		name         string
+		failClosed   bool
+		expectedCode int
+	}{
+		{
+			name:         "FailOpen",
+			failClosed:   false,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "FailClosed",
+			failClosed:   true,
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			server.limiter = ratelimit.NewLimiter(failingBucketStore{}, 0)
+			server.config.RateLimitFailClosed = tc.failClosed
+
+			server.router.GET(
+				"/limited",
+				server.rateLimitMiddleware("test", 1, defaultUsersRateLimit),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			request, err := http.NewRequest(http.MethodGet, "/limited", nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.expectedCode, recorder.Code)
+			// nothing is known about the budget, so no rate limit headers are sent
+			require.Empty(t, recorder.Header().Get("RateLimit-Remaining"))
+		})
+	}
+}
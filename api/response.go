@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	db "practice-docker/db/sqlc"
+)
+
+// The responses below add a formatted decimal amount next to each raw amount in minor units.
+
+type accountResponse struct {
+	db.Accounts
+	FormattedBalance string `json:"formatted_balance"`
+}
+
+type entryResponse struct {
+	db.Entries
+	FormattedAmount string `json:"formatted_amount"`
+}
+
+type statementEntryResponse struct {
+	db.ListAccountStatementRow
+	FormattedAmount         string `json:"formatted_amount"`
+	FormattedRunningBalance string `json:"formatted_running_balance"`
+}
+
+type transferResponse struct {
+	db.Transfers
+	FromCurrency               string `json:"from_currency"`
+	ToCurrency                 string `json:"to_currency"`
+	FormattedAmount            string `json:"formatted_amount"`
+	FormattedDestinationAmount string `json:"formatted_destination_amount"`
+}
+
+type transferTxResponse struct {
+	Transfer    transferResponse `json:"transfer"`
+	FromAccount accountResponse  `json:"from_account"`
+	ToAccount   accountResponse  `json:"to_account"`
+	FromEntry   entryResponse    `json:"from_entry"`
+	ToEntry     entryResponse    `json:"to_entry"`
+}
+
+type accountActivityResponse struct {
+	AccountID        int64         `json:"account_id"`
+	Entry            entryResponse `json:"entry"`
+	Balance          int64         `json:"balance"`
+	FormattedBalance string        `json:"formatted_balance"`
+}
+
+func (server *Server) newAccountResponse(ctx context.Context, account db.Accounts) accountResponse {
+	return accountResponse{
+		Accounts:         account,
+		FormattedBalance: server.currencies.Format(ctx, account.Currency, account.Balance),
+	}
+}
+
+func (server *Server) newAccountsResponse(ctx context.Context, accounts []db.Accounts) []accountResponse {
+	rsp := make([]accountResponse, len(accounts))
+	for i, account := range accounts {
+		rsp[i] = server.newAccountResponse(ctx, account)
+	}
+	return rsp
+}
+
+func (server *Server) newStatementResponse(
+	ctx context.Context,
+	currency string,
+	entries []db.ListAccountStatementRow,
+) []statementEntryResponse {
+	rsp := make([]statementEntryResponse, len(entries))
+	for i, entry := range entries {
+		rsp[i] = statementEntryResponse{
+			ListAccountStatementRow: entry,
+			FormattedAmount:         server.currencies.Format(ctx, currency, entry.Amount),
+			FormattedRunningBalance: server.currencies.Format(ctx, currency, entry.RunningBalance),
+		}
+	}
+	return rsp
+}
+
+func (server *Server) newTransferResponse(
+	ctx context.Context,
+	transfer db.Transfers,
+	fromCurrency string,
+	toCurrency string,
+) transferResponse {
+	return transferResponse{
+		Transfers:                  transfer,
+		FromCurrency:               fromCurrency,
+		ToCurrency:                 toCurrency,
+		FormattedAmount:            server.currencies.Format(ctx, fromCurrency, transfer.Amount),
+		FormattedDestinationAmount: server.currencies.Format(ctx, toCurrency, transfer.DestinationAmount),
+	}
+}
+
+func (server *Server) newUserTransfersResponse(ctx context.Context, rows []db.ListUserTransfersRow) []transferResponse {
+	rsp := make([]transferResponse, len(rows))
+	for i, row := range rows {
+		transfer := db.Transfers{
+			ID:                row.ID,
+			FromAccountID:     row.FromAccountID,
+			ToAccountID:       row.ToAccountID,
+			Amount:            row.Amount,
+			CreatedAt:         row.CreatedAt,
+			ExchangeRate:      row.ExchangeRate,
+			SourceAmount:      row.SourceAmount,
+			DestinationAmount: row.DestinationAmount,
+			ReversalOf:        row.ReversalOf,
+		}
+		rsp[i] = server.newTransferResponse(ctx, transfer, row.FromCurrency, row.ToCurrency)
+	}
+	return rsp
+}
+
+func (server *Server) newTransferTxResponse(ctx context.Context, result db.TransferTxResult) transferTxResponse {
+	fromCurrency := result.FromAccount.Currency
+	toCurrency := result.ToAccount.Currency
+
+	return transferTxResponse{
+		Transfer:    server.newTransferResponse(ctx, result.Transfer, fromCurrency, toCurrency),
+		FromAccount: server.newAccountResponse(ctx, result.FromAccount),
+		ToAccount:   server.newAccountResponse(ctx, result.ToAccount),
+		FromEntry: entryResponse{
+			Entries:         result.FromEntry,
+			FormattedAmount: server.currencies.Format(ctx, fromCurrency, result.FromEntry.Amount),
+		},
+		ToEntry: entryResponse{
+			Entries:         result.ToEntry,
+			FormattedAmount: server.currencies.Format(ctx, toCurrency, result.ToEntry.Amount),
+		},
+	}
+}
+
+func (server *Server) newAccountActivityResponse(
+	ctx context.Context,
+	currency string,
+	activity db.AccountActivity,
+) accountActivityResponse {
+	return accountActivityResponse{
+		AccountID: activity.AccountID,
+		Entry: entryResponse{
+			Entries:         activity.Entry,
+			FormattedAmount: server.currencies.Format(ctx, currency, activity.Entry.Amount),
+		},
+		Balance:          activity.Balance,
+		FormattedBalance: server.currencies.Format(ctx, currency, activity.Balance),
+	}
+}
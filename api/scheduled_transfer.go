@@ -0,0 +1,159 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	db "practice-docker/db/sqlc"
+	"practice-docker/service"
+	"practice-docker/token"
+	"time"
+)
+
+type createScheduledTransferRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	Currency      string `json:"currency" binding:"required,currency"`
+	Frequency     string `json:"frequency" binding:"required,oneof=once daily weekly monthly"`
+	// StartAt is the first occurrence. It defaults to now.
+	StartAt time.Time `json:"start_at"`
+	// EndAt is optional. No occurrence runs after it.
+	EndAt time.Time `json:"end_at"`
+}
+
+// POST /scheduled-transfers
+func (server *Server) createScheduledTransfer(ctx *gin.Context) {
+	var req createScheduledTransferRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	scheduled, err := server.bank.CreateScheduledTransfer(ctx, service.CreateScheduledTransferParams{
+		Username:      authPayload.Username,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Frequency:     db.TransferFrequency(req.Frequency),
+		StartAt:       req.StartAt,
+		EndAt:         req.EndAt,
+	})
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+type listScheduledTransfersRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=1,max=100"`
+}
+
+// GET /scheduled-transfers
+func (server *Server) listScheduledTransfers(ctx *gin.Context) {
+	var req listScheduledTransfersRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	scheduled, err := server.bank.ListScheduledTransfers(ctx, service.ListScheduledTransfersParams{
+		Owner:    authPayload.Username,
+		PageID:   req.PageID,
+		PageSize: req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+type getScheduledTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// GET /scheduled-transfers/:id
+func (server *Server) getScheduledTransfer(ctx *gin.Context) {
+	var req getScheduledTransferRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	scheduled, err := server.bank.GetScheduledTransfer(ctx, authPayload.Username, req.ID)
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+type updateScheduledTransferRequest struct {
+	Amount int64     `json:"amount" binding:"omitempty,gt=0"`
+	EndAt  time.Time `json:"end_at"`
+	// IsActive pauses or resumes the schedule. A resumed schedule skips the occurrences it missed while paused.
+	IsActive *bool `json:"is_active"`
+}
+
+// PATCH /scheduled-transfers/:id
+func (server *Server) updateScheduledTransfer(ctx *gin.Context) {
+	var uri getScheduledTransferRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateScheduledTransferRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	scheduled, err := server.bank.UpdateScheduledTransfer(ctx, service.UpdateScheduledTransferParams{
+		Username: authPayload.Username,
+		ID:       uri.ID,
+		Amount:   req.Amount,
+		EndAt:    req.EndAt,
+		IsActive: req.IsActive,
+	})
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+// DELETE /scheduled-transfers/:id
+func (server *Server) deleteScheduledTransfer(ctx *gin.Context) {
+	var req getScheduledTransferRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	err = server.bank.DeleteScheduledTransfer(ctx, authPayload.Username, req.ID)
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -4,7 +4,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"practice-docker/activity"
 	db "practice-docker/db/sqlc"
+	"practice-docker/ratelimit"
+	"practice-docker/service"
 	"practice-docker/token"
 	"practice-docker/util"
 )
@@ -13,39 +16,114 @@ type Server struct {
 	config     util.Config
 	store      db.Store
 	tokenMaker token.Maker
+	currencies *util.CurrencyRegistry
+	activity   *activity.Broker
+	limiter    *ratelimit.Limiter
+	bank       *service.Bank
 	router     *gin.Engine
 }
 
 // Set up the routing of the server.
-func (server *Server) setupRouter() {
+func (server *Server) setupRouter() error {
 	router := gin.Default()
 
 	// Set up the mode of the server.
 	gin.SetMode(gin.DebugMode)
-	err := router.SetTrustedProxies([]string{
-		"127.0.0.1",
-	})
+	trustedProxies := server.config.TrustedProxies
+	if len(trustedProxies) == 0 {
+		trustedProxies = util.DefaultTrustedProxies
+	}
+	err := router.SetTrustedProxies(trustedProxies)
 	if err != nil {
-		return
+		return err
 	}
 
-	router.POST("/users", server.createUser)
-	router.POST("/users/login", server.loginUser)
+	router.GET("/openapi.json", server.getOpenAPISpec)
+	router.GET("/docs", server.getDocs)
+
+	// Every group of routes has its own budget of requests per client.
+	usersRateLimit := server.rateLimitMiddleware("users", server.config.RateLimitUsers, defaultUsersRateLimit)
+
+	userRoutes := router.Group("/", usersRateLimit)
+
+	userRoutes.POST("/users", server.createUser)
+	userRoutes.POST("/users/login", server.loginUser)
+	userRoutes.GET("/users/verify_email", server.verifyEmail)
+	userRoutes.POST("/users/password-reset", server.requestPasswordReset)
+	userRoutes.POST("/users/password-reset/confirm", server.confirmPasswordReset)
+	userRoutes.POST("/tokens/renew_access", server.renewAccessToken)
+
+	// Use the groups to apply middleware to routes.
+	// The rate limit comes after the authentication, so it counts the requests of the user.
+	authenticate := authMiddleware(server.bank)
+
+	authUserRoutes := router.Group("/", authenticate, usersRateLimit)
+
+	authUserRoutes.POST("/users/logout", server.logoutUser)
+	authUserRoutes.POST("/users/verify_email/resend", server.resendVerificationEmail)
+	authUserRoutes.PUT("/users/me/password", server.changePassword)
+
+	accountRoutes := router.Group("/", authenticate,
+		server.rateLimitMiddleware("accounts", server.config.RateLimitAccounts, defaultAccountsRateLimit))
+
+	accountRoutes.POST("/accounts", server.createAccount)
+	accountRoutes.GET("/accounts/:id", server.getAccount)
+	accountRoutes.GET("/accounts", server.listAccounts)
+	accountRoutes.GET("/accounts/:id/entries", server.listAccountEntries)
+	accountRoutes.PATCH("/accounts/:id/status", server.updateAccountStatus)
+	accountRoutes.GET("/accounts/:id/stream", server.streamAccount)
 
-	// Use the group to apply middleware to routes.
-	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
+	transferRoutes := router.Group("/", authenticate,
+		server.rateLimitMiddleware("transfers", server.config.RateLimitTransfers, defaultTransfersRateLimit))
 
-	authRoutes.POST("/accounts", server.createAccount)
-	authRoutes.GET("/accounts/:id", server.getAccount)
-	authRoutes.GET("/accounts", server.listAccounts)
+	transferRoutes.POST("/transfers", server.createTransfer)
+	transferRoutes.GET("/transfers", server.listTransfers)
+	transferRoutes.GET("/transfers/:id", server.getTransfer)
+	transferRoutes.POST("/transfers/:id/reverse", server.reverseTransfer)
 
-	authRoutes.POST("/transfers", server.createTransfer)
+	transferRoutes.POST("/scheduled-transfers", server.createScheduledTransfer)
+	transferRoutes.GET("/scheduled-transfers", server.listScheduledTransfers)
+	transferRoutes.GET("/scheduled-transfers/:id", server.getScheduledTransfer)
+	transferRoutes.PATCH("/scheduled-transfers/:id", server.updateScheduledTransfer)
+	transferRoutes.DELETE("/scheduled-transfers/:id", server.deleteScheduledTransfer)
+
+	webhookRoutes := router.Group("/", authenticate,
+		server.rateLimitMiddleware("webhooks", server.config.RateLimitWebhooks, defaultWebhooksRateLimit))
+
+	webhookRoutes.POST("/webhooks", server.createWebhook)
+	webhookRoutes.GET("/webhooks", server.listWebhooks)
+	webhookRoutes.DELETE("/webhooks/:id", server.deleteWebhook)
+	webhookRoutes.GET("/webhooks/:id/deliveries", server.listWebhookDeliveries)
+
+	// Admin routes need an access token with the admin role.
+	adminRoutes := router.Group("/admin").Use(
+		authenticate,
+		roleMiddleware(util.AdminRole),
+		server.rateLimitMiddleware("admin", server.config.RateLimitAdmin, defaultAdminRateLimit),
+	)
+
+	adminRoutes.GET("/users", server.adminListUsers)
+	adminRoutes.GET("/accounts", server.adminListAccounts)
+	adminRoutes.POST("/accounts/:id/freeze", server.adminFreezeAccount)
+	adminRoutes.POST("/accounts/:id/unfreeze", server.adminUnfreezeAccount)
+	adminRoutes.PUT("/accounts/:id/overdraft_limit", server.adminSetOverdraftLimit)
+	adminRoutes.GET("/transfers/:id", server.adminGetTransfer)
+	adminRoutes.GET("/reconciliation", server.adminReconcile)
+	adminRoutes.POST("/reconciliation/fix", server.adminFixReconciliation)
 
 	server.router = router
+	return nil
+}
+
+// Dependencies are what the HTTP server is built on besides the config.
+type Dependencies struct {
+	service.Dependencies
+	Activity *activity.Broker
+	Limiter  *ratelimit.Limiter
 }
 
 // NewServer creates a new HTTP server and setup routing.
-func NewServer(config util.Config, store db.Store) (*Server, error) {
+func NewServer(config util.Config, deps Dependencies) (*Server, error) {
 	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
 	if err != nil {
 		return nil, err
@@ -53,12 +131,16 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 
 	server := &Server{
 		config:     config,
-		store:      store,
+		store:      deps.Store,
 		tokenMaker: tokenMaker,
+		currencies: deps.Currencies,
+		activity:   deps.Activity,
+		limiter:    deps.Limiter,
+		bank:       service.NewBank(config, tokenMaker, deps.Dependencies),
 	}
 	// Register the custom validator.
 	if validate, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		err := validate.RegisterValidation("currency", validCurrency)
+		err := validate.RegisterValidation("currency", server.validCurrency)
 		if err != nil {
 			return nil, err
 		}
@@ -66,7 +148,10 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 
 	// Set up the routing of the server.
 	// START //
-	server.setupRouter()
+	err = server.setupRouter()
+	if err != nil {
+		return nil, err
+	}
 	// END //
 
 	return server, nil
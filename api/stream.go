@@ -0,0 +1,59 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"practice-docker/token"
+	"time"
+)
+
+// streamKeepAliveInterval is how often an idle stream sends a ping, so proxies keep the connection open.
+const streamKeepAliveInterval = 15 * time.Second
+
+// GET /accounts/:id/stream
+// The stream starts with an "account" event of the current account, followed by an "activity"
+// event for every entry posted to it. It ends when the client should read the account again.
+func (server *Server) streamAccount(ctx *gin.Context) {
+	var req getAccountRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// subscribe before the account is read, so no activity falls between the two
+	activities, unsubscribe := server.activity.Subscribe(req.ID)
+	defer unsubscribe()
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	account, err := server.bank.GetAccount(ctx, authPayload.Username, req.ID)
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	keepAlive := time.NewTicker(streamKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.SSEvent("account", server.newAccountResponse(ctx, account))
+	ctx.Writer.Flush()
+
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case activity, ok := <-activities:
+			if !ok {
+				return false
+			}
+			ctx.SSEvent("activity", server.newAccountActivityResponse(ctx, account.Currency, activity))
+			return true
+		case <-keepAlive.C:
+			ctx.SSEvent("ping", "")
+			return true
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	mockDB "practice-docker/db/mock"
+	db "practice-docker/db/sqlc"
+	"practice-docker/util"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readEvent reads the next server-sent event and returns its name and data.
+func readEvent(t *testing.T, reader *bufio.Reader) (string, string) {
+	var name, data string
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimRight(line, "\n")
+		if line == "" {
+			return name, data
+		}
+		if strings.HasPrefix(line, "event:") {
+			name = line[len("event:"):]
+		}
+		if strings.HasPrefix(line, "data:") {
+			data = line[len("data:"):]
+		}
+	}
+}
+
+// GET /accounts/:id/stream
+func TestServer_streamAccountAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockDB.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+	server := newTestServer(t, store)
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/accounts/%d/stream", httpServer.URL, account.ID), nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.UserRole, time.Minute)
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+	reader := bufio.NewReader(response.Body)
+
+	// the stream starts with the current account
+	name, data := readEvent(t, reader)
+	require.Equal(t, "account", name)
+
+	var gotAccount db.Accounts
+	require.NoError(t, json.Unmarshal([]byte(data), &gotAccount))
+	require.Equal(t, account.ID, gotAccount.ID)
+	require.Equal(t, account.Balance, gotAccount.Balance)
+
+	// the subscription exists once the account was sent
+	activity := db.AccountActivity{
+		AccountID: account.ID,
+		Entry: db.Entries{
+			ID:        util.RandomInt(1, 1000),
+			AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+			Amount:    25,
+		},
+		Balance: account.Balance + 25,
+	}
+	server.activity.Publish(db.AccountActivity{AccountID: account.ID + 1})
+	server.activity.Publish(activity)
+
+	name, data = readEvent(t, reader)
+	require.Equal(t, "activity", name)
+
+	var got accountActivityResponse
+	require.NoError(t, json.Unmarshal([]byte(data), &got))
+	require.Equal(t, account.ID, got.AccountID)
+	require.Equal(t, activity.Entry.ID, got.Entry.ID)
+	require.Equal(t, activity.Balance, got.Balance)
+
+	// the stream ends when the activity may have been missed
+	server.activity.CloseAll()
+	_, err = reader.ReadString('\n')
+	require.Error(t, err)
+}
+
+// GET /accounts/:id/stream
+func TestServer_streamAccountAPI_Errors(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	account := randomAccount(user1.Username)
+
+	testCases := []struct {
+		name       string
+		username   string
+		buildStubs func(store *mockDB.MockStore)
+		status     int
+	}{
+		{
+			name:     "UnauthorizedUser",
+			username: user2.Username,
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:     "NotFound",
+			username: user1.Username,
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Accounts{}, sql.ErrNoRows)
+			},
+			status: http.StatusNotFound,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockDB.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d/stream", account.ID), nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.UserRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.status, recorder.Code)
+		})
+	}
+}
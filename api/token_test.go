@@ -0,0 +1,302 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	mockDB "practice-docker/db/mock"
+	db "practice-docker/db/sqlc"
+	"practice-docker/token"
+	"practice-docker/util"
+	"testing"
+	"time"
+)
+
+func TestServer_renewAccessTokenAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		tokenType     token.TokenType
+		duration      time.Duration
+		buildSession  func(refreshToken string, payload *token.Payload) db.Sessions
+		buildStubs    func(store *mockDB.MockStore, payload *token.Payload, session db.Sessions)
+		checkResponse func(recorder *httptest.ResponseRecorder, tokenMaker token.Maker)
+	}{
+		{
+			name:      "OK",
+			tokenType: token.TokenTypeRefresh,
+			duration:  time.Minute,
+			buildSession: func(refreshToken string, payload *token.Payload) db.Sessions {
+				return db.Sessions{
+					ID:           payload.ID,
+					Username:     payload.Username,
+					RefreshToken: refreshToken,
+					ExpiresAt:    payload.ExpiredAt,
+				}
+			},
+			buildStubs: func(store *mockDB.MockStore, payload *token.Payload, session db.Sessions) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(session, nil)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(payload.Username)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp renewAccessTokenResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.AccessToken)
+			},
+		},
+		{
+			name:      "RoleChanged",
+			tokenType: token.TokenTypeRefresh,
+			duration:  time.Minute,
+			buildSession: func(refreshToken string, payload *token.Payload) db.Sessions {
+				return db.Sessions{
+					ID:           payload.ID,
+					Username:     payload.Username,
+					RefreshToken: refreshToken,
+					ExpiresAt:    payload.ExpiredAt,
+				}
+			},
+			buildStubs: func(store *mockDB.MockStore, payload *token.Payload, session db.Sessions) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(session, nil)
+
+				// the user was made an admin after the login
+				admin := user
+				admin.Role = util.AdminRole
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(payload.Username)).
+					Times(1).
+					Return(admin, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp renewAccessTokenResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+
+				payload, err := tokenMaker.VerifyToken(rsp.AccessToken)
+				require.NoError(t, err)
+				require.Equal(t, util.AdminRole, payload.Role)
+			},
+		},
+		{
+			name:      "PasswordChanged",
+			tokenType: token.TokenTypeRefresh,
+			duration:  time.Minute,
+			buildSession: func(refreshToken string, payload *token.Payload) db.Sessions {
+				return db.Sessions{
+					ID:           payload.ID,
+					Username:     payload.Username,
+					RefreshToken: refreshToken,
+					ExpiresAt:    payload.ExpiredAt,
+				}
+			},
+			buildStubs: func(store *mockDB.MockStore, payload *token.Payload, session db.Sessions) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(session, nil)
+
+				// the password was changed after the login
+				changed := user
+				changed.PasswordChangedAt = payload.IssuedAt.Add(time.Second)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(payload.Username)).
+					Times(1).
+					Return(changed, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "UserNotFound",
+			tokenType: token.TokenTypeRefresh,
+			duration:  time.Minute,
+			buildSession: func(refreshToken string, payload *token.Payload) db.Sessions {
+				return db.Sessions{
+					ID:           payload.ID,
+					Username:     payload.Username,
+					RefreshToken: refreshToken,
+					ExpiresAt:    payload.ExpiredAt,
+				}
+			},
+			buildStubs: func(store *mockDB.MockStore, payload *token.Payload, session db.Sessions) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(session, nil)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(payload.Username)).
+					Times(1).
+					Return(db.Users{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "ExpiredRefreshToken",
+			tokenType: token.TokenTypeRefresh,
+			duration:  -time.Minute,
+			buildSession: func(refreshToken string, payload *token.Payload) db.Sessions {
+				return db.Sessions{}
+			},
+			buildStubs: func(store *mockDB.MockStore, payload *token.Payload, session db.Sessions) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "AccessToken",
+			tokenType: token.TokenTypeAccess,
+			duration:  time.Minute,
+			buildSession: func(refreshToken string, payload *token.Payload) db.Sessions {
+				return db.Sessions{}
+			},
+			buildStubs: func(store *mockDB.MockStore, payload *token.Payload, session db.Sessions) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "SessionNotFound",
+			tokenType: token.TokenTypeRefresh,
+			duration:  time.Minute,
+			buildSession: func(refreshToken string, payload *token.Payload) db.Sessions {
+				return db.Sessions{}
+			},
+			buildStubs: func(store *mockDB.MockStore, payload *token.Payload, session db.Sessions) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(db.Sessions{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "BlockedSession",
+			tokenType: token.TokenTypeRefresh,
+			duration:  time.Minute,
+			buildSession: func(refreshToken string, payload *token.Payload) db.Sessions {
+				return db.Sessions{
+					ID:           payload.ID,
+					Username:     payload.Username,
+					RefreshToken: refreshToken,
+					IsBlocked:    true,
+					ExpiresAt:    payload.ExpiredAt,
+				}
+			},
+			buildStubs: func(store *mockDB.MockStore, payload *token.Payload, session db.Sessions) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(session, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "MismatchedSessionToken",
+			tokenType: token.TokenTypeRefresh,
+			duration:  time.Minute,
+			buildSession: func(refreshToken string, payload *token.Payload) db.Sessions {
+				return db.Sessions{
+					ID:           payload.ID,
+					Username:     payload.Username,
+					RefreshToken: "other",
+					ExpiresAt:    payload.ExpiredAt,
+				}
+			},
+			buildStubs: func(store *mockDB.MockStore, payload *token.Payload, session db.Sessions) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(session, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "InternalError",
+			tokenType: token.TokenTypeRefresh,
+			duration:  time.Minute,
+			buildSession: func(refreshToken string, payload *token.Payload) db.Sessions {
+				return db.Sessions{}
+			},
+			buildStubs: func(store *mockDB.MockStore, payload *token.Payload, session db.Sessions) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Sessions{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockDB.NewMockStore(ctrl)
+
+			// start test server and issue a refresh token
+			server := newTestServer(t, store)
+			refreshToken, payload, err := server.tokenMaker.CreateToken(user.Username, util.UserRole, tc.tokenType, tc.duration)
+			require.NoError(t, err)
+
+			tc.buildStubs(store, payload, tc.buildSession(refreshToken, payload))
+
+			recorder := httptest.NewRecorder()
+
+			body, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+			require.NoErrorf(t, err, "failed to marshal body: %v", err)
+
+			url := fmt.Sprintf("/tokens/renew_access")
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+			require.NoErrorf(t, err, "failed to create request: %v", err)
+
+			request.Header.Set("Content-Type", "application/json")
+
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder, server.tokenMaker)
+		})
+	}
+}
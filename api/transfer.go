@@ -1,39 +1,15 @@
 package api
 
 import (
-	"database/sql"
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
-	db "practice-docker/db/sqlc"
+	"practice-docker/service"
 	"practice-docker/token"
+	"practice-docker/util"
+	"time"
 )
 
-// validAccount checks if the account exists and is owned by the given user.
-func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Accounts, bool) {
-
-	// check if the account exists
-	account, err := server.store.GetAccount(ctx, accountID)
-	if err != nil {
-		// check if the error is not ErrAccountNotFound
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return account, false
-		}
-
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return account, false
-	}
-
-	// check if the account is in the correct currency
-	if account.Currency != currency {
-		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", accountID, account.Currency, currency)
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
-		return account, false
-	}
-
-	return account, true
-}
+const idempotencyKeyHeader = "Idempotency-Key"
 
 type transferRequest struct {
 	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
@@ -51,39 +27,187 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
-	// check if the from account exists and is owned by the user
-	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
-	if !valid {
+	// the service converts the amount when the to account is in another currency
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.bank.CreateTransfer(ctx, service.CreateTransferParams{
+		Username:       authPayload.Username,
+		FromAccountID:  req.FromAccountID,
+		ToAccountID:    req.ToAccountID,
+		Amount:         req.Amount,
+		Currency:       req.Currency,
+		IdempotencyKey: ctx.GetHeader(idempotencyKeyHeader),
+	})
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	// return the result to the client
+	ctx.JSON(http.StatusOK, server.newTransferTxResponse(ctx, result))
+}
+
+// listTransfersRequest supports offset paging with page_id and keyset paging with cursor.
+// Without page_id the response is a page with a next_cursor.
+type listTransfersRequest struct {
+	Direction             string    `form:"direction" binding:"omitempty,oneof=in out"`
+	CounterpartyAccountID int64     `form:"counterparty_account_id" binding:"omitempty,min=1"`
+	MinAmount             int64     `form:"min_amount" binding:"omitempty,min=1"`
+	MaxAmount             int64     `form:"max_amount" binding:"omitempty,min=1"`
+	FromTime              time.Time `form:"from_time"`
+	ToTime                time.Time `form:"to_time"`
+	PageID                int32     `form:"page_id" binding:"omitempty,min=1"`
+	PageSize              int32     `form:"page_size" binding:"required,min=1,max=100"`
+	Cursor                string    `form:"cursor"`
+}
+
+func (req listTransfersRequest) filter() service.TransferFilter {
+	return service.TransferFilter{
+		Direction:             req.Direction,
+		CounterpartyAccountID: req.CounterpartyAccountID,
+		MinAmount:             req.MinAmount,
+		MaxAmount:             req.MaxAmount,
+		FromTime:              req.FromTime,
+		ToTime:                req.ToTime,
+	}
+}
+
+// GET /transfers
+func (server *Server) listTransfers(ctx *gin.Context) {
+	var req listTransfersRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	err = validatePaging(req.PageID, req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if fromAccount.Owner != authPayload.Username {
-		err := fmt.Errorf("from account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+
+	if req.PageID == 0 {
+		server.listTransfersAfter(ctx, authPayload.Username, req)
+		return
+	}
+
+	// Get the transfers of every account the user owns.
+	transfers, err := server.bank.ListTransfers(ctx, service.ListTransfersParams{
+		Owner:    authPayload.Username,
+		Filter:   req.filter(),
+		PageID:   req.PageID,
+		PageSize: req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
 		return
 	}
 
-	// check if the to account exists and is owned by the user
-	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
-	if !valid {
+	ctx.JSON(http.StatusOK, server.newUserTransfersResponse(ctx, transfers))
+}
+
+// listTransfersAfter returns the page of transfers after the cursor.
+func (server *Server) listTransfersAfter(ctx *gin.Context, owner string, req listTransfersRequest) {
+	cursor, err := decodeCursor(req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	arg := db.TransferTxParams{
-		FromAccountID: req.FromAccountID,
-		ToAccountID:   req.ToAccountID,
-		Amount:        req.Amount,
+	// Fetch one extra row to know if there is a next page.
+	transfers, err := server.bank.ListTransfersAfter(ctx, service.ListTransfersAfterParams{
+		Owner:           owner,
+		Filter:          req.filter(),
+		CursorCreatedAt: cursor.CreatedAt,
+		CursorID:        cursor.ID,
+		Limit:           req.PageSize + 1,
+	})
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	var nextCursor string
+	if len(transfers) > int(req.PageSize) {
+		transfers = transfers[:req.PageSize]
+		last := transfers[len(transfers)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
 	}
 
-	// execute the transfer in the database
-	result, err := server.store.TransferTx(ctx, arg)
+	ctx.JSON(http.StatusOK, pageResponse{
+		Items:      server.newUserTransfersResponse(ctx, transfers),
+		NextCursor: nextCursor,
+	})
+}
+
+type getTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
 
+// GET /transfers/:id
+func (server *Server) getTransfer(ctx *gin.Context) {
+	var req getTransferRequest
+	err := ctx.ShouldBindUri(&req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	// return the result to the client
-	ctx.JSON(http.StatusOK, result)
+	// The user must own the account on either side of the transfer.
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.bank.GetTransfer(ctx, authPayload.Username, req.ID)
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, server.newTransferResponse(
+		ctx,
+		result.Transfer,
+		result.FromAccount.Currency,
+		result.ToAccount.Currency,
+	))
+}
+
+type reverseTransferRequest struct {
+	// Amount is the refund in the currency of the sender. Without it the rest of the transfer is refunded.
+	Amount int64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// POST /transfers/:id/reverse
+func (server *Server) reverseTransfer(ctx *gin.Context) {
+	var uri getTransferRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// The body is optional, it is only needed for a partial refund.
+	var req reverseTransferRequest
+	if ctx.Request.ContentLength != 0 {
+		err := ctx.ShouldBindJSON(&req)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	// Only the recipient gives money back, unless an admin steps in.
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.bank.ReverseTransfer(ctx, service.ReverseTransferParams{
+		Username:       authPayload.Username,
+		IsAdmin:        authPayload.Role == util.AdminRole,
+		TransferID:     uri.ID,
+		Amount:         req.Amount,
+		IdempotencyKey: ctx.GetHeader(idempotencyKeyHeader),
+	})
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, server.newTransferTxResponse(ctx, result))
 }
@@ -1,12 +1,14 @@
 package api
 
 import (
-	"database/sql"
+	"errors"
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
+	"github.com/google/uuid"
 	"net/http"
 	db "practice-docker/db/sqlc"
-	"practice-docker/util"
+	"practice-docker/service"
+	"practice-docker/throttle"
+	"practice-docker/token"
 	"time"
 )
 
@@ -21,6 +23,8 @@ type userResponse struct {
 	Username          string    `json:"username"`
 	FullName          string    `json:"full_name"`
 	Email             string    `json:"email"`
+	Role              string    `json:"role"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 }
@@ -30,6 +34,8 @@ func newUserResponse(user db.Users) userResponse {
 		Username:          user.Username,
 		FullName:          user.FullName,
 		Email:             user.Email,
+		Role:              user.Role,
+		IsEmailVerified:   user.IsEmailVerified,
 		PasswordChangedAt: user.PasswordChangedAt,
 		CreatedAt:         user.CreatedAt,
 	}
@@ -44,49 +50,79 @@ func (server *Server) createUser(ctx *gin.Context) {
 		return
 	}
 
-	// Hash the user's password.
-	hashedPassword, err := util.HashPassword(req.Password)
+	// Create a new user.
+	user, err := server.bank.CreateUser(ctx, service.CreateUserParams{
+		Username: req.Username,
+		Password: req.Password,
+		FullName: req.FullName,
+		Email:    req.Email,
+	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
 		return
 	}
 
-	arg := db.CreateUserParams{
-		Username:       req.Username,
-		HashedPassword: hashedPassword,
-		FullName:       req.FullName,
-		Email:          req.Email,
-	}
+	// Return the user.
+	rsp := newUserResponse(user)
+	ctx.JSON(http.StatusOK, rsp)
+}
 
-	// Create a new user.
-	user, err := server.store.CreateUser(ctx, arg)
+type verifyEmailRequest struct {
+	Username  string `form:"username" binding:"required"`
+	Email     string `form:"email" binding:"required"`
+	ExpiresAt int64  `form:"expires_at" binding:"required"`
+	Signature string `form:"signature" binding:"required"`
+}
+
+// GET /users/verify_email
+func (server *Server) verifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	err := ctx.ShouldBindQuery(&req)
 	if err != nil {
-		// Check if the error is pq.Error.
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-			case "unique_violation": // error code 23505
-				ctx.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			}
-		}
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
 
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	// Check the signed link of the verification mail.
+	user, err := server.bank.VerifyEmail(ctx, service.VerifyEmailParams{
+		Username:  req.Username,
+		Email:     req.Email,
+		ExpiresAt: req.ExpiresAt,
+		Signature: req.Signature,
+	})
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
 		return
 	}
 
-	// Return the user.
 	rsp := newUserResponse(user)
 	ctx.JSON(http.StatusOK, rsp)
 }
 
+// POST /users/verify_email/resend
+func (server *Server) resendVerificationEmail(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	err := server.bank.ResendVerificationEmail(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
+
 type loginUserRequest struct {
 	Username string `json:"username" binding:"required,alphanum"`
 	Password string `json:"password" binding:"required,min=6"`
 }
 
 type loginUserResponse struct {
-	AccessToken string       `json:"access_token"`
-	User        userResponse `json:"user"`
+	SessionID             uuid.UUID    `json:"session_id"`
+	AccessToken           string       `json:"access_token"`
+	AccessTokenExpiresAt  time.Time    `json:"access_token_expires_at"`
+	RefreshToken          string       `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
+	User                  userResponse `json:"user"`
 }
 
 // POST /users/login
@@ -98,39 +134,108 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
-	// Get the user by username.
-	user, err := server.store.GetUser(ctx, req.Username)
+	// Check the password and start a session.
+	result, err := server.bank.LoginUser(ctx, service.LoginUserParams{
+		Username:  req.Username,
+		Password:  req.Password,
+		UserAgent: ctx.Request.UserAgent(),
+		ClientIP:  ctx.ClientIP(),
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
+		// an unknown username has always been a bad request over HTTP
+		if service.ErrorCode(err) == service.CodeNotFound {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+
+		// tell a throttled client when it may try again, in whole seconds
+		var throttled *throttle.ThrottledError
+		if errors.As(err, &throttled) {
+			ctx.Header("Retry-After", seconds(throttled.RetryAfter))
+		}
+
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	// Return the tokens and the user.
+	rsp := loginUserResponse{
+		SessionID:             result.Session.ID,
+		AccessToken:           result.AccessToken,
+		AccessTokenExpiresAt:  result.AccessPayload.ExpiredAt,
+		RefreshToken:          result.RefreshToken,
+		RefreshTokenExpiresAt: result.RefreshPayload.ExpiredAt,
+		User:                  newUserResponse(result.User),
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type logoutUserRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// POST /users/logout
+func (server *Server) logoutUser(ctx *gin.Context) {
+	// The body is optional, it is only needed to end the refresh session as well.
+	var req logoutUserRequest
+	if ctx.Request.ContentLength != 0 {
+		err := ctx.ShouldBindJSON(&req)
+		if err != nil {
 			ctx.JSON(http.StatusBadRequest, errorResponse(err))
 			return
 		}
+	}
 
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	// Revoke the access token used for this request, and the refresh token with its session if given.
+	err := server.bank.Logout(ctx, authPayload, req.RefreshToken)
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
 		return
 	}
 
-	// Check if the password is correct.
-	err = util.CheckPassword(req.Password, user.HashedPassword)
+	ctx.Status(http.StatusNoContent)
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required,min=6"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+// PUT /users/me/password
+func (server *Server) changePassword(ctx *gin.Context) {
+	var req changePasswordRequest
+	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	// Generate access token.
-	accessToken, err := server.tokenMaker.CreateToken(
-		user.Username,
-		server.config.AccessTokenLifetime,
-	)
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	// Check the current password, store the new one and start a new session.
+	result, err := server.bank.ChangePassword(ctx, service.ChangePasswordParams{
+		Username:        authPayload.Username,
+		CurrentPassword: req.CurrentPassword,
+		NewPassword:     req.NewPassword,
+		UserAgent:       ctx.Request.UserAgent(),
+		ClientIP:        ctx.ClientIP(),
+	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
 		return
 	}
 
-	// Return the access token and the user.
+	// Return the tokens of the new session and the user.
 	rsp := loginUserResponse{
-		AccessToken: accessToken,
-		User:        newUserResponse(user),
+		SessionID:             result.Session.ID,
+		AccessToken:           result.AccessToken,
+		AccessTokenExpiresAt:  result.AccessPayload.ExpiredAt,
+		RefreshToken:          result.RefreshToken,
+		RefreshTokenExpiresAt: result.RefreshPayload.ExpiredAt,
+		User:                  newUserResponse(result.User),
 	}
 
 	ctx.JSON(http.StatusOK, rsp)
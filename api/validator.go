@@ -1,13 +1,14 @@
 package api
 
 import (
+	"context"
 	"github.com/go-playground/validator/v10"
-	"practice-docker/util"
 )
 
-var validCurrency validator.Func = func(fl validator.FieldLevel) bool {
+// validCurrency checks the currency against the cached currencies table.
+func (server *Server) validCurrency(fl validator.FieldLevel) bool {
 	if currency, ok := fl.Field().Interface().(string); ok {
-		return util.IsSupportedCurrency(currency)
+		return server.currencies.IsSupported(context.Background(), currency)
 	}
 	return false
 }
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	db "practice-docker/db/sqlc"
+	"practice-docker/service"
+	"practice-docker/token"
+	"time"
+)
+
+type createWebhookRequest struct {
+	URL string `json:"url" binding:"required,url"`
+}
+
+type webhookResponse struct {
+	ID        int64     `json:"id"`
+	Owner     string    `json:"owner"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// createWebhookResponse is the only response that contains the secret.
+type createWebhookResponse struct {
+	webhookResponse
+	Secret string `json:"secret"`
+}
+
+func newWebhookResponse(webhook db.Webhooks) webhookResponse {
+	return webhookResponse{
+		ID:        webhook.ID,
+		Owner:     webhook.Owner,
+		URL:       webhook.Url,
+		CreatedAt: webhook.CreatedAt,
+	}
+}
+
+// POST /webhooks
+func (server *Server) createWebhook(ctx *gin.Context) {
+	var req createWebhookRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	webhook, err := server.bank.CreateWebhook(ctx, authPayload.Username, req.URL)
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, createWebhookResponse{
+		webhookResponse: newWebhookResponse(webhook),
+		Secret:          webhook.Secret,
+	})
+}
+
+type listWebhooksRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=1,max=100"`
+}
+
+// GET /webhooks
+func (server *Server) listWebhooks(ctx *gin.Context) {
+	var req listWebhooksRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	webhooks, err := server.bank.ListWebhooks(ctx, service.ListWebhooksParams{
+		Owner:    authPayload.Username,
+		PageID:   req.PageID,
+		PageSize: req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	rsp := make([]webhookResponse, len(webhooks))
+	for i, webhook := range webhooks {
+		rsp[i] = newWebhookResponse(webhook)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type getWebhookRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// DELETE /webhooks/:id
+func (server *Server) deleteWebhook(ctx *gin.Context) {
+	var req getWebhookRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// pending deliveries are deleted with the webhook
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	err = server.bank.DeleteWebhook(ctx, authPayload.Username, req.ID)
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+type listWebhookDeliveriesRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=1,max=100"`
+}
+
+// GET /webhooks/:id/deliveries
+func (server *Server) listWebhookDeliveries(ctx *gin.Context) {
+	var uri getWebhookRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listWebhookDeliveriesRequest
+	err = ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the newest deliveries come first
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	deliveries, err := server.bank.ListWebhookDeliveries(ctx, service.ListWebhookDeliveriesParams{
+		Username:  authPayload.Username,
+		WebhookID: uri.ID,
+		PageID:    req.PageID,
+		PageSize:  req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(serviceErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, deliveries)
+}
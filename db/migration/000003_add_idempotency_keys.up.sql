@@ -0,0 +1,12 @@
+CREATE TABLE idempotency_keys
+(
+    username        varchar     NOT NULL,
+    idempotency_key varchar     NOT NULL,
+    request_hash    varchar     NOT NULL,
+    response        jsonb       NOT NULL DEFAULT '{}',
+    created_at      timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (username, idempotency_key)
+);
+
+ALTER TABLE idempotency_keys
+    ADD FOREIGN KEY (username) REFERENCES users (username);
@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS accounts DROP CONSTRAINT IF EXISTS accounts_balance_check;
+
+ALTER TABLE IF EXISTS accounts DROP CONSTRAINT IF EXISTS accounts_overdraft_limit_check;
+
+ALTER TABLE IF EXISTS accounts DROP COLUMN IF EXISTS overdraft_limit;
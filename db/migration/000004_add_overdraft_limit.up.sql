@@ -0,0 +1,14 @@
+ALTER TABLE accounts
+    ADD COLUMN overdraft_limit bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE accounts
+    ADD CONSTRAINT accounts_overdraft_limit_check CHECK (overdraft_limit >= 0);
+
+-- accounts that are already overdrawn get a limit that covers their balance,
+-- otherwise every update of the row, credits included, would fail the check below
+UPDATE accounts
+SET overdraft_limit = -balance
+WHERE balance < 0;
+
+ALTER TABLE accounts
+    ADD CONSTRAINT accounts_balance_check CHECK (balance >= -overdraft_limit);
@@ -0,0 +1,7 @@
+ALTER TABLE IF EXISTS transfers DROP CONSTRAINT IF EXISTS transfers_exchange_rate_check;
+
+ALTER TABLE IF EXISTS transfers DROP COLUMN IF EXISTS destination_amount;
+
+ALTER TABLE IF EXISTS transfers DROP COLUMN IF EXISTS source_amount;
+
+ALTER TABLE IF EXISTS transfers DROP COLUMN IF EXISTS exchange_rate;
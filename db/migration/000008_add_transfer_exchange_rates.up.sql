@@ -0,0 +1,17 @@
+-- amount stays the amount debited from the source account for older clients.
+ALTER TABLE transfers
+    ADD COLUMN exchange_rate      numeric(20, 8) NOT NULL DEFAULT 1,
+    ADD COLUMN source_amount      bigint,
+    ADD COLUMN destination_amount bigint;
+
+-- every transfer before this migration was between accounts of the same currency
+UPDATE transfers
+SET source_amount      = amount,
+    destination_amount = amount;
+
+ALTER TABLE transfers
+    ALTER COLUMN source_amount SET NOT NULL,
+    ALTER COLUMN destination_amount SET NOT NULL;
+
+ALTER TABLE transfers
+    ADD CONSTRAINT transfers_exchange_rate_check CHECK (exchange_rate > 0);
@@ -0,0 +1,19 @@
+CREATE TABLE currencies
+(
+    code       varchar(3) PRIMARY KEY,
+    -- number of minor-unit decimal places from ISO 4217, e.g. 2 for USD and 0 for JPY
+    exponent   integer     NOT NULL CHECK (exponent >= 0 AND exponent <= 4),
+    enabled    boolean     NOT NULL DEFAULT true,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- JPY and KWD are known but must be enabled before accounts can be opened in them.
+INSERT INTO currencies (code, exponent, enabled)
+VALUES ('USD', 2, true),
+       ('EUR', 2, true),
+       ('CAD', 2, true),
+       ('JPY', 0, false),
+       ('KWD', 3, false);
+
+ALTER TABLE accounts
+    ADD CONSTRAINT accounts_currency_fkey FOREIGN KEY (currency) REFERENCES currencies (code);
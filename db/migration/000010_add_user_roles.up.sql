@@ -0,0 +1,9 @@
+ALTER TABLE users
+    ADD COLUMN role varchar NOT NULL DEFAULT 'user';
+
+ALTER TABLE users
+    ADD CONSTRAINT users_role_check CHECK (role IN ('user', 'admin'));
+
+-- admins can freeze an account to stop money moving in or out of it
+ALTER TABLE accounts
+    ADD COLUMN is_frozen boolean NOT NULL DEFAULT false;
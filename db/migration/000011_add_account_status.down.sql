@@ -0,0 +1,12 @@
+ALTER TABLE IF EXISTS accounts DROP CONSTRAINT IF EXISTS accounts_closed_balance_check;
+
+ALTER TABLE IF EXISTS accounts
+    ADD COLUMN IF NOT EXISTS is_frozen boolean NOT NULL DEFAULT false;
+
+UPDATE accounts
+SET is_frozen = true
+WHERE status = 'frozen';
+
+ALTER TABLE IF EXISTS accounts DROP COLUMN IF EXISTS status;
+
+DROP TYPE IF EXISTS account_status;
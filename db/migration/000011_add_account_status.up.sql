@@ -0,0 +1,16 @@
+CREATE TYPE account_status AS ENUM ('active', 'frozen', 'closed');
+
+ALTER TABLE accounts
+    ADD COLUMN status account_status NOT NULL DEFAULT 'active';
+
+-- is_frozen is replaced by the frozen status
+UPDATE accounts
+SET status = 'frozen'
+WHERE is_frozen;
+
+ALTER TABLE accounts
+    DROP COLUMN is_frozen;
+
+-- only an empty account can be closed, and a closed account cannot receive money
+ALTER TABLE accounts
+    ADD CONSTRAINT accounts_closed_balance_check CHECK (status <> 'closed' OR balance = 0);
@@ -0,0 +1,5 @@
+-- a reversal is a compensating transfer that points at the transfer it refunds
+ALTER TABLE transfers
+    ADD COLUMN reversal_of bigint REFERENCES transfers (id);
+
+CREATE INDEX ON transfers (reversal_of);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS scheduled_transfers;
+
+DROP TYPE IF EXISTS transfer_frequency;
@@ -0,0 +1,26 @@
+CREATE TYPE transfer_frequency AS ENUM ('once', 'daily', 'weekly', 'monthly');
+
+create table "scheduled_transfers"
+(
+    "id"               bigserial PRIMARY KEY,
+    "owner"            varchar            NOT NULL REFERENCES users (username),
+    "from_account_id"  bigint             NOT NULL REFERENCES accounts (id),
+    "to_account_id"    bigint             NOT NULL REFERENCES accounts (id),
+    "amount"           bigint             NOT NULL CHECK (amount > 0),
+    "frequency"        transfer_frequency NOT NULL,
+    -- occurrences are counted from start_at, so a monthly order on the 31st stays at the end of the month
+    "start_at"         timestamptz        NOT NULL,
+    "next_run_at"      timestamptz        NOT NULL,
+    "end_at"           timestamptz,
+    "run_count"        integer            NOT NULL DEFAULT 0,
+    "is_active"        boolean            NOT NULL DEFAULT true,
+    "last_run_at"      timestamptz,
+    "last_transfer_id" bigint REFERENCES transfers (id),
+    "last_error"       varchar,
+    "created_at"       timestamptz        NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON scheduled_transfers (owner);
+
+-- the worker only looks at active schedules that are due
+CREATE INDEX ON scheduled_transfers (next_run_at) WHERE is_active;
@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS entries DROP COLUMN IF EXISTS is_correction;
+
+ALTER TABLE IF EXISTS entries DROP COLUMN IF EXISTS transfer_id;
@@ -0,0 +1,14 @@
+-- entries of a transfer point at it, correcting entries posted by reconciliation are flagged
+ALTER TABLE entries
+    ADD COLUMN transfer_id   bigint REFERENCES transfers (id),
+    ADD COLUMN is_correction boolean NOT NULL DEFAULT false;
+
+-- both entries of a transfer were created in its transaction, so they share its created_at
+UPDATE entries e
+SET transfer_id = t.id
+FROM transfers t
+WHERE e.created_at = t.created_at
+  AND ((e.account_id = t.from_account_id AND e.amount = -t.source_amount)
+    OR (e.account_id = t.to_account_id AND e.amount = t.destination_amount));
+
+CREATE INDEX ON entries (transfer_id);
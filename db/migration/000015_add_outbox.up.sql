@@ -0,0 +1,15 @@
+-- events are written in the transaction of the change they describe and published by a relay
+create table "outbox"
+(
+    "id"              bigserial PRIMARY KEY,
+    "event_type"      varchar     NOT NULL,
+    "payload"         jsonb       NOT NULL,
+    "attempts"        integer     NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz NOT NULL DEFAULT (now()),
+    "last_error"      varchar,
+    "published_at"    timestamptz,
+    "created_at"      timestamptz NOT NULL DEFAULT (now())
+);
+
+-- the relay only looks at events that were not published yet
+CREATE INDEX ON outbox (next_attempt_at) WHERE published_at IS NULL;
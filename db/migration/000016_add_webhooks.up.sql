@@ -0,0 +1,33 @@
+CREATE TYPE webhook_delivery_status AS ENUM ('pending', 'succeeded', 'failed');
+
+create table "webhooks"
+(
+    "id"         bigserial PRIMARY KEY,
+    "owner"      varchar     NOT NULL REFERENCES users (username),
+    "url"        varchar     NOT NULL,
+    -- payloads are signed with the secret, so it has to be kept as it is
+    "secret"     varchar     NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON webhooks (owner);
+
+create table "webhook_deliveries"
+(
+    "id"              bigserial PRIMARY KEY,
+    "webhook_id"      bigint                  NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    "event_type"      varchar                 NOT NULL,
+    "payload"         jsonb                   NOT NULL,
+    "status"          webhook_delivery_status NOT NULL DEFAULT 'pending',
+    "attempts"        integer                 NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz             NOT NULL DEFAULT (now()),
+    "response_status" integer,
+    "last_error"      varchar,
+    "delivered_at"    timestamptz,
+    "created_at"      timestamptz             NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON webhook_deliveries (webhook_id);
+
+-- the dispatcher only looks at deliveries that are still pending
+CREATE INDEX ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,12 @@
+-- only the sha256 of a reset token is stored, the token itself is mailed to the user
+create table "password_resets"
+(
+    "id"         bigserial PRIMARY KEY,
+    "username"   varchar     NOT NULL REFERENCES users (username),
+    "token_hash" varchar     NOT NULL UNIQUE,
+    "expires_at" timestamptz NOT NULL,
+    "used_at"    timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON password_resets (username);
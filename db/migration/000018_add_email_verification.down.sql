@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS users_lower_email_key;
+
+ALTER TABLE IF EXISTS users DROP COLUMN IF EXISTS is_email_verified;
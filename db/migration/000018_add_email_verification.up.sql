@@ -0,0 +1,27 @@
+-- an email can only belong to one user, whatever its case
+-- users that already share an email in another case have to be resolved by hand first,
+-- the migration fails before it changes anything and lists their emails
+DO
+$$
+    DECLARE
+        duplicates text;
+    BEGIN
+        SELECT string_agg(email, ', ')
+        INTO duplicates
+        FROM (SELECT lower(email) AS email
+              FROM users
+              GROUP BY lower(email)
+              HAVING count(*) > 1
+              ORDER BY lower(email)) AS d;
+
+        IF duplicates IS NOT NULL THEN
+            RAISE EXCEPTION 'cannot add users_lower_email_key, these emails belong to more than one user when case is ignored: %', duplicates
+                USING HINT = 'change the email of all but one user of each before running the migration again';
+        END IF;
+    END
+$$;
+
+ALTER TABLE users
+    ADD COLUMN is_email_verified boolean NOT NULL DEFAULT false;
+
+CREATE UNIQUE INDEX users_lower_email_key ON users (lower(email));
@@ -0,0 +1,12 @@
+-- failed logins are counted per "username:<name>" and per "ip:<address>" key
+-- an attempt is counted before the password is checked and taken back when it succeeds,
+-- previous_failure_at is the last failure before the latest attempt, which the attempt is judged by
+create table "login_failures"
+(
+    "key"                 varchar PRIMARY KEY,
+    "failures"            integer     NOT NULL,
+    "last_failure_at"     timestamptz NOT NULL,
+    "previous_failure_at" timestamptz
+);
+
+CREATE INDEX ON login_failures (last_failure_at);
@@ -0,0 +1,9 @@
+-- token buckets of the rate limit, per "<route group>:user:<name>" and "<route group>:ip:<address>" key
+create table "rate_limit_buckets"
+(
+    "key"        varchar PRIMARY KEY,
+    "tokens"     double precision NOT NULL,
+    "updated_at" timestamptz      NOT NULL
+);
+
+CREATE INDEX ON rate_limit_buckets (updated_at);
@@ -14,6 +14,15 @@ WHERE owner = $1
 ORDER BY id LIMIT $2
 OFFSET $3;
 
+-- name: GetAccountsAfter :many
+-- Keyset pagination: returns the accounts created after the (created_at, id) cursor.
+SELECT *
+FROM accounts
+WHERE owner = @owner
+  AND (created_at, id) > (@cursor_created_at::timestamptz, @cursor_id::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg(limit);
+
 -- name: GetAccountForUpdate :one
 SELECT *
 FROM accounts
@@ -35,3 +44,25 @@ WHERE id = @id RETURNING *;
 DELETE
 FROM accounts
 WHERE id = $1;
+
+-- name: ListAllAccounts :many
+-- Lists the accounts of every owner for admins.
+SELECT *
+FROM accounts
+ORDER BY id
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: UpdateAccountStatus :one
+-- Changes the status only if it is still the expected one, so a concurrent change is not overwritten.
+UPDATE accounts
+SET status = @status
+WHERE id = @id
+  AND status = @current_status
+RETURNING *;
+
+-- name: UpdateAccountOverdraftLimit :one
+-- Sets how far below zero the balance of the account may go.
+UPDATE accounts
+SET overdraft_limit = @overdraft_limit
+WHERE id = @id
+RETURNING *;
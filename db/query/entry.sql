@@ -1,6 +1,6 @@
 -- name: CreateEntry :one
-INSERT INTO entries (account_id, amount)
-VALUES ($1, $2)
+INSERT INTO entries (account_id, amount, transfer_id, is_correction)
+VALUES ($1, $2, $3, $4)
 RETURNING *;
 
 -- name: GetEntry :one
@@ -15,3 +15,43 @@ FROM entries
 WHERE account_id = $1
 ORDER BY id
 LIMIT $2 OFFSET $3;
+
+-- name: ListAccountStatement :many
+-- running_balance is the account balance right after the entry. It is computed
+-- backwards from the current balance, so opening balances are included.
+SELECT id, account_id, amount, running_balance, created_at
+FROM (SELECT e.id,
+             e.account_id,
+             e.amount,
+             (a.balance - COALESCE(SUM(e.amount) OVER (
+                 ORDER BY e.created_at DESC, e.id DESC
+                 ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+                 ), 0))::bigint AS running_balance,
+             e.created_at
+      FROM entries e
+               JOIN accounts a ON a.id = e.account_id
+      WHERE e.account_id = @account_id) AS statement
+WHERE (sqlc.narg(from_time)::timestamptz IS NULL OR created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamptz IS NULL OR created_at < sqlc.narg(to_time))
+ORDER BY created_at, id
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: ListAccountStatementAfter :many
+-- Keyset pagination of ListAccountStatement after the (created_at, id) cursor.
+SELECT id, account_id, amount, running_balance, created_at
+FROM (SELECT e.id,
+             e.account_id,
+             e.amount,
+             (a.balance - COALESCE(SUM(e.amount) OVER (
+                 ORDER BY e.created_at DESC, e.id DESC
+                 ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+                 ), 0))::bigint AS running_balance,
+             e.created_at
+      FROM entries e
+               JOIN accounts a ON a.id = e.account_id
+      WHERE e.account_id = @account_id) AS statement
+WHERE (sqlc.narg(from_time)::timestamptz IS NULL OR created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamptz IS NULL OR created_at < sqlc.narg(to_time))
+  AND (created_at, id) > (@cursor_created_at::timestamptz, @cursor_id::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg(limit);
@@ -0,0 +1,37 @@
+-- name: GetLoginFailures :one
+SELECT *
+FROM login_failures
+WHERE key = $1
+LIMIT 1;
+
+-- name: RecordLoginAttempt :one
+-- Counts an attempt of the key as a failure, starting again at one when the last failure is older than the window.
+-- The row is locked while it is updated, so concurrent attempts are counted one after the other.
+INSERT INTO login_failures (key, failures, last_failure_at)
+VALUES (sqlc.arg(key), 1, sqlc.arg(now))
+ON CONFLICT (key) DO UPDATE
+    SET failures            = CASE
+                                  WHEN login_failures.last_failure_at < sqlc.arg(window_start) THEN 1
+                                  ELSE login_failures.failures + 1
+        END,
+        previous_failure_at = login_failures.last_failure_at,
+        last_failure_at     = EXCLUDED.last_failure_at
+RETURNING *;
+
+-- name: TakeBackLoginAttempt :exec
+-- Uncounts an attempt of the key that was throttled or succeeded, the last failure is the one before it again.
+UPDATE login_failures
+SET failures        = failures - 1,
+    last_failure_at = COALESCE(previous_failure_at, last_failure_at)
+WHERE key = $1
+  AND failures > 0;
+
+-- name: DeleteLoginFailures :exec
+DELETE
+FROM login_failures
+WHERE key = $1;
+
+-- name: DeleteStaleLoginFailures :execrows
+DELETE
+FROM login_failures
+WHERE last_failure_at < $1;
@@ -0,0 +1,3 @@
+-- name: Notify :exec
+-- Notifies the listeners of the channel. Inside a transaction the notification is sent on commit.
+SELECT pg_notify(@channel::text, @payload::text);
@@ -0,0 +1,33 @@
+-- name: ClaimDueOutboxEvent :one
+-- Claims the oldest unpublished event that is due by moving its next attempt to the end of the lease.
+-- Other relays skip it while it is published, and it is retried when the outcome is never recorded.
+UPDATE outbox
+SET next_attempt_at = @lease_until
+WHERE id = (SELECT id
+            FROM outbox
+            WHERE published_at IS NULL
+              AND next_attempt_at <= @now
+            ORDER BY next_attempt_at, id
+            LIMIT 1 FOR UPDATE SKIP LOCKED)
+RETURNING *;
+
+-- name: CreateOutboxEvent :one
+INSERT INTO outbox (event_type, payload)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetOutboxEvent :one
+SELECT *
+FROM outbox
+WHERE id = $1
+LIMIT 1;
+
+-- name: UpdateOutboxEventDelivery :one
+-- Records the outcome of a delivery attempt.
+UPDATE outbox
+SET attempts        = $2,
+    next_attempt_at = $3,
+    last_error      = $4,
+    published_at    = $5
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,20 @@
+-- name: CreatePasswordReset :one
+INSERT INTO password_resets (username, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: UsePasswordReset :one
+-- Marks the reset as used, unless it was used before or has expired.
+UPDATE password_resets
+SET used_at = sqlc.arg(now)
+WHERE token_hash = sqlc.arg(token_hash)
+  AND used_at IS NULL
+  AND expires_at > sqlc.arg(now)
+RETURNING *;
+
+-- name: ExpirePasswordResets :exec
+-- Marks every unused reset of the user as used, so older tokens can't be used after a reset.
+UPDATE password_resets
+SET used_at = sqlc.arg(now)
+WHERE username = sqlc.arg(username)
+  AND used_at IS NULL;
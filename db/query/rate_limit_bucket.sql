@@ -0,0 +1,23 @@
+-- name: GetRateLimitBucket :one
+SELECT *
+FROM rate_limit_buckets
+WHERE key = $1
+LIMIT 1;
+
+-- name: TakeRateLimitToken :one
+-- Refills the bucket of the key for the time since it was last updated and takes a token.
+-- No row is returned when less than one token is left, the bucket is left as it was then.
+INSERT INTO rate_limit_buckets (key, tokens, updated_at)
+VALUES (sqlc.arg(key), sqlc.arg(burst)::float8 - 1, sqlc.arg(now))
+ON CONFLICT (key) DO UPDATE
+    SET tokens     = LEAST(sqlc.arg(burst)::float8, rate_limit_buckets.tokens + sqlc.arg(rate)::float8 *
+                           GREATEST(EXTRACT(EPOCH FROM EXCLUDED.updated_at - rate_limit_buckets.updated_at)::float8, 0)) - 1,
+        updated_at = EXCLUDED.updated_at
+    WHERE LEAST(sqlc.arg(burst)::float8, rate_limit_buckets.tokens + sqlc.arg(rate)::float8 *
+                GREATEST(EXTRACT(EPOCH FROM EXCLUDED.updated_at - rate_limit_buckets.updated_at)::float8, 0)) >= 1
+RETURNING *;
+
+-- name: DeleteStaleRateLimitBuckets :execrows
+DELETE
+FROM rate_limit_buckets
+WHERE updated_at < $1;
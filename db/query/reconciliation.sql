@@ -0,0 +1,42 @@
+-- name: ListAccountBalanceDrift :many
+-- Lists the accounts whose balance is not the sum of their entries.
+SELECT a.id                                             AS account_id,
+       a.owner,
+       a.currency,
+       a.balance,
+       COALESCE(SUM(e.amount), 0)::bigint               AS entries_total,
+       (a.balance - COALESCE(SUM(e.amount), 0))::bigint AS drift
+FROM accounts a
+         LEFT JOIN entries e ON e.account_id = a.id
+GROUP BY a.id
+HAVING a.balance <> COALESCE(SUM(e.amount), 0)
+ORDER BY a.id;
+
+-- name: ListOrphanEntries :many
+-- Lists the entries that belong to no account, or to no transfer without being a correction.
+SELECT *
+FROM entries
+WHERE account_id IS NULL
+   OR (transfer_id IS NULL AND NOT is_correction)
+ORDER BY id;
+
+-- name: ListUnmatchedTransfers :many
+-- Lists the transfers that do not have exactly one debit and one credit entry for their amounts.
+SELECT t.id,
+       t.from_account_id,
+       t.to_account_id,
+       t.source_amount,
+       t.destination_amount,
+       COUNT(e.id) AS matching_entries
+FROM transfers t
+         LEFT JOIN entries e ON e.transfer_id = t.id
+    AND ((e.account_id = t.from_account_id AND e.amount = -t.source_amount)
+        OR (e.account_id = t.to_account_id AND e.amount = t.destination_amount))
+GROUP BY t.id
+HAVING COUNT(e.id) <> 2
+ORDER BY t.id;
+
+-- name: GetAccountEntriesTotal :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM entries
+WHERE account_id = @account_id::bigint;
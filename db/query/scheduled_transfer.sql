@@ -0,0 +1,55 @@
+-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers (owner, from_account_id, to_account_id, amount, frequency, start_at, next_run_at,
+                                 end_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetScheduledTransfer :one
+SELECT *
+FROM scheduled_transfers
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetDueScheduledTransferForUpdate :one
+-- Claims the next due schedule. Rows locked by another worker are skipped instead of waited for.
+SELECT *
+FROM scheduled_transfers
+WHERE is_active
+  AND next_run_at <= @now
+ORDER BY next_run_at
+LIMIT 1 FOR UPDATE SKIP LOCKED;
+
+-- name: ListScheduledTransfers :many
+SELECT *
+FROM scheduled_transfers
+WHERE owner = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
+
+-- name: UpdateScheduledTransfer :one
+-- Changes the amount, end or pause state of a schedule, or skips it ahead to a later occurrence. Every field is optional.
+UPDATE scheduled_transfers
+SET amount      = COALESCE(sqlc.narg(amount), amount),
+    end_at      = COALESCE(sqlc.narg(end_at), end_at),
+    is_active   = COALESCE(sqlc.narg(is_active), is_active),
+    next_run_at = COALESCE(sqlc.narg(next_run_at), next_run_at),
+    run_count   = COALESCE(sqlc.narg(run_count), run_count)
+WHERE id = @id
+RETURNING *;
+
+-- name: UpdateScheduledTransferRun :one
+-- Records the outcome of an occurrence and moves the schedule to the next one.
+UPDATE scheduled_transfers
+SET next_run_at      = $2,
+    run_count        = $3,
+    is_active        = $4,
+    last_run_at      = $5,
+    last_transfer_id = $6,
+    last_error       = $7
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteScheduledTransfer :exec
+DELETE
+FROM scheduled_transfers
+WHERE id = $1;
@@ -1,6 +1,7 @@
 -- name: CreateTransfer :one
-INSERT INTO transfers (from_account_id, to_account_id, amount)
-VALUES ($1, $2, $3)
+INSERT INTO transfers (from_account_id, to_account_id, amount, exchange_rate, source_amount, destination_amount,
+                       reversal_of)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 RETURNING *;
 
 -- name: GetTransfer :one
@@ -9,10 +10,71 @@ FROM transfers
 WHERE id = $1
 LIMIT 1;
 
+-- name: GetTransferForUpdate :one
+-- Locks the transfer so that concurrent reversals of it are serialized.
+SELECT *
+FROM transfers
+WHERE id = $1
+LIMIT 1
+FOR UPDATE;
+
+-- name: GetTransferReversalTotals :one
+-- Sums the amounts already moved back by reversals of the transfer.
+SELECT COALESCE(SUM(source_amount), 0)::bigint      AS source_amount,
+       COALESCE(SUM(destination_amount), 0)::bigint AS destination_amount
+FROM transfers
+WHERE reversal_of = @transfer_id::bigint;
+
 -- name: ListTransfers :many
 SELECT *
 FROM transfers
-WHERE from_account_id = $1
-   OR to_account_id = $2
+WHERE from_account_id = @account_id
+   OR to_account_id = @account_id
 ORDER BY id
-LIMIT $3 OFFSET $4;
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: ListUserTransfers :many
+-- Lists the transfers touching any account of the owner. Every filter is optional.
+-- The amount filters compare the amount in the currency of the owner's side, which is the destination amount of an incoming transfer.
+SELECT t.*, fa.currency AS from_currency, ta.currency AS to_currency
+FROM transfers t
+         JOIN accounts fa ON fa.id = t.from_account_id
+         JOIN accounts ta ON ta.id = t.to_account_id
+WHERE (fa.owner = @owner OR ta.owner = @owner)
+  AND (sqlc.narg(direction)::varchar IS NULL
+    OR (sqlc.narg(direction) = 'out' AND fa.owner = @owner)
+    OR (sqlc.narg(direction) = 'in' AND ta.owner = @owner))
+  AND (sqlc.narg(counterparty_account_id)::bigint IS NULL
+    OR (fa.owner = @owner AND t.to_account_id = sqlc.narg(counterparty_account_id))
+    OR (ta.owner = @owner AND t.from_account_id = sqlc.narg(counterparty_account_id)))
+  AND (sqlc.narg(min_amount)::bigint IS NULL
+    OR CASE WHEN sqlc.narg(direction) = 'in' OR fa.owner <> @owner THEN t.destination_amount ELSE t.amount END >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL
+    OR CASE WHEN sqlc.narg(direction) = 'in' OR fa.owner <> @owner THEN t.destination_amount ELSE t.amount END <= sqlc.narg(max_amount))
+  AND (sqlc.narg(from_time)::timestamptz IS NULL OR t.created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamptz IS NULL OR t.created_at < sqlc.narg(to_time))
+ORDER BY t.created_at, t.id
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: ListUserTransfersAfter :many
+-- Keyset pagination of ListUserTransfers after the (created_at, id) cursor.
+SELECT t.*, fa.currency AS from_currency, ta.currency AS to_currency
+FROM transfers t
+         JOIN accounts fa ON fa.id = t.from_account_id
+         JOIN accounts ta ON ta.id = t.to_account_id
+WHERE (fa.owner = @owner OR ta.owner = @owner)
+  AND (sqlc.narg(direction)::varchar IS NULL
+    OR (sqlc.narg(direction) = 'out' AND fa.owner = @owner)
+    OR (sqlc.narg(direction) = 'in' AND ta.owner = @owner))
+  AND (sqlc.narg(counterparty_account_id)::bigint IS NULL
+    OR (fa.owner = @owner AND t.to_account_id = sqlc.narg(counterparty_account_id))
+    OR (ta.owner = @owner AND t.from_account_id = sqlc.narg(counterparty_account_id)))
+  AND (sqlc.narg(min_amount)::bigint IS NULL
+    OR CASE WHEN sqlc.narg(direction) = 'in' OR fa.owner <> @owner THEN t.destination_amount ELSE t.amount END >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL
+    OR CASE WHEN sqlc.narg(direction) = 'in' OR fa.owner <> @owner THEN t.destination_amount ELSE t.amount END <= sqlc.narg(max_amount))
+  AND (sqlc.narg(from_time)::timestamptz IS NULL OR t.created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamptz IS NULL OR t.created_at < sqlc.narg(to_time))
+  AND (t.created_at, t.id) > (@cursor_created_at::timestamptz, @cursor_id::bigint)
+ORDER BY t.created_at, t.id
+LIMIT sqlc.arg(limit);
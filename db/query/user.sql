@@ -8,3 +8,39 @@ SELECT *
 FROM users
 WHERE username = $1
 LIMIT 1;
+
+-- name: GetUserByEmail :one
+SELECT *
+FROM users
+WHERE lower(email) = lower(sqlc.arg(email))
+ORDER BY created_at
+LIMIT 1;
+
+-- name: GetUserPasswordChangedAt :one
+-- Only reads when the password changed, it is checked for every authenticated request.
+SELECT password_changed_at
+FROM users
+WHERE username = $1
+LIMIT 1;
+
+-- name: ListUsers :many
+SELECT *
+FROM users
+ORDER BY username
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: UpdateUserPassword :one
+-- Replaces the password hash and records when it changed, tokens issued before are no longer accepted.
+UPDATE users
+SET hashed_password     = $2,
+    password_changed_at = $3
+WHERE username = $1
+RETURNING *;
+
+-- name: VerifyUserEmail :one
+-- Marks the email as verified, unless the user changed it since the verification mail was sent.
+UPDATE users
+SET is_email_verified = true
+WHERE username = $1
+  AND email = $2
+RETURNING *;
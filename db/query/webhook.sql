@@ -0,0 +1,61 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (owner, url, secret)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetWebhook :one
+SELECT *
+FROM webhooks
+WHERE id = $1
+LIMIT 1;
+
+-- name: ListWebhooks :many
+SELECT *
+FROM webhooks
+WHERE owner = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
+
+-- name: DeleteWebhook :exec
+DELETE
+FROM webhooks
+WHERE id = $1;
+
+-- name: CreateWebhookDeliveries :exec
+-- Queues the event for every webhook of the owner.
+INSERT INTO webhook_deliveries (webhook_id, event_type, payload)
+SELECT id, @event_type::varchar, @payload::jsonb
+FROM webhooks
+WHERE owner = @owner;
+
+-- name: ClaimDueWebhookDelivery :one
+-- Claims the oldest pending delivery that is due by moving its next attempt to the end of the lease.
+-- Other dispatchers skip it while it is sent, and it is retried when the outcome is never recorded.
+UPDATE webhook_deliveries
+SET next_attempt_at = @lease_until
+WHERE id = (SELECT id
+            FROM webhook_deliveries
+            WHERE status = 'pending'
+              AND next_attempt_at <= @now
+            ORDER BY next_attempt_at, id
+            LIMIT 1 FOR UPDATE SKIP LOCKED)
+RETURNING *;
+
+-- name: ListWebhookDeliveries :many
+SELECT *
+FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY id DESC
+LIMIT $2 OFFSET $3;
+
+-- name: UpdateWebhookDelivery :one
+-- Records the outcome of a delivery attempt.
+UPDATE webhook_deliveries
+SET status          = $2,
+    attempts        = $3,
+    next_attempt_at = $4,
+    response_status = $5,
+    last_error      = $6,
+    delivered_at    = $7
+WHERE id = $1
+RETURNING *;
@@ -12,9 +12,10 @@ import (
 func createRandomAccount(t *testing.T) Accounts {
 	user := createRandomUser(t)
 	// we need to make random data for the test
+	// the balance covers the transfers made by the store tests
 	arg := CreateAccountsParams{
 		Owner:    user.Username,
-		Balance:  util.RandomMoney(),
+		Balance:  util.RandomInt(100, 1000),
 		Currency: util.RandomCurrency(),
 	}
 
@@ -26,6 +27,7 @@ func createRandomAccount(t *testing.T) Accounts {
 	require.Equal(t, arg.Owner, account.Owner)
 	require.Equal(t, arg.Balance, account.Balance)
 	require.Equal(t, arg.Currency, account.Currency)
+	require.Equal(t, AccountStatusActive, account.Status)
 
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
@@ -142,3 +144,111 @@ func TestQueries_AddAccountBalance(t *testing.T) {
 	require.Equal(t, account1.Currency, account2.Currency)
 	require.WithinDuration(t, account1.CreatedAt, account2.CreatedAt, time.Second)
 }
+
+func TestQueries_GetAccountsAfter(t *testing.T) {
+	account := createRandomAccount(t)
+
+	arg := GetAccountsAfterParams{
+		Owner: account.Owner,
+		Limit: 5,
+	}
+
+	// the zero cursor starts before the first account
+	accounts, err := testQueries.GetAccountsAfter(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account.ID, accounts[0].ID)
+
+	arg.CursorCreatedAt = account.CreatedAt
+	arg.CursorID = account.ID
+
+	accounts, err = testQueries.GetAccountsAfter(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+}
+
+func TestQueries_ListAllAccounts(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		createRandomAccount(t)
+	}
+
+	accounts, err := testQueries.ListAllAccounts(context.Background(), ListAllAccountsParams{
+		Limit:  5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 5)
+
+	for i := 1; i < len(accounts); i++ {
+		require.Less(t, accounts[i-1].ID, accounts[i].ID)
+	}
+}
+
+func TestQueries_UpdateAccountStatus(t *testing.T) {
+	account1 := createRandomAccount(t)
+	require.Equal(t, AccountStatusActive, account1.Status)
+
+	account2, err := testQueries.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:            account1.ID,
+		Status:        AccountStatusFrozen,
+		CurrentStatus: AccountStatusActive,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, account2.ID)
+	require.Equal(t, AccountStatusFrozen, account2.Status)
+
+	// the status is no longer active, so nothing is updated
+	_, err = testQueries.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:            account1.ID,
+		Status:        AccountStatusClosed,
+		CurrentStatus: AccountStatusActive,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestQueries_UpdateAccountStatusCloseNonEmpty(t *testing.T) {
+	account := createRandomAccount(t)
+	require.NotZero(t, account.Balance)
+
+	_, err := testQueries.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:            account.ID,
+		Status:        AccountStatusClosed,
+		CurrentStatus: AccountStatusActive,
+	})
+	require.Error(t, err)
+}
+
+func TestQueries_UpdateAccountOverdraftLimit(t *testing.T) {
+	account1 := createRandomAccount(t)
+
+	account2, err := testQueries.UpdateAccountOverdraftLimit(context.Background(), UpdateAccountOverdraftLimitParams{
+		ID:             account1.ID,
+		OverdraftLimit: 100,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, account2.ID)
+	require.Equal(t, int64(100), account2.OverdraftLimit)
+
+	// the account can be overdrawn down to the limit
+	account2, err = testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:      account1.ID,
+		Balance: -account1.Balance - 100,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(-100), account2.Balance)
+
+	// an overdrawn account still takes credits
+	account2, err = testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:      account1.ID,
+		Balance: 10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(-90), account2.Balance)
+
+	// the limit cannot be lowered below the current overdraft
+	_, err = testQueries.UpdateAccountOverdraftLimit(context.Background(), UpdateAccountOverdraftLimitParams{
+		ID:             account1.ID,
+		OverdraftLimit: 50,
+	})
+	require.Error(t, err)
+}
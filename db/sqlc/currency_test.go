@@ -0,0 +1,28 @@
+package db
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"practice-docker/util"
+	"testing"
+)
+
+func TestQueries_ListCurrencies(t *testing.T) {
+	currencies, err := testQueries.ListCurrencies(context.Background())
+	require.NoError(t, err)
+
+	byCode := make(map[string]Currencies, len(currencies))
+	for _, currency := range currencies {
+		byCode[currency.Code] = currency
+	}
+
+	// the currencies that accounts can be opened in are seeded by the migration
+	for _, code := range []string{util.USD, util.EUR, util.CAD} {
+		require.Contains(t, byCode, code)
+		require.Equal(t, int32(2), byCode[code].Exponent)
+		require.True(t, byCode[code].Enabled)
+	}
+
+	require.Equal(t, int32(0), byCode[util.JPY].Exponent)
+	require.Equal(t, int32(3), byCode[util.KWD].Exponent)
+}
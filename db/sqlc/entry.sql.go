@@ -8,33 +8,43 @@ package db
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
 const createEntry = `-- name: CreateEntry :one
-INSERT INTO entries (account_id, amount)
-VALUES ($1, $2)
-RETURNING id, account_id, amount, created_at
+INSERT INTO entries (account_id, amount, transfer_id, is_correction)
+VALUES ($1, $2, $3, $4)
+RETURNING id, account_id, amount, created_at, transfer_id, is_correction
 `
 
 type CreateEntryParams struct {
-	AccountID sql.NullInt64 `json:"account_id"`
-	Amount    int64         `json:"amount"`
+	AccountID    sql.NullInt64 `json:"account_id"`
+	Amount       int64         `json:"amount"`
+	TransferID   sql.NullInt64 `json:"transfer_id"`
+	IsCorrection bool          `json:"is_correction"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entries, error) {
-	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.TransferID,
+		arg.IsCorrection,
+	)
 	var i Entries
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.IsCorrection,
 	)
 	return i, err
 }
 
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at
+SELECT id, account_id, amount, created_at, transfer_id, is_correction
 FROM entries
 WHERE id = $1
 LIMIT 1
@@ -48,12 +58,160 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entries, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.IsCorrection,
 	)
 	return i, err
 }
 
+const listAccountStatement = `-- name: ListAccountStatement :many
+SELECT id, account_id, amount, running_balance, created_at
+FROM (SELECT e.id,
+             e.account_id,
+             e.amount,
+             (a.balance - COALESCE(SUM(e.amount) OVER (
+                 ORDER BY e.created_at DESC, e.id DESC
+                 ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+                 ), 0))::bigint AS running_balance,
+             e.created_at
+      FROM entries e
+               JOIN accounts a ON a.id = e.account_id
+      WHERE e.account_id = $1) AS statement
+WHERE ($2::timestamptz IS NULL OR created_at >= $2)
+  AND ($3::timestamptz IS NULL OR created_at < $3)
+ORDER BY created_at, id
+LIMIT $4 OFFSET $5
+`
+
+type ListAccountStatementParams struct {
+	AccountID sql.NullInt64 `json:"account_id"`
+	FromTime  sql.NullTime  `json:"from_time"`
+	ToTime    sql.NullTime  `json:"to_time"`
+	Limit     int32         `json:"limit"`
+	Offset    int32         `json:"offset"`
+}
+
+type ListAccountStatementRow struct {
+	ID             int64         `json:"id"`
+	AccountID      sql.NullInt64 `json:"account_id"`
+	Amount         int64         `json:"amount"`
+	RunningBalance int64         `json:"running_balance"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+// running_balance is the account balance right after the entry. It is computed
+// backwards from the current balance, so opening balances are included.
+func (q *Queries) ListAccountStatement(ctx context.Context, arg ListAccountStatementParams) ([]ListAccountStatementRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountStatement,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountStatementRow{}
+	for rows.Next() {
+		var i ListAccountStatementRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.RunningBalance,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountStatementAfter = `-- name: ListAccountStatementAfter :many
+SELECT id, account_id, amount, running_balance, created_at
+FROM (SELECT e.id,
+             e.account_id,
+             e.amount,
+             (a.balance - COALESCE(SUM(e.amount) OVER (
+                 ORDER BY e.created_at DESC, e.id DESC
+                 ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+                 ), 0))::bigint AS running_balance,
+             e.created_at
+      FROM entries e
+               JOIN accounts a ON a.id = e.account_id
+      WHERE e.account_id = $1) AS statement
+WHERE ($2::timestamptz IS NULL OR created_at >= $2)
+  AND ($3::timestamptz IS NULL OR created_at < $3)
+  AND (created_at, id) > ($4::timestamptz, $5::bigint)
+ORDER BY created_at, id
+LIMIT $6
+`
+
+type ListAccountStatementAfterParams struct {
+	AccountID       sql.NullInt64 `json:"account_id"`
+	FromTime        sql.NullTime  `json:"from_time"`
+	ToTime          sql.NullTime  `json:"to_time"`
+	CursorCreatedAt time.Time     `json:"cursor_created_at"`
+	CursorID        int64         `json:"cursor_id"`
+	Limit           int32         `json:"limit"`
+}
+
+type ListAccountStatementAfterRow struct {
+	ID             int64         `json:"id"`
+	AccountID      sql.NullInt64 `json:"account_id"`
+	Amount         int64         `json:"amount"`
+	RunningBalance int64         `json:"running_balance"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+// Keyset pagination of ListAccountStatement after the (created_at, id) cursor.
+func (q *Queries) ListAccountStatementAfter(ctx context.Context, arg ListAccountStatementAfterParams) ([]ListAccountStatementAfterRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountStatementAfter,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountStatementAfterRow{}
+	for rows.Next() {
+		var i ListAccountStatementAfterRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.RunningBalance,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at
+SELECT id, account_id, amount, created_at, transfer_id, is_correction
 FROM entries
 WHERE account_id = $1
 ORDER BY id
@@ -80,6 +238,8 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.TransferID,
+			&i.IsCorrection,
 		); err != nil {
 			return nil, err
 		}
@@ -93,3 +93,50 @@ func TestQueries_GetEntries(t *testing.T) {
 		require.NotEmpty(t, entry)
 	}
 }
+
+func TestQueries_ListAccountStatement(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	startTime := time.Now().Add(-time.Second)
+
+	n := 3
+	amount := int64(10)
+	for i := 0; i < n; i++ {
+		_, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		})
+		require.NoError(t, err)
+	}
+
+	arg := ListAccountStatementParams{
+		AccountID: sql.NullInt64{
+			Int64: account1.ID,
+			Valid: true,
+		},
+		FromTime: sql.NullTime{Time: startTime, Valid: true},
+		Limit:    10,
+		Offset:   0,
+	}
+
+	statement, err := testQueries.ListAccountStatement(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, statement, n)
+
+	// each entry carries the balance right after it was posted
+	for i, entry := range statement {
+		require.Equal(t, -amount, entry.Amount)
+		require.Equal(t, account1.Balance-int64(i+1)*amount, entry.RunningBalance)
+	}
+
+	// entries outside the time window are filtered out
+	arg.FromTime = sql.NullTime{}
+	arg.ToTime = sql.NullTime{Time: startTime, Valid: true}
+
+	statement, err = testQueries.ListAccountStatement(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, statement)
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Event types written to the outbox.
+const (
+	// EventAccountCreated carries the created Accounts.
+	EventAccountCreated = "account.created"
+	// EventTransferCompleted carries the TransferTxResult of a transfer or a reversal.
+	EventTransferCompleted = "transfer.completed"
+)
+
+// Event types delivered to the webhooks of the owner of the credited account.
+const (
+	// WebhookTransferCreated carries the Transfers that credited the account.
+	WebhookTransferCreated = "transfer.created"
+	// WebhookAccountCredited carries an AccountCreditedEvent.
+	WebhookAccountCredited = "account.credited"
+)
+
+// AccountCreditedEvent describes money reaching an account.
+type AccountCreditedEvent struct {
+	AccountID  int64  `json:"account_id"`
+	TransferID int64  `json:"transfer_id"`
+	Amount     int64  `json:"amount"`
+	Currency   string `json:"currency"`
+	Balance    int64  `json:"balance"`
+}
+
+// AccountActivityChannel is the Postgres channel notified when money moves in or out of an account.
+const AccountActivityChannel = "account_activity"
+
+// AccountActivity is the payload of a notification on AccountActivityChannel.
+type AccountActivity struct {
+	AccountID int64   `json:"account_id"`
+	Entry     Entries `json:"entry"`
+	Balance   int64   `json:"balance"`
+}
+
+// addOutboxEvent records an event in the transaction of q, so it is published only if the transaction commits.
+func addOutboxEvent(ctx context.Context, q *Queries, eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateOutboxEvent(ctx, CreateOutboxEventParams{
+		EventType: eventType,
+		Payload:   data,
+	})
+	return err
+}
+
+// addWebhookDeliveries queues the webhook events of a transfer for the owner of the to account.
+func addWebhookDeliveries(ctx context.Context, q *Queries, result TransferTxResult) error {
+	credited := AccountCreditedEvent{
+		AccountID:  result.ToAccount.ID,
+		TransferID: result.Transfer.ID,
+		Amount:     result.ToEntry.Amount,
+		Currency:   result.ToAccount.Currency,
+		Balance:    result.ToAccount.Balance,
+	}
+
+	events := []struct {
+		eventType string
+		payload   interface{}
+	}{
+		{WebhookTransferCreated, result.Transfer},
+		{WebhookAccountCredited, credited},
+	}
+
+	for _, event := range events {
+		data, err := json.Marshal(event.payload)
+		if err != nil {
+			return err
+		}
+
+		err = q.CreateWebhookDeliveries(ctx, CreateWebhookDeliveriesParams{
+			EventType: event.eventType,
+			Payload:   data,
+			Owner:     result.ToAccount.Owner,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// notifyAccountActivity notifies the listeners of both accounts of a transfer once the transaction commits.
+func notifyAccountActivity(ctx context.Context, q *Queries, result TransferTxResult) error {
+	activities := []AccountActivity{
+		{AccountID: result.FromAccount.ID, Entry: result.FromEntry, Balance: result.FromAccount.Balance},
+		{AccountID: result.ToAccount.ID, Entry: result.ToEntry, Balance: result.ToAccount.Balance},
+	}
+
+	for _, activity := range activities {
+		data, err := json.Marshal(activity)
+		if err != nil {
+			return err
+		}
+
+		err = q.Notify(ctx, NotifyParams{
+			Channel: AccountActivityChannel,
+			Payload: string(data),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"practice-docker/util"
+	"testing"
+	"time"
+)
+
+func createRandomIdempotencyKey(t *testing.T, user Users) IdempotencyKeys {
+	arg := CreateIdempotencyKeyParams{
+		Username:       user.Username,
+		IdempotencyKey: util.RandomString(16),
+		RequestHash:    util.RandomString(64),
+	}
+
+	key, err := testQueries.CreateIdempotencyKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, key)
+
+	require.Equal(t, arg.Username, key.Username)
+	require.Equal(t, arg.IdempotencyKey, key.IdempotencyKey)
+	require.Equal(t, arg.RequestHash, key.RequestHash)
+	require.NotZero(t, key.CreatedAt)
+
+	return key
+}
+
+func TestQueries_CreateIdempotencyKey(t *testing.T) {
+	user := createRandomUser(t)
+	key := createRandomIdempotencyKey(t, user)
+
+	// a second insert with the same key does nothing
+	_, err := testQueries.CreateIdempotencyKey(context.Background(), CreateIdempotencyKeyParams{
+		Username:       key.Username,
+		IdempotencyKey: key.IdempotencyKey,
+		RequestHash:    util.RandomString(64),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestQueries_GetIdempotencyKey(t *testing.T) {
+	user := createRandomUser(t)
+	key1 := createRandomIdempotencyKey(t, user)
+
+	key2, err := testQueries.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username:       key1.Username,
+		IdempotencyKey: key1.IdempotencyKey,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, key2)
+
+	require.Equal(t, key1.Username, key2.Username)
+	require.Equal(t, key1.IdempotencyKey, key2.IdempotencyKey)
+	require.Equal(t, key1.RequestHash, key2.RequestHash)
+	require.WithinDuration(t, key1.CreatedAt, key2.CreatedAt, time.Second)
+}
+
+func TestQueries_UpdateIdempotencyKeyResponse(t *testing.T) {
+	user := createRandomUser(t)
+	key1 := createRandomIdempotencyKey(t, user)
+
+	response := json.RawMessage(`{"transfer":{"id":1}}`)
+	key2, err := testQueries.UpdateIdempotencyKeyResponse(context.Background(), UpdateIdempotencyKeyResponseParams{
+		Username:       key1.Username,
+		IdempotencyKey: key1.IdempotencyKey,
+		Response:       response,
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, string(response), string(key2.Response))
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"practice-docker/util"
+	"testing"
+	"time"
+)
+
+func TestQueries_RecordLoginAttempt(t *testing.T) {
+	key := "username:" + util.RandomOwner()
+	now := time.Now()
+
+	arg := RecordLoginAttemptParams{
+		Key:         key,
+		Now:         now,
+		WindowStart: now.Add(-time.Minute),
+	}
+
+	failures, err := testQueries.RecordLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failures.Failures)
+	require.False(t, failures.PreviousFailureAt.Valid)
+
+	arg.Now = now.Add(time.Second)
+	failures, err = testQueries.RecordLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), failures.Failures)
+	require.WithinDuration(t, arg.Now, failures.LastFailureAt, time.Millisecond)
+	require.WithinDuration(t, now, failures.PreviousFailureAt.Time, time.Millisecond)
+
+	// a taken back attempt restores the failure before it
+	err = testQueries.TakeBackLoginAttempt(context.Background(), key)
+	require.NoError(t, err)
+
+	got, err := testQueries.GetLoginFailures(context.Background(), key)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), got.Failures)
+	require.WithinDuration(t, now, got.LastFailureAt, time.Millisecond)
+
+	// after a window without failures the count starts again
+	arg.Now = now.Add(time.Hour)
+	arg.WindowStart = now.Add(time.Hour - time.Minute)
+	failures, err = testQueries.RecordLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failures.Failures)
+
+	got, err = testQueries.GetLoginFailures(context.Background(), key)
+	require.NoError(t, err)
+	require.Equal(t, failures.Failures, got.Failures)
+
+	err = testQueries.DeleteLoginFailures(context.Background(), key)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetLoginFailures(context.Background(), key)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestQueries_DeleteStaleLoginFailures(t *testing.T) {
+	key := "ip:" + util.RandomString(12)
+
+	// the failure happened long before the failures of the other tests
+	now := time.Now().AddDate(-30, 0, 0)
+	_, err := testQueries.RecordLoginAttempt(context.Background(), RecordLoginAttemptParams{
+		Key:         key,
+		Now:         now,
+		WindowStart: now,
+	})
+	require.NoError(t, err)
+
+	deleted, err := testQueries.DeleteStaleLoginFailures(context.Background(), now.Add(time.Second))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+
+	_, err = testQueries.GetLoginFailures(context.Background(), key)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -6,30 +6,257 @@ package db
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
+
+	"github.com/google/uuid"
+)
+
+type AccountStatus string
+
+const (
+	AccountStatusActive AccountStatus = "active"
+	AccountStatusFrozen AccountStatus = "frozen"
+	AccountStatusClosed AccountStatus = "closed"
+)
+
+func (e *AccountStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = AccountStatus(s)
+	case string:
+		*e = AccountStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for AccountStatus: %T", src)
+	}
+	return nil
+}
+
+type NullAccountStatus struct {
+	AccountStatus AccountStatus
+	Valid         bool // Valid is true if AccountStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullAccountStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.AccountStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.AccountStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullAccountStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.AccountStatus), nil
+}
+
+type TransferFrequency string
+
+const (
+	TransferFrequencyOnce    TransferFrequency = "once"
+	TransferFrequencyDaily   TransferFrequency = "daily"
+	TransferFrequencyWeekly  TransferFrequency = "weekly"
+	TransferFrequencyMonthly TransferFrequency = "monthly"
 )
 
+func (e *TransferFrequency) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TransferFrequency(s)
+	case string:
+		*e = TransferFrequency(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TransferFrequency: %T", src)
+	}
+	return nil
+}
+
+type NullTransferFrequency struct {
+	TransferFrequency TransferFrequency
+	Valid             bool // Valid is true if TransferFrequency is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTransferFrequency) Scan(value interface{}) error {
+	if value == nil {
+		ns.TransferFrequency, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TransferFrequency.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTransferFrequency) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TransferFrequency), nil
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed"
+)
+
+func (e *WebhookDeliveryStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WebhookDeliveryStatus(s)
+	case string:
+		*e = WebhookDeliveryStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WebhookDeliveryStatus: %T", src)
+	}
+	return nil
+}
+
+type NullWebhookDeliveryStatus struct {
+	WebhookDeliveryStatus WebhookDeliveryStatus
+	Valid                 bool // Valid is true if WebhookDeliveryStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullWebhookDeliveryStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.WebhookDeliveryStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.WebhookDeliveryStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullWebhookDeliveryStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.WebhookDeliveryStatus), nil
+}
+
 type Accounts struct {
-	ID        int64     `json:"id"`
-	Owner     string    `json:"owner"`
-	Balance   int64     `json:"balance"`
-	Currency  string    `json:"currency"`
+	ID             int64         `json:"id"`
+	Owner          string        `json:"owner"`
+	Balance        int64         `json:"balance"`
+	Currency       string        `json:"currency"`
+	CreatedAt      time.Time     `json:"created_at"`
+	OverdraftLimit int64         `json:"overdraft_limit"`
+	Status         AccountStatus `json:"status"`
+}
+
+type Currencies struct {
+	Code      string    `json:"code"`
+	Exponent  int32     `json:"exponent"`
+	Enabled   bool      `json:"enabled"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
 type Entries struct {
-	ID        int64         `json:"id"`
-	AccountID sql.NullInt64 `json:"account_id"`
-	Amount    int64         `json:"amount"`
-	CreatedAt time.Time     `json:"created_at"`
+	ID           int64         `json:"id"`
+	AccountID    sql.NullInt64 `json:"account_id"`
+	Amount       int64         `json:"amount"`
+	CreatedAt    time.Time     `json:"created_at"`
+	TransferID   sql.NullInt64 `json:"transfer_id"`
+	IsCorrection bool          `json:"is_correction"`
+}
+
+type IdempotencyKeys struct {
+	Username       string          `json:"username"`
+	IdempotencyKey string          `json:"idempotency_key"`
+	RequestHash    string          `json:"request_hash"`
+	Response       json.RawMessage `json:"response"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+type LoginFailures struct {
+	Key               string       `json:"key"`
+	Failures          int32        `json:"failures"`
+	LastFailureAt     time.Time    `json:"last_failure_at"`
+	PreviousFailureAt sql.NullTime `json:"previous_failure_at"`
+}
+
+type Outbox struct {
+	ID            int64           `json:"id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      int32           `json:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	LastError     sql.NullString  `json:"last_error"`
+	PublishedAt   sql.NullTime    `json:"published_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+type PasswordResets struct {
+	ID        int64        `json:"id"`
+	Username  string       `json:"username"`
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type RateLimitBuckets struct {
+	Key       string    `json:"key"`
+	Tokens    float64   `json:"tokens"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type RevokedTokens struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	ExpiresAt time.Time `json:"expires_at"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+type ScheduledTransfers struct {
+	ID             int64             `json:"id"`
+	Owner          string            `json:"owner"`
+	FromAccountID  int64             `json:"from_account_id"`
+	ToAccountID    int64             `json:"to_account_id"`
+	Amount         int64             `json:"amount"`
+	Frequency      TransferFrequency `json:"frequency"`
+	StartAt        time.Time         `json:"start_at"`
+	NextRunAt      time.Time         `json:"next_run_at"`
+	EndAt          sql.NullTime      `json:"end_at"`
+	RunCount       int32             `json:"run_count"`
+	IsActive       bool              `json:"is_active"`
+	LastRunAt      sql.NullTime      `json:"last_run_at"`
+	LastTransferID sql.NullInt64     `json:"last_transfer_id"`
+	LastError      sql.NullString    `json:"last_error"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+type Sessions struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 type Transfers struct {
-	ID            int64         `json:"id"`
-	FromAccountID sql.NullInt64 `json:"from_account_id"`
-	ToAccountID   sql.NullInt64 `json:"to_account_id"`
-	Amount        int64         `json:"amount"`
-	CreatedAt     time.Time     `json:"created_at"`
+	ID                int64         `json:"id"`
+	FromAccountID     sql.NullInt64 `json:"from_account_id"`
+	ToAccountID       sql.NullInt64 `json:"to_account_id"`
+	Amount            int64         `json:"amount"`
+	CreatedAt         time.Time     `json:"created_at"`
+	ExchangeRate      string        `json:"exchange_rate"`
+	SourceAmount      int64         `json:"source_amount"`
+	DestinationAmount int64         `json:"destination_amount"`
+	ReversalOf        sql.NullInt64 `json:"reversal_of"`
 }
 
 type Users struct {
@@ -39,4 +266,28 @@ type Users struct {
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	Role              string    `json:"role"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
+}
+
+type WebhookDeliveries struct {
+	ID             int64                 `json:"id"`
+	WebhookID      int64                 `json:"webhook_id"`
+	EventType      string                `json:"event_type"`
+	Payload        json.RawMessage       `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int32                 `json:"attempts"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at"`
+	ResponseStatus sql.NullInt32         `json:"response_status"`
+	LastError      sql.NullString        `json:"last_error"`
+	DeliveredAt    sql.NullTime          `json:"delivered_at"`
+	CreatedAt      time.Time             `json:"created_at"`
+}
+
+type Webhooks struct {
+	ID        int64     `json:"id"`
+	Owner     string    `json:"owner"`
+	Url       string    `json:"url"`
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"created_at"`
 }
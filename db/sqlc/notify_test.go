@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"practice-docker/util"
+	"testing"
+	"time"
+)
+
+func TestStore_TransferTxNotifiesAccountActivity(t *testing.T) {
+	config, err := util.LoadConfig("../..")
+	require.NoError(t, err)
+
+	listener := pq.NewListener(config.DBSource, time.Second, time.Second, nil)
+	defer listener.Close()
+	require.NoError(t, listener.Listen(AccountActivityChannel))
+
+	store := NewStore(testDB)
+	fromAccount := createRandomAccount(t)
+	toAccount := createRandomAccount(t)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	activities := make(map[int64]AccountActivity)
+	timeout := time.After(5 * time.Second)
+	for len(activities) < 2 {
+		select {
+		case notification := <-listener.Notify:
+			var activity AccountActivity
+			require.NoError(t, json.Unmarshal([]byte(notification.Extra), &activity))
+			if activity.Entry.TransferID.Int64 == result.Transfer.ID {
+				activities[activity.AccountID] = activity
+			}
+		case <-timeout:
+			t.Fatal("account activity was not notified")
+		}
+	}
+
+	require.Equal(t, result.FromEntry.ID, activities[fromAccount.ID].Entry.ID)
+	require.Equal(t, result.FromAccount.Balance, activities[fromAccount.ID].Balance)
+	require.Equal(t, result.ToEntry.ID, activities[toAccount.ID].Entry.ID)
+	require.Equal(t, result.ToAccount.Balance, activities[toAccount.ID].Balance)
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// findOutboxEvent returns the event of the given type whose payload has the given id.
+func findOutboxEvent(t *testing.T, eventType string, path string, id int64) Outbox {
+	var eventID int64
+	err := testDB.QueryRow(
+		"SELECT id FROM outbox WHERE event_type = $1 AND (payload #>> $2::text[])::bigint = $3",
+		eventType, path, id,
+	).Scan(&eventID)
+	require.NoError(t, err)
+
+	event, err := testQueries.GetOutboxEvent(context.Background(), eventID)
+	require.NoError(t, err)
+	return event
+}
+
+func TestStore_CreateAccountTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountsParams{
+		Owner:    user.Username,
+		Balance:  0,
+		Currency: "USD",
+	})
+	require.NoError(t, err)
+
+	event := findOutboxEvent(t, EventAccountCreated, "{id}", account.ID)
+	require.False(t, event.PublishedAt.Valid)
+	require.Zero(t, event.Attempts)
+
+	var payload Accounts
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, account.Owner, payload.Owner)
+	require.Equal(t, account.Currency, payload.Currency)
+}
+
+func TestStore_TransferTxOutboxEvent(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createRandomAccount(t)
+	toAccount := createRandomAccount(t)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	event := findOutboxEvent(t, EventTransferCompleted, "{transfer,id}", result.Transfer.ID)
+
+	var payload TransferTxResult
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, result.FromEntry.ID, payload.FromEntry.ID)
+	require.Equal(t, result.ToEntry.ID, payload.ToEntry.ID)
+	require.Equal(t, result.FromAccount.Balance, payload.FromAccount.Balance)
+}
+
+func TestStore_TransferTxOutboxEventRolledBack(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createRandomAccount(t)
+	toAccount := createRandomAccount(t)
+
+	// a failed transfer leaves no event behind
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        fromAccount.Balance + fromAccount.OverdraftLimit + 1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	var count int
+	err = testDB.QueryRow(
+		"SELECT count(*) FROM outbox WHERE event_type = $1 AND (payload #>> '{from_account,id}')::bigint = $2",
+		EventTransferCompleted, fromAccount.ID,
+	).Scan(&count)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
+
+func TestQueries_ClaimDueOutboxEvent(t *testing.T) {
+	// the event is due long before any event of the other tests
+	now := time.Now().AddDate(-30, 0, 0)
+	event, err := testQueries.CreateOutboxEvent(context.Background(), CreateOutboxEventParams{
+		EventType: "test.event",
+		Payload:   json.RawMessage(`{}`),
+	})
+	require.NoError(t, err)
+	_, err = testQueries.UpdateOutboxEventDelivery(context.Background(), UpdateOutboxEventDeliveryParams{
+		ID:            event.ID,
+		NextAttemptAt: now,
+	})
+	require.NoError(t, err)
+
+	arg := ClaimDueOutboxEventParams{
+		LeaseUntil: now.Add(time.Minute),
+		Now:        now,
+	}
+	claimed, err := testQueries.ClaimDueOutboxEvent(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, event.ID, claimed.ID)
+	require.WithinDuration(t, arg.LeaseUntil, claimed.NextAttemptAt, time.Millisecond)
+
+	// the claim is committed, so the event is not due again until the lease ends
+	claimed, err = testQueries.ClaimDueOutboxEvent(context.Background(), arg)
+	if err == nil {
+		require.NotEqual(t, event.ID, claimed.ID)
+	} else {
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	}
+
+	published, err := testQueries.UpdateOutboxEventDelivery(context.Background(), UpdateOutboxEventDeliveryParams{
+		ID:            event.ID,
+		Attempts:      1,
+		NextAttemptAt: arg.LeaseUntil,
+		PublishedAt:   sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), published.Attempts)
+	require.True(t, published.PublishedAt.Valid)
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"practice-docker/util"
+	"testing"
+	"time"
+)
+
+func createRandomPasswordReset(t *testing.T, user Users, expiresAt time.Time) PasswordResets {
+	arg := CreatePasswordResetParams{
+		Username:  user.Username,
+		TokenHash: util.RandomString(64),
+		ExpiresAt: expiresAt,
+	}
+
+	reset, err := testQueries.CreatePasswordReset(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, reset.Username)
+	require.Equal(t, arg.TokenHash, reset.TokenHash)
+	require.WithinDuration(t, arg.ExpiresAt, reset.ExpiresAt, time.Second)
+	require.False(t, reset.UsedAt.Valid)
+	require.NotZero(t, reset.CreatedAt)
+
+	return reset
+}
+
+func TestQueries_UsePasswordReset(t *testing.T) {
+	user := createRandomUser(t)
+	reset := createRandomPasswordReset(t, user, time.Now().Add(time.Minute))
+
+	arg := UsePasswordResetParams{
+		Now:       time.Now(),
+		TokenHash: reset.TokenHash,
+	}
+
+	used, err := testQueries.UsePasswordReset(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, reset.ID, used.ID)
+	require.True(t, used.UsedAt.Valid)
+
+	// a reset can only be used once
+	_, err = testQueries.UsePasswordReset(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestQueries_UsePasswordResetExpired(t *testing.T) {
+	user := createRandomUser(t)
+	reset := createRandomPasswordReset(t, user, time.Now().Add(-time.Minute))
+
+	_, err := testQueries.UsePasswordReset(context.Background(), UsePasswordResetParams{
+		Now:       time.Now(),
+		TokenHash: reset.TokenHash,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestStore_ResetPasswordTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	reset1 := createRandomPasswordReset(t, user, time.Now().Add(time.Minute))
+	reset2 := createRandomPasswordReset(t, user, time.Now().Add(time.Minute))
+
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	arg := ResetPasswordTxParams{
+		TokenHash:      reset1.TokenHash,
+		HashedPassword: hashedPassword,
+		Now:            time.Now(),
+	}
+
+	updated, err := store.ResetPasswordTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, updated.Username)
+	require.Equal(t, hashedPassword, updated.HashedPassword)
+	require.WithinDuration(t, arg.Now, updated.PasswordChangedAt, time.Second)
+
+	// the token can't be used again
+	_, err = store.ResetPasswordTx(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// and the other token of the user was expired with it
+	arg.TokenHash = reset2.TokenHash
+	_, err = store.ResetPasswordTx(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -6,24 +6,123 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Accounts, error)
+	BlockSession(ctx context.Context, id uuid.UUID) (Sessions, error)
+	// Claims the oldest unpublished event that is due by moving its next attempt to the end of the lease.
+	// Other relays skip it while it is published, and it is retried when the outcome is never recorded.
+	ClaimDueOutboxEvent(ctx context.Context, arg ClaimDueOutboxEventParams) (Outbox, error)
+	// Claims the oldest pending delivery that is due by moving its next attempt to the end of the lease.
+	// Other dispatchers skip it while it is sent, and it is retried when the outcome is never recorded.
+	ClaimDueWebhookDelivery(ctx context.Context, arg ClaimDueWebhookDeliveryParams) (WebhookDeliveries, error)
 	CreateAccounts(ctx context.Context, arg CreateAccountsParams) (Accounts, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entries, error)
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKeys, error)
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (Outbox, error)
+	CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordResets, error)
+	CreateRevokedToken(ctx context.Context, arg CreateRevokedTokenParams) error
+	CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfers, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Sessions, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfers, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (Users, error)
+	CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhooks, error)
+	// Queues the event for every webhook of the owner.
+	CreateWebhookDeliveries(ctx context.Context, arg CreateWebhookDeliveriesParams) error
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteExpiredRevokedTokens(ctx context.Context, expiresAt time.Time) (int64, error)
+	DeleteLoginFailures(ctx context.Context, key string) error
+	DeleteScheduledTransfer(ctx context.Context, id int64) error
+	DeleteStaleLoginFailures(ctx context.Context, lastFailureAt time.Time) (int64, error)
+	DeleteStaleRateLimitBuckets(ctx context.Context, updatedAt time.Time) (int64, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	// Marks every unused reset of the user as used, so older tokens can't be used after a reset.
+	ExpirePasswordResets(ctx context.Context, arg ExpirePasswordResetsParams) error
 	GetAccount(ctx context.Context, id int64) (Accounts, error)
+	GetAccountEntriesTotal(ctx context.Context, accountID int64) (int64, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Accounts, error)
 	GetAccounts(ctx context.Context, arg GetAccountsParams) ([]Accounts, error)
+	// Keyset pagination: returns the accounts created after the (created_at, id) cursor.
+	GetAccountsAfter(ctx context.Context, arg GetAccountsAfterParams) ([]Accounts, error)
+	// Claims the next due schedule. Rows locked by another worker are skipped instead of waited for.
+	GetDueScheduledTransferForUpdate(ctx context.Context, now time.Time) (ScheduledTransfers, error)
 	GetEntry(ctx context.Context, id int64) (Entries, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKeys, error)
+	GetLoginFailures(ctx context.Context, key string) (LoginFailures, error)
+	GetOutboxEvent(ctx context.Context, id int64) (Outbox, error)
+	GetRateLimitBucket(ctx context.Context, key string) (RateLimitBuckets, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfers, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Sessions, error)
 	GetTransfer(ctx context.Context, id int64) (Transfers, error)
+	// Locks the transfer so that concurrent reversals of it are serialized.
+	GetTransferForUpdate(ctx context.Context, id int64) (Transfers, error)
+	// Sums the amounts already moved back by reversals of the transfer.
+	GetTransferReversalTotals(ctx context.Context, transferID int64) (GetTransferReversalTotalsRow, error)
 	GetUser(ctx context.Context, username string) (Users, error)
+	GetUserByEmail(ctx context.Context, email string) (Users, error)
+	// Only reads when the password changed, it is checked for every authenticated request.
+	GetUserPasswordChangedAt(ctx context.Context, username string) (time.Time, error)
+	GetWebhook(ctx context.Context, id int64) (Webhooks, error)
+	IsTokenRevoked(ctx context.Context, id uuid.UUID) (bool, error)
+	// Lists the accounts whose balance is not the sum of their entries.
+	ListAccountBalanceDrift(ctx context.Context) ([]ListAccountBalanceDriftRow, error)
+	// running_balance is the account balance right after the entry. It is computed
+	// backwards from the current balance, so opening balances are included.
+	ListAccountStatement(ctx context.Context, arg ListAccountStatementParams) ([]ListAccountStatementRow, error)
+	// Keyset pagination of ListAccountStatement after the (created_at, id) cursor.
+	ListAccountStatementAfter(ctx context.Context, arg ListAccountStatementAfterParams) ([]ListAccountStatementAfterRow, error)
+	// Lists the accounts of every owner for admins.
+	ListAllAccounts(ctx context.Context, arg ListAllAccountsParams) ([]Accounts, error)
+	ListCurrencies(ctx context.Context) ([]Currencies, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entries, error)
+	// Lists the entries that belong to no account, or to no transfer without being a correction.
+	ListOrphanEntries(ctx context.Context) ([]Entries, error)
+	ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]ScheduledTransfers, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfers, error)
+	// Lists the transfers that do not have exactly one debit and one credit entry for their amounts.
+	ListUnmatchedTransfers(ctx context.Context) ([]ListUnmatchedTransfersRow, error)
+	// Lists the transfers touching any account of the owner. Every filter is optional.
+	// The amount filters compare the amount in the currency of the owner's side, which is the destination amount of an incoming transfer.
+	ListUserTransfers(ctx context.Context, arg ListUserTransfersParams) ([]ListUserTransfersRow, error)
+	// Keyset pagination of ListUserTransfers after the (created_at, id) cursor.
+	ListUserTransfersAfter(ctx context.Context, arg ListUserTransfersAfterParams) ([]ListUserTransfersAfterRow, error)
+	ListUsers(ctx context.Context, arg ListUsersParams) ([]Users, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDeliveries, error)
+	ListWebhooks(ctx context.Context, arg ListWebhooksParams) ([]Webhooks, error)
+	// Notifies the listeners of the channel. Inside a transaction the notification is sent on commit.
+	Notify(ctx context.Context, arg NotifyParams) error
+	// Counts an attempt of the key as a failure, starting again at one when the last failure is older than the window.
+	// The row is locked while it is updated, so concurrent attempts are counted one after the other.
+	RecordLoginAttempt(ctx context.Context, arg RecordLoginAttemptParams) (LoginFailures, error)
+	// Uncounts an attempt of the key that was throttled or succeeded, the last failure is the one before it again.
+	TakeBackLoginAttempt(ctx context.Context, key string) error
+	// Refills the bucket of the key for the time since it was last updated and takes a token.
+	// No row is returned when less than one token is left, the bucket is left as it was then.
+	TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenParams) (RateLimitBuckets, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Accounts, error)
+	// Sets how far below zero the balance of the account may go.
+	UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Accounts, error)
+	// Changes the status only if it is still the expected one, so a concurrent change is not overwritten.
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Accounts, error)
+	UpdateIdempotencyKeyResponse(ctx context.Context, arg UpdateIdempotencyKeyResponseParams) (IdempotencyKeys, error)
+	// Records the outcome of a delivery attempt.
+	UpdateOutboxEventDelivery(ctx context.Context, arg UpdateOutboxEventDeliveryParams) (Outbox, error)
+	// Changes the amount, end or pause state of a schedule, or skips it ahead to a later occurrence. Every field is optional.
+	UpdateScheduledTransfer(ctx context.Context, arg UpdateScheduledTransferParams) (ScheduledTransfers, error)
+	// Records the outcome of an occurrence and moves the schedule to the next one.
+	UpdateScheduledTransferRun(ctx context.Context, arg UpdateScheduledTransferRunParams) (ScheduledTransfers, error)
+	// Replaces the password hash and records when it changed, tokens issued before are no longer accepted.
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (Users, error)
+	// Records the outcome of a delivery attempt.
+	UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) (WebhookDeliveries, error)
+	// Marks the reset as used, unless it was used before or has expired.
+	UsePasswordReset(ctx context.Context, arg UsePasswordResetParams) (PasswordResets, error)
+	// Marks the email as verified, unless the user changed it since the verification mail was sent.
+	VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (Users, error)
 }
 
 var _ Querier = (*Queries)(nil)
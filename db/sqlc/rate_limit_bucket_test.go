@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"practice-docker/util"
+	"testing"
+	"time"
+)
+
+func TestQueries_TakeRateLimitToken(t *testing.T) {
+	key := "transfers:user:" + util.RandomOwner()
+	now := time.Now()
+
+	// two tokens, refilled at one token per second
+	arg := TakeRateLimitTokenParams{
+		Key:   key,
+		Burst: 2,
+		Now:   now,
+		Rate:  1,
+	}
+
+	bucket, err := testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.InDelta(t, 1, bucket.Tokens, 0.001)
+
+	bucket, err = testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.InDelta(t, 0, bucket.Tokens, 0.001)
+
+	// the bucket is empty, so it is left as it was
+	_, err = testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	got, err := testQueries.GetRateLimitBucket(context.Background(), key)
+	require.NoError(t, err)
+	require.InDelta(t, 0, got.Tokens, 0.001)
+	require.WithinDuration(t, now, got.UpdatedAt, time.Millisecond)
+
+	// half a second refills half a token, which is not enough
+	arg.Now = now.Add(500 * time.Millisecond)
+	_, err = testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// the refill stops at the burst
+	arg.Now = now.Add(time.Hour)
+	bucket, err = testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.InDelta(t, 1, bucket.Tokens, 0.001)
+	require.WithinDuration(t, arg.Now, bucket.UpdatedAt, time.Millisecond)
+}
+
+func TestQueries_DeleteStaleRateLimitBuckets(t *testing.T) {
+	key := "users:ip:" + util.RandomString(12)
+
+	// the bucket was used long before the buckets of the other tests
+	now := time.Now().AddDate(-30, 0, 0)
+	_, err := testQueries.TakeRateLimitToken(context.Background(), TakeRateLimitTokenParams{
+		Key:   key,
+		Burst: 10,
+		Now:   now,
+		Rate:  1,
+	})
+	require.NoError(t, err)
+
+	deleted, err := testQueries.DeleteStaleRateLimitBuckets(context.Background(), now.Add(time.Second))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+
+	_, err = testQueries.GetRateLimitBucket(context.Background(), key)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
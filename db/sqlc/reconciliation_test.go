@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestQueries_ListAccountBalanceDrift(t *testing.T) {
+	// the opening balance of a random account has no entry
+	account := createRandomAccount(t)
+	entry := createRandomEntry(t, account)
+
+	drift, err := testQueries.ListAccountBalanceDrift(context.Background())
+	require.NoError(t, err)
+
+	var found bool
+	for _, row := range drift {
+		if row.AccountID == account.ID {
+			found = true
+			require.Equal(t, account.Balance, row.Balance)
+			require.Equal(t, entry.Amount, row.EntriesTotal)
+			require.Equal(t, account.Balance-entry.Amount, row.Drift)
+		}
+	}
+	require.True(t, found)
+}
+
+func TestQueries_ListOrphanEntries(t *testing.T) {
+	entry := createRandomEntry(t, createRandomAccount(t))
+
+	orphans, err := testQueries.ListOrphanEntries(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, orphans, entry)
+}
+
+func TestQueries_ListUnmatchedTransfers(t *testing.T) {
+	store := NewStore(testDB)
+
+	// a transfer without entries is unmatched
+	unmatched := createRandomTransfer(t, createRandomAccount(t), createRandomAccount(t))
+
+	// TransferTx links both entries to the transfer
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: createRandomAccount(t).ID,
+		ToAccountID:   createRandomAccount(t).ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, result.Transfer.ID, result.FromEntry.TransferID.Int64)
+	require.Equal(t, result.Transfer.ID, result.ToEntry.TransferID.Int64)
+
+	rows, err := testQueries.ListUnmatchedTransfers(context.Background())
+	require.NoError(t, err)
+
+	ids := make(map[int64]int64, len(rows))
+	for _, row := range rows {
+		ids[row.ID] = row.MatchingEntries
+	}
+	require.Contains(t, ids, unmatched.ID)
+	require.Zero(t, ids[unmatched.ID])
+	require.NotContains(t, ids, result.Transfer.ID)
+}
+
+func TestStore_CorrectAccountDriftTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccount(t)
+	entry := createRandomEntry(t, account)
+
+	result, err := store.CorrectAccountDriftTx(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance-entry.Amount, result.Drift)
+	require.Equal(t, result.Drift, result.Entry.Amount)
+	require.True(t, result.Entry.IsCorrection)
+	require.Equal(t, account.ID, result.Entry.AccountID.Int64)
+
+	total, err := store.GetAccountEntriesTotal(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, total)
+
+	// a second run has nothing left to correct
+	result, err = store.CorrectAccountDriftTx(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Zero(t, result.Drift)
+	require.Zero(t, result.Entry.ID)
+}
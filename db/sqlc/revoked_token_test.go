@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomRevokedToken(t *testing.T, user Users, expiresAt time.Time) uuid.UUID {
+	arg := CreateRevokedTokenParams{
+		ID:        uuid.New(),
+		Username:  user.Username,
+		ExpiresAt: expiresAt,
+	}
+
+	err := testQueries.CreateRevokedToken(context.Background(), arg)
+	require.NoError(t, err)
+
+	// revoking the same token twice is not an error
+	err = testQueries.CreateRevokedToken(context.Background(), arg)
+	require.NoError(t, err)
+
+	return arg.ID
+}
+
+func TestQueries_IsTokenRevoked(t *testing.T) {
+	user := createRandomUser(t)
+	tokenID := createRandomRevokedToken(t, user, time.Now().Add(time.Minute))
+
+	revoked, err := testQueries.IsTokenRevoked(context.Background(), tokenID)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	revoked, err = testQueries.IsTokenRevoked(context.Background(), uuid.New())
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
+
+func TestQueries_DeleteExpiredRevokedTokens(t *testing.T) {
+	user := createRandomUser(t)
+	expiredID := createRandomRevokedToken(t, user, time.Now().Add(-time.Minute))
+	activeID := createRandomRevokedToken(t, user, time.Now().Add(time.Minute))
+
+	deleted, err := testQueries.DeleteExpiredRevokedTokens(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+
+	revoked, err := testQueries.IsTokenRevoked(context.Background(), expiredID)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	revoked, err = testQueries.IsTokenRevoked(context.Background(), activeID)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomScheduledTransfer(t *testing.T, fromAccount Accounts, toAccount Accounts, startAt time.Time) ScheduledTransfers {
+	arg := CreateScheduledTransferParams{
+		Owner:         fromAccount.Owner,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        10,
+		Frequency:     TransferFrequencyMonthly,
+		StartAt:       startAt,
+		NextRunAt:     startAt,
+	}
+
+	scheduled, err := testQueries.CreateScheduledTransfer(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, scheduled.ID)
+
+	require.Equal(t, arg.Owner, scheduled.Owner)
+	require.Equal(t, arg.FromAccountID, scheduled.FromAccountID)
+	require.Equal(t, arg.ToAccountID, scheduled.ToAccountID)
+	require.Equal(t, arg.Amount, scheduled.Amount)
+	require.Equal(t, arg.Frequency, scheduled.Frequency)
+	require.WithinDuration(t, arg.StartAt, scheduled.NextRunAt, time.Second)
+	require.Zero(t, scheduled.RunCount)
+	require.True(t, scheduled.IsActive)
+	require.False(t, scheduled.EndAt.Valid)
+
+	return scheduled
+}
+
+func TestQueries_CreateScheduledTransfer(t *testing.T) {
+	createRandomScheduledTransfer(t, createRandomAccount(t), createRandomAccount(t), time.Now())
+}
+
+func TestQueries_ListScheduledTransfers(t *testing.T) {
+	fromAccount := createRandomAccount(t)
+	toAccount := createRandomAccount(t)
+
+	for i := 0; i < 3; i++ {
+		createRandomScheduledTransfer(t, fromAccount, toAccount, time.Now().Add(time.Hour))
+	}
+
+	scheduled, err := testQueries.ListScheduledTransfers(context.Background(), ListScheduledTransfersParams{
+		Owner:  fromAccount.Owner,
+		Limit:  5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, scheduled, 3)
+
+	for _, s := range scheduled {
+		require.Equal(t, fromAccount.Owner, s.Owner)
+	}
+}
+
+func TestQueries_UpdateScheduledTransfer(t *testing.T) {
+	scheduled := createRandomScheduledTransfer(t, createRandomAccount(t), createRandomAccount(t), time.Now())
+
+	// fields that are not set are kept
+	updated, err := testQueries.UpdateScheduledTransfer(context.Background(), UpdateScheduledTransferParams{
+		ID:       scheduled.ID,
+		IsActive: sql.NullBool{Bool: false, Valid: true},
+	})
+	require.NoError(t, err)
+	require.False(t, updated.IsActive)
+	require.Equal(t, scheduled.Amount, updated.Amount)
+}
+
+func TestQueries_DeleteScheduledTransfer(t *testing.T) {
+	scheduled := createRandomScheduledTransfer(t, createRandomAccount(t), createRandomAccount(t), time.Now())
+
+	err := testQueries.DeleteScheduledTransfer(context.Background(), scheduled.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetScheduledTransfer(context.Background(), scheduled.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestStore_RunScheduledTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	// the schedule is due long before any schedule of the other tests
+	now := time.Now().AddDate(-30, 0, 0)
+	scheduled := createRandomScheduledTransfer(t, createRandomAccount(t), createRandomAccount(t), now)
+
+	locked := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		_, err := store.RunScheduledTransferTx(context.Background(), now, func(_ context.Context, due ScheduledTransfers) (UpdateScheduledTransferRunParams, error) {
+			require.Equal(t, scheduled.ID, due.ID)
+			close(locked)
+			<-release
+
+			// the schedule ends, so it is never due for later test runs
+			return UpdateScheduledTransferRunParams{
+				NextRunAt: due.NextRunAt.AddDate(0, 1, 0),
+				RunCount:  due.RunCount + 1,
+				IsActive:  false,
+				LastRunAt: sql.NullTime{Time: time.Now(), Valid: true},
+			}, nil
+		})
+		done <- err
+	}()
+
+	// a second worker skips the row while the first one holds it
+	<-locked
+	_, err := store.RunScheduledTransferTx(context.Background(), now, func(_ context.Context, due ScheduledTransfers) (UpdateScheduledTransferRunParams, error) {
+		t.Errorf("claimed locked schedule %d", due.ID)
+		return UpdateScheduledTransferRunParams{}, sql.ErrConnDone
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	close(release)
+	require.NoError(t, <-done)
+
+	updated, err := store.GetScheduledTransfer(context.Background(), scheduled.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), updated.RunCount)
+	require.False(t, updated.IsActive)
+	require.True(t, updated.LastRunAt.Valid)
+}
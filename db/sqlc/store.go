@@ -3,13 +3,55 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/lib/pq"
+	"math/big"
+	"time"
 )
 
+var (
+	// ErrIdempotencyKeyMismatch is returned when an idempotency key is reused with a different request.
+	ErrIdempotencyKeyMismatch = errors.New("idempotency key was already used with a different request")
+	// ErrInsufficientFunds is returned when a transfer would take an account below its overdraft limit.
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrAccountFrozen is returned when money would move in or out of a frozen account.
+	ErrAccountFrozen = errors.New("account is frozen")
+	// ErrAccountClosed is returned when money would move in or out of a closed account.
+	ErrAccountClosed = errors.New("account is closed")
+	// ErrTransferAlreadyReversed is returned when the whole amount of a transfer was already refunded.
+	ErrTransferAlreadyReversed = errors.New("transfer is already reversed")
+	// ErrTransferIsReversal is returned when a reversal itself would be reversed.
+	ErrTransferIsReversal = errors.New("a reversal cannot be reversed")
+	// ErrReversalExceedsRemaining is returned when a refund is larger than what is left of the transfer.
+	ErrReversalExceedsRemaining = errors.New("refund exceeds the remaining amount of the transfer")
+	// ErrReversalTooSmall is returned when a partial refund converts to nothing in the currency of the to account.
+	ErrReversalTooSmall = errors.New("refund is too small to convert back")
+)
+
+const (
+	// accountBalanceCheck is the database constraint that keeps balances above the overdraft limit.
+	accountBalanceCheck = "accounts_balance_check"
+	// accountClosedBalanceCheck is the database constraint that keeps closed accounts empty.
+	accountClosedBalanceCheck = "accounts_closed_balance_check"
+)
+
+// defaultExchangeRate is recorded for transfers between accounts of the same currency.
+const defaultExchangeRate = "1"
+
+// exchangeRateScale is the number of decimals stored for transfers.exchange_rate.
+const exchangeRateScale = 8
+
 // Store provides all functions to execute db queries and transactions
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error)
+	RunScheduledTransferTx(ctx context.Context, now time.Time, run ScheduledTransferRun) (ScheduledTransfers, error)
+	CorrectAccountDriftTx(ctx context.Context, accountID int64) (CorrectAccountDriftTxResult, error)
+	CreateAccountTx(ctx context.Context, arg CreateAccountsParams) (Accounts, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (Users, error)
 }
 
 // SQLStore provides all functions to execute db queries and transactions
@@ -37,7 +79,7 @@ func (store *SQLStore) execTx(ctx context.Context, fn func(queries *Queries) err
 	err = fn(q)
 	if err != nil {
 		if rbErr := tx.Rollback(); rbErr != nil {
-			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+			return fmt.Errorf("tx err: %w, rb err: %v", err, rbErr)
 		}
 		return err
 	}
@@ -49,6 +91,20 @@ type TransferTxParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	Amount        int64 `json:"amount"`
+	// ToAmount is the amount credited in the currency of the to account.
+	// It defaults to Amount for transfers between accounts of the same currency.
+	ToAmount int64 `json:"to_amount"`
+	// ExchangeRate is the decimal rate used to convert Amount into ToAmount. It defaults to 1.
+	ExchangeRate string `json:"exchange_rate"`
+	// Idempotency is optional. When set, the result is stored under the key and replayed on retries.
+	Idempotency *IdempotencyParams `json:"-"`
+}
+
+// IdempotencyParams identifies a client request that may be retried.
+type IdempotencyParams struct {
+	Username    string
+	Key         string
+	RequestHash string
 }
 
 type TransferTxResult struct {
@@ -65,56 +121,380 @@ func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (Tr
 	err := store.execTx(ctx, func(q *Queries) error {
 		var err error
 
-		fromId := sql.NullInt64{Int64: arg.FromAccountID, Valid: true}
-		toId := sql.NullInt64{Int64: arg.ToAccountID, Valid: true}
+		// a retry of an already processed request returns the stored result
+		if arg.Idempotency != nil {
+			replayed, err := claimIdempotencyKey(ctx, q, *arg.Idempotency, &result)
+			if err != nil || replayed {
+				return err
+			}
+		}
 
-		// create a transfer
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: fromId,
-			ToAccountID:   toId,
-			Amount:        arg.Amount,
-		})
+		result, err = transfer(ctx, q, arg, sql.NullInt64{})
+		if err != nil {
+			return err
+		}
+
+		if arg.Idempotency != nil {
+			return saveIdempotencyKey(ctx, q, *arg.Idempotency, result)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// ReverseTransferTxParams refunds a transfer in full or in part.
+type ReverseTransferTxParams struct {
+	TransferID int64 `json:"transfer_id"`
+	// Amount is the refund in the currency of the original from account.
+	// It defaults to what is left of the original amount.
+	Amount int64 `json:"amount"`
+	// Idempotency is optional. When set, the result is stored under the key and replayed on retries.
+	Idempotency *IdempotencyParams `json:"-"`
+}
+
+// ReverseTransferTx creates a compensating transfer that moves money back from the
+// to account of the original transfer to its from account.
+// The reversals of a transfer never add up to more than its original amount.
+func (store *SQLStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		// a retry of an already processed request returns the stored result
+		if arg.Idempotency != nil {
+			replayed, err := claimIdempotencyKey(ctx, q, *arg.Idempotency, &result)
+			if err != nil || replayed {
+				return err
+			}
+		}
+
+		// the lock is held until commit, so a concurrent reversal sees this one in the totals
+		original, err := q.GetTransferForUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+
+		if original.ReversalOf.Valid {
+			return ErrTransferIsReversal
+		}
+
+		reversed, err := q.GetTransferReversalTotals(ctx, original.ID)
+		if err != nil {
+			return err
+		}
+
+		remaining := original.SourceAmount - reversed.DestinationAmount
+		if remaining <= 0 {
+			return ErrTransferAlreadyReversed
+		}
+
+		amount := arg.Amount
+		if amount == 0 {
+			amount = remaining
+		}
+		if amount > remaining {
+			return ErrReversalExceedsRemaining
+		}
+
+		// the last refund takes back exactly what is left, so rounding never leaves a remainder
+		debit := original.DestinationAmount - reversed.SourceAmount
+		if amount < remaining {
+			debit = min64(scaleAmount(amount, original.DestinationAmount, original.SourceAmount), debit)
+		}
+		if debit <= 0 {
+			return ErrReversalTooSmall
+		}
+
+		exchangeRate, err := inverseExchangeRate(original.ExchangeRate)
+		if err != nil {
+			return err
+		}
 
+		result, err = transfer(ctx, q, TransferTxParams{
+			FromAccountID: original.ToAccountID.Int64,
+			ToAccountID:   original.FromAccountID.Int64,
+			Amount:        debit,
+			ToAmount:      amount,
+			ExchangeRate:  exchangeRate,
+		}, sql.NullInt64{Int64: original.ID, Valid: true})
 		if err != nil {
 			return err
 		}
 
-		// create from entry
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: fromId,
-			Amount:    -arg.Amount,
+		if arg.Idempotency != nil {
+			return saveIdempotencyKey(ctx, q, *arg.Idempotency, result)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// ScheduledTransferRun executes a claimed occurrence of a scheduled transfer.
+// It returns how the schedule moves on, or an error to leave the schedule untouched.
+type ScheduledTransferRun func(ctx context.Context, scheduled ScheduledTransfers) (UpdateScheduledTransferRunParams, error)
+
+// RunScheduledTransferTx claims one scheduled transfer that is due at now and passes it to run.
+// The row stays locked until the update returned by run is committed, so other workers skip it
+// instead of running the same occurrence. It returns sql.ErrNoRows when nothing is due.
+func (store *SQLStore) RunScheduledTransferTx(
+	ctx context.Context,
+	now time.Time,
+	run ScheduledTransferRun,
+) (ScheduledTransfers, error) {
+	var result ScheduledTransfers
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		scheduled, err := q.GetDueScheduledTransferForUpdate(ctx, now)
+		if err != nil {
+			return err
+		}
+
+		arg, err := run(ctx, scheduled)
+		if err != nil {
+			return err
+		}
+
+		arg.ID = scheduled.ID
+		result, err = q.UpdateScheduledTransferRun(ctx, arg)
+		return err
+	})
+
+	return result, err
+}
+
+// CorrectAccountDriftTxResult is the outcome of reconciling the entries of an account with its balance.
+type CorrectAccountDriftTxResult struct {
+	Account Accounts `json:"account"`
+	Drift   int64    `json:"drift"`
+	// Entry is the correcting entry. It is empty when the account had no drift.
+	Entry Entries `json:"entry"`
+}
+
+// CorrectAccountDriftTx posts a correcting entry so that the entries of the account add up to its balance.
+// The account is locked before its entries are summed, so a concurrent transfer is counted fully or not at all.
+func (store *SQLStore) CorrectAccountDriftTx(ctx context.Context, accountID int64) (CorrectAccountDriftTxResult, error) {
+	var result CorrectAccountDriftTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Account, err = q.GetAccountForUpdate(ctx, accountID)
+		if err != nil {
+			return err
+		}
+
+		total, err := q.GetAccountEntriesTotal(ctx, accountID)
+		if err != nil {
+			return err
+		}
+
+		result.Drift = result.Account.Balance - total
+		if result.Drift == 0 {
+			return nil
+		}
+
+		result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:    sql.NullInt64{Int64: accountID, Valid: true},
+			Amount:       result.Drift,
+			IsCorrection: true,
 		})
+		return err
+	})
+
+	return result, err
+}
+
+// CreateAccountTx creates an account and records an EventAccountCreated event for it.
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountsParams) (Accounts, error) {
+	var result Accounts
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
 
+		result, err = q.CreateAccounts(ctx, arg)
 		if err != nil {
 			return err
 		}
 
-		// create to entry
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: toId,
-			Amount:    arg.Amount,
+		return addOutboxEvent(ctx, q, EventAccountCreated, result)
+	})
+
+	return result, err
+}
+
+// ResetPasswordTxParams contains the input parameters of the password reset.
+type ResetPasswordTxParams struct {
+	TokenHash      string    `json:"token_hash"`
+	HashedPassword string    `json:"hashed_password"`
+	Now            time.Time `json:"now"`
+}
+
+// ResetPasswordTx uses the password reset with the token hash and replaces the password of its user.
+// The other unused resets of the user are expired with it. It returns sql.ErrNoRows when the reset
+// does not exist, was already used or has expired.
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (Users, error) {
+	var result Users
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		reset, err := q.UsePasswordReset(ctx, UsePasswordResetParams{
+			Now:       arg.Now,
+			TokenHash: arg.TokenHash,
 		})
+		if err != nil {
+			return err
+		}
 
+		result, err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			Username:          reset.Username,
+			HashedPassword:    arg.HashedPassword,
+			PasswordChangedAt: arg.Now,
+		})
 		if err != nil {
 			return err
 		}
 
-		// we should update the account balance in the same order
-		// to avoid deadlock
-		if arg.FromAccountID < arg.ToAccountID {
-			// update from count to account
-			result.FromAccount, result.ToAccount, err =
-				addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
+		return q.ExpirePasswordResets(ctx, ExpirePasswordResetsParams{
+			Now:      arg.Now,
+			Username: reset.Username,
+		})
+	})
+
+	return result, err
+}
+
+// scaleAmount returns amount * numerator / denominator rounded half away from zero.
+func scaleAmount(amount, numerator, denominator int64) int64 {
+	product := new(big.Int).Mul(big.NewInt(amount), big.NewInt(numerator))
+	quotient, remainder := new(big.Int).QuoRem(product, big.NewInt(denominator), new(big.Int))
+
+	// round up when the remainder is at least half of the denominator
+	if remainder.Abs(remainder).Lsh(remainder, 1).CmpAbs(big.NewInt(denominator)) >= 0 {
+		if product.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
 		} else {
-			// update to count from account
-			result.ToAccount, result.FromAccount, err =
-				addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+			quotient.Add(quotient, big.NewInt(1))
 		}
+	}
 
-		return nil
+	return quotient.Int64()
+}
+
+// inverseExchangeRate returns the rate that converts back along a transfer made at rate.
+func inverseExchangeRate(rate string) (string, error) {
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok || r.Sign() <= 0 {
+		return "", fmt.Errorf("invalid exchange rate %q", rate)
+	}
+
+	return r.Inv(r).FloatString(exchangeRateScale), nil
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// transfer moves the money between the accounts of arg and records the transfer and its entries.
+// reversalOf is set when the transfer refunds an earlier one.
+func transfer(ctx context.Context, q *Queries, arg TransferTxParams, reversalOf sql.NullInt64) (TransferTxResult, error) {
+	var result TransferTxResult
+	var err error
+
+	fromId := sql.NullInt64{Int64: arg.FromAccountID, Valid: true}
+	toId := sql.NullInt64{Int64: arg.ToAccountID, Valid: true}
+
+	toAmount := arg.ToAmount
+	if toAmount == 0 {
+		toAmount = arg.Amount
+	}
+	exchangeRate := arg.ExchangeRate
+	if exchangeRate == "" {
+		exchangeRate = defaultExchangeRate
+	}
+
+	// create a transfer
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID:     fromId,
+		ToAccountID:       toId,
+		Amount:            arg.Amount,
+		ExchangeRate:      exchangeRate,
+		SourceAmount:      arg.Amount,
+		DestinationAmount: toAmount,
+		ReversalOf:        reversalOf,
 	})
 
-	return result, err
+	if err != nil {
+		return result, err
+	}
+
+	// create from entry
+	transferID := sql.NullInt64{Int64: result.Transfer.ID, Valid: true}
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  fromId,
+		Amount:     -arg.Amount,
+		TransferID: transferID,
+	})
+
+	if err != nil {
+		return result, err
+	}
+
+	// create to entry in the currency of the to account
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  toId,
+		Amount:     toAmount,
+		TransferID: transferID,
+	})
+
+	if err != nil {
+		return result, err
+	}
+
+	// we should update the account balance in the same order
+	// to avoid deadlock
+	if arg.FromAccountID < arg.ToAccountID {
+		// update from count to account
+		result.FromAccount, result.ToAccount, err =
+			addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, toAmount)
+	} else {
+		// update to count from account
+		result.ToAccount, result.FromAccount, err =
+			addMoney(ctx, q, arg.ToAccountID, toAmount, arg.FromAccountID, -arg.Amount)
+	}
+
+	if err != nil {
+		return result, err
+	}
+
+	// the balance updates lock both accounts, so a concurrent status change cannot be missed
+	err = accountStatusError(result.FromAccount, result.ToAccount)
+	if err != nil {
+		return result, err
+	}
+
+	// the source account must stay within its overdraft limit
+	if result.FromAccount.Balance < -result.FromAccount.OverdraftLimit {
+		return result, ErrInsufficientFunds
+	}
+
+	err = addOutboxEvent(ctx, q, EventTransferCompleted, result)
+	if err != nil {
+		return result, err
+	}
+
+	err = addWebhookDeliveries(ctx, q, result)
+	if err != nil {
+		return result, err
+	}
+
+	return result, notifyAccountActivity(ctx, q, result)
 }
 
 func addMoney(
@@ -132,7 +512,7 @@ func addMoney(
 	})
 
 	if err != nil {
-		return Accounts{}, Accounts{}, err
+		return Accounts{}, Accounts{}, balanceError(err)
 	}
 
 	account2, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
@@ -141,8 +521,94 @@ func addMoney(
 	})
 
 	if err != nil {
-		return Accounts{}, Accounts{}, err
+		return Accounts{}, Accounts{}, balanceError(err)
 	}
 
 	return account1, account2, err
 }
+
+// balanceError converts a violation of the balance constraints into ErrInsufficientFunds or ErrAccountClosed.
+func balanceError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "check_violation" {
+		switch pqErr.Constraint {
+		case accountBalanceCheck:
+			return ErrInsufficientFunds
+		case accountClosedBalanceCheck:
+			return ErrAccountClosed
+		}
+	}
+
+	return err
+}
+
+// accountStatusError returns an error if one of the accounts cannot take part in a transfer.
+func accountStatusError(accounts ...Accounts) error {
+	for _, account := range accounts {
+		switch account.Status {
+		case AccountStatusFrozen:
+			return ErrAccountFrozen
+		case AccountStatusClosed:
+			return ErrAccountClosed
+		}
+	}
+
+	return nil
+}
+
+// claimIdempotencyKey reserves the idempotency key for the current transaction.
+// If the key was already used, the stored result is decoded into result and replayed is true.
+// Concurrent requests with the same key block on the insert until the first transaction finishes.
+func claimIdempotencyKey(
+	ctx context.Context,
+	q *Queries,
+	arg IdempotencyParams,
+	result *TransferTxResult,
+) (replayed bool, err error) {
+	_, err = q.CreateIdempotencyKey(ctx, CreateIdempotencyKeyParams{
+		Username:       arg.Username,
+		IdempotencyKey: arg.Key,
+		RequestHash:    arg.RequestHash,
+	})
+	if err == nil {
+		return false, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, err
+	}
+
+	// the key already exists, so this is a replay
+	stored, err := q.GetIdempotencyKey(ctx, GetIdempotencyKeyParams{
+		Username:       arg.Username,
+		IdempotencyKey: arg.Key,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if stored.RequestHash != arg.RequestHash {
+		return false, ErrIdempotencyKeyMismatch
+	}
+
+	err = json.Unmarshal(stored.Response, result)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// saveIdempotencyKey stores the result of the transfer under the idempotency key.
+func saveIdempotencyKey(ctx context.Context, q *Queries, arg IdempotencyParams, result TransferTxResult) error {
+	response, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.UpdateIdempotencyKeyResponse(ctx, UpdateIdempotencyKeyResponseParams{
+		Username:       arg.Username,
+		IdempotencyKey: arg.Key,
+		Response:       response,
+	})
+
+	return err
+}
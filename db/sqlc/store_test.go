@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/stretchr/testify/require"
+	"practice-docker/util"
 	"testing"
 )
 
@@ -50,6 +51,8 @@ func TestStore_TransferTx(t *testing.T) {
 		require.Equal(t, account1.ID, transfer.FromAccountID.Int64)
 		require.Equal(t, account2.ID, transfer.ToAccountID.Int64)
 		require.Equal(t, amount, transfer.Amount)
+		require.Equal(t, amount, transfer.SourceAmount)
+		require.Equal(t, amount, transfer.DestinationAmount)
 		require.NotZero(t, transfer.ID)
 		require.NotZero(t, transfer.CreatedAt)
 
@@ -168,3 +171,273 @@ func TestStore_TransferTxDeadlock(t *testing.T) {
 	require.Equal(t, account1.Balance, updatedAccount1.Balance)
 	require.Equal(t, account2.Balance, updatedAccount2.Balance)
 }
+
+func TestStore_TransferTxIdempotency(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Idempotency: &IdempotencyParams{
+			Username:    account1.Owner,
+			Key:         util.RandomString(16),
+			RequestHash: util.RandomString(64),
+		},
+	}
+
+	result1, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	// a replay returns the stored result without moving money again
+	result2, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, result1.Transfer.ID, result2.Transfer.ID)
+	require.Equal(t, result1.FromEntry.ID, result2.FromEntry.ID)
+	require.Equal(t, result1.ToEntry.ID, result2.ToEntry.ID)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-arg.Amount, updatedAccount1.Balance)
+
+	// the same key with a different request is rejected
+	mismatch := arg
+	mismatch.Amount = 20
+	mismatch.Idempotency = &IdempotencyParams{
+		Username:    arg.Idempotency.Username,
+		Key:         arg.Idempotency.Key,
+		RequestHash: util.RandomString(64),
+	}
+
+	_, err = store.TransferTx(context.Background(), mismatch)
+	require.ErrorIs(t, err, ErrIdempotencyKeyMismatch)
+}
+
+func TestStore_TransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        account1.Balance + account1.OverdraftLimit + 1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// the transaction is rolled back, so no money moved
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	updatedAccount2, err := store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+}
+
+func TestStore_TransferTxExchangeRate(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		ToAmount:      9,
+		ExchangeRate:  "0.92",
+	})
+	require.NoError(t, err)
+
+	// the transfer records both sides of the conversion
+	require.Equal(t, "0.92000000", result.Transfer.ExchangeRate)
+	require.Equal(t, int64(10), result.Transfer.SourceAmount)
+	require.Equal(t, int64(9), result.Transfer.DestinationAmount)
+
+	// each entry is in the currency of its account
+	require.Equal(t, int64(-10), result.FromEntry.Amount)
+	require.Equal(t, int64(9), result.ToEntry.Amount)
+	require.Equal(t, account1.Balance-10, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+9, result.ToAccount.Balance)
+}
+
+func TestStore_TransferTxFrozenAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	_, err := store.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:            account2.ID,
+		Status:        AccountStatusFrozen,
+		CurrentStatus: AccountStatusActive,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	// the transaction is rolled back, so no money moved
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+func TestStore_TransferTxClosedAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	// empty the account so it can be closed
+	_, err := store.UpdateAccount(context.Background(), UpdateAccountParams{ID: account2.ID, Balance: 0})
+	require.NoError(t, err)
+
+	_, err = store.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:            account2.ID,
+		Status:        AccountStatusClosed,
+		CurrentStatus: AccountStatusActive,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrAccountClosed)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        10,
+	})
+	require.Error(t, err)
+}
+
+func TestStore_ReverseTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	original, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	// a partial refund moves money back and links to the original transfer
+	partial, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		Amount:     4,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account2.ID, partial.Transfer.FromAccountID.Int64)
+	require.Equal(t, account1.ID, partial.Transfer.ToAccountID.Int64)
+	require.Equal(t, int64(4), partial.Transfer.Amount)
+	require.Equal(t, original.Transfer.ID, partial.Transfer.ReversalOf.Int64)
+	require.Equal(t, int64(-4), partial.FromEntry.Amount)
+	require.Equal(t, int64(4), partial.ToEntry.Amount)
+
+	// more than what is left cannot be refunded
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		Amount:     7,
+	})
+	require.ErrorIs(t, err, ErrReversalExceedsRemaining)
+
+	// without an amount the rest is refunded
+	rest, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(6), rest.Transfer.Amount)
+	require.Equal(t, account1.Balance, rest.ToAccount.Balance)
+	require.Equal(t, account2.Balance, rest.FromAccount.Balance)
+
+	// the transfer cannot be reversed twice
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+	})
+	require.ErrorIs(t, err, ErrTransferAlreadyReversed)
+
+	// a reversal cannot be reversed
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: rest.Transfer.ID,
+	})
+	require.ErrorIs(t, err, ErrTransferIsReversal)
+}
+
+func TestStore_ReverseTransferTxExchangeRate(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	original, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		ToAmount:      8,
+		ExchangeRate:  "0.8",
+	})
+	require.NoError(t, err)
+
+	// the refund is in the currency of the sender and converted back at the original rate
+	result, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		Amount:     5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "1.25000000", result.Transfer.ExchangeRate)
+	require.Equal(t, int64(4), result.Transfer.SourceAmount)
+	require.Equal(t, int64(5), result.Transfer.DestinationAmount)
+}
+
+func TestStore_ReverseTransferTxConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	original, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	// only one of the concurrent full reversals goes through
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+				TransferID: original.Transfer.ID,
+			})
+			errs <- err
+		}()
+	}
+
+	reversed := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			reversed++
+			continue
+		}
+		require.ErrorIs(t, err, ErrTransferAlreadyReversed)
+	}
+	require.Equal(t, 1, reversed)
+}
@@ -8,22 +8,36 @@ package db
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
 const createTransfer = `-- name: CreateTransfer :one
-INSERT INTO transfers (from_account_id, to_account_id, amount)
-VALUES ($1, $2, $3)
-RETURNING id, from_account_id, to_account_id, amount, created_at
+INSERT INTO transfers (from_account_id, to_account_id, amount, exchange_rate, source_amount, destination_amount,
+                       reversal_of)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, from_account_id, to_account_id, amount, created_at, exchange_rate, source_amount, destination_amount, reversal_of
 `
 
 type CreateTransferParams struct {
-	FromAccountID sql.NullInt64 `json:"from_account_id"`
-	ToAccountID   sql.NullInt64 `json:"to_account_id"`
-	Amount        int64         `json:"amount"`
+	FromAccountID     sql.NullInt64 `json:"from_account_id"`
+	ToAccountID       sql.NullInt64 `json:"to_account_id"`
+	Amount            int64         `json:"amount"`
+	ExchangeRate      string        `json:"exchange_rate"`
+	SourceAmount      int64         `json:"source_amount"`
+	DestinationAmount int64         `json:"destination_amount"`
+	ReversalOf        sql.NullInt64 `json:"reversal_of"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfers, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ExchangeRate,
+		arg.SourceAmount,
+		arg.DestinationAmount,
+		arg.ReversalOf,
+	)
 	var i Transfers
 	err := row.Scan(
 		&i.ID,
@@ -31,12 +45,16 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ExchangeRate,
+		&i.SourceAmount,
+		&i.DestinationAmount,
+		&i.ReversalOf,
 	)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at
+SELECT id, from_account_id, to_account_id, amount, created_at, exchange_rate, source_amount, destination_amount, reversal_of
 FROM transfers
 WHERE id = $1
 LIMIT 1
@@ -51,30 +69,167 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfers, error)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ExchangeRate,
+		&i.SourceAmount,
+		&i.DestinationAmount,
+		&i.ReversalOf,
 	)
 	return i, err
 }
 
+const getTransferForUpdate = `-- name: GetTransferForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, created_at, exchange_rate, source_amount, destination_amount, reversal_of
+FROM transfers
+WHERE id = $1
+LIMIT 1
+FOR UPDATE
+`
+
+// Locks the transfer so that concurrent reversals of it are serialized.
+func (q *Queries) GetTransferForUpdate(ctx context.Context, id int64) (Transfers, error) {
+	row := q.db.QueryRowContext(ctx, getTransferForUpdate, id)
+	var i Transfers
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ExchangeRate,
+		&i.SourceAmount,
+		&i.DestinationAmount,
+		&i.ReversalOf,
+	)
+	return i, err
+}
+
+const getTransferReversalTotals = `-- name: GetTransferReversalTotals :one
+SELECT COALESCE(SUM(source_amount), 0)::bigint      AS source_amount,
+       COALESCE(SUM(destination_amount), 0)::bigint AS destination_amount
+FROM transfers
+WHERE reversal_of = $1::bigint
+`
+
+type GetTransferReversalTotalsRow struct {
+	SourceAmount      int64 `json:"source_amount"`
+	DestinationAmount int64 `json:"destination_amount"`
+}
+
+// Sums the amounts already moved back by reversals of the transfer.
+func (q *Queries) GetTransferReversalTotals(ctx context.Context, transferID int64) (GetTransferReversalTotalsRow, error) {
+	row := q.db.QueryRowContext(ctx, getTransferReversalTotals, transferID)
+	var i GetTransferReversalTotalsRow
+	err := row.Scan(&i.SourceAmount, &i.DestinationAmount)
+	return i, err
+}
+
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at
+SELECT id, from_account_id, to_account_id, amount, created_at, exchange_rate, source_amount, destination_amount, reversal_of
 FROM transfers
 WHERE from_account_id = $1
-   OR to_account_id = $2
+   OR to_account_id = $1
 ORDER BY id
-LIMIT $3 OFFSET $4
+LIMIT $2 OFFSET $3
 `
 
 type ListTransfersParams struct {
-	FromAccountID sql.NullInt64 `json:"from_account_id"`
-	ToAccountID   sql.NullInt64 `json:"to_account_id"`
-	Limit         int32         `json:"limit"`
-	Offset        int32         `json:"offset"`
+	AccountID sql.NullInt64 `json:"account_id"`
+	Limit     int32         `json:"limit"`
+	Offset    int32         `json:"offset"`
 }
 
 func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfers, error) {
-	rows, err := q.db.QueryContext(ctx, listTransfers,
-		arg.FromAccountID,
-		arg.ToAccountID,
+	rows, err := q.db.QueryContext(ctx, listTransfers, arg.AccountID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfers{}
+	for rows.Next() {
+		var i Transfers
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ExchangeRate,
+			&i.SourceAmount,
+			&i.DestinationAmount,
+			&i.ReversalOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserTransfers = `-- name: ListUserTransfers :many
+SELECT t.id, t.from_account_id, t.to_account_id, t.amount, t.created_at, t.exchange_rate, t.source_amount, t.destination_amount, t.reversal_of, fa.currency AS from_currency, ta.currency AS to_currency
+FROM transfers t
+         JOIN accounts fa ON fa.id = t.from_account_id
+         JOIN accounts ta ON ta.id = t.to_account_id
+WHERE (fa.owner = $1 OR ta.owner = $1)
+  AND ($2::varchar IS NULL
+    OR ($2 = 'out' AND fa.owner = $1)
+    OR ($2 = 'in' AND ta.owner = $1))
+  AND ($3::bigint IS NULL
+    OR (fa.owner = $1 AND t.to_account_id = $3)
+    OR (ta.owner = $1 AND t.from_account_id = $3))
+  AND ($4::bigint IS NULL
+    OR CASE WHEN $2 = 'in' OR fa.owner <> $1 THEN t.destination_amount ELSE t.amount END >= $4)
+  AND ($5::bigint IS NULL
+    OR CASE WHEN $2 = 'in' OR fa.owner <> $1 THEN t.destination_amount ELSE t.amount END <= $5)
+  AND ($6::timestamptz IS NULL OR t.created_at >= $6)
+  AND ($7::timestamptz IS NULL OR t.created_at < $7)
+ORDER BY t.created_at, t.id
+LIMIT $8 OFFSET $9
+`
+
+type ListUserTransfersParams struct {
+	Owner                 string         `json:"owner"`
+	Direction             sql.NullString `json:"direction"`
+	CounterpartyAccountID sql.NullInt64  `json:"counterparty_account_id"`
+	MinAmount             sql.NullInt64  `json:"min_amount"`
+	MaxAmount             sql.NullInt64  `json:"max_amount"`
+	FromTime              sql.NullTime   `json:"from_time"`
+	ToTime                sql.NullTime   `json:"to_time"`
+	Limit                 int32          `json:"limit"`
+	Offset                int32          `json:"offset"`
+}
+
+type ListUserTransfersRow struct {
+	ID                int64         `json:"id"`
+	FromAccountID     sql.NullInt64 `json:"from_account_id"`
+	ToAccountID       sql.NullInt64 `json:"to_account_id"`
+	Amount            int64         `json:"amount"`
+	CreatedAt         time.Time     `json:"created_at"`
+	ExchangeRate      string        `json:"exchange_rate"`
+	SourceAmount      int64         `json:"source_amount"`
+	DestinationAmount int64         `json:"destination_amount"`
+	ReversalOf        sql.NullInt64 `json:"reversal_of"`
+	FromCurrency      string        `json:"from_currency"`
+	ToCurrency        string        `json:"to_currency"`
+}
+
+// Lists the transfers touching any account of the owner. Every filter is optional.
+// The amount filters compare the amount in the currency of the owner's side, which is the destination amount of an incoming transfer.
+func (q *Queries) ListUserTransfers(ctx context.Context, arg ListUserTransfersParams) ([]ListUserTransfersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserTransfers,
+		arg.Owner,
+		arg.Direction,
+		arg.CounterpartyAccountID,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.FromTime,
+		arg.ToTime,
 		arg.Limit,
 		arg.Offset,
 	)
@@ -82,15 +237,118 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Transfers{}
+	items := []ListUserTransfersRow{}
 	for rows.Next() {
-		var i Transfers
+		var i ListUserTransfersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ExchangeRate,
+			&i.SourceAmount,
+			&i.DestinationAmount,
+			&i.ReversalOf,
+			&i.FromCurrency,
+			&i.ToCurrency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserTransfersAfter = `-- name: ListUserTransfersAfter :many
+SELECT t.id, t.from_account_id, t.to_account_id, t.amount, t.created_at, t.exchange_rate, t.source_amount, t.destination_amount, t.reversal_of, fa.currency AS from_currency, ta.currency AS to_currency
+FROM transfers t
+         JOIN accounts fa ON fa.id = t.from_account_id
+         JOIN accounts ta ON ta.id = t.to_account_id
+WHERE (fa.owner = $1 OR ta.owner = $1)
+  AND ($2::varchar IS NULL
+    OR ($2 = 'out' AND fa.owner = $1)
+    OR ($2 = 'in' AND ta.owner = $1))
+  AND ($3::bigint IS NULL
+    OR (fa.owner = $1 AND t.to_account_id = $3)
+    OR (ta.owner = $1 AND t.from_account_id = $3))
+  AND ($4::bigint IS NULL
+    OR CASE WHEN $2 = 'in' OR fa.owner <> $1 THEN t.destination_amount ELSE t.amount END >= $4)
+  AND ($5::bigint IS NULL
+    OR CASE WHEN $2 = 'in' OR fa.owner <> $1 THEN t.destination_amount ELSE t.amount END <= $5)
+  AND ($6::timestamptz IS NULL OR t.created_at >= $6)
+  AND ($7::timestamptz IS NULL OR t.created_at < $7)
+  AND (t.created_at, t.id) > ($8::timestamptz, $9::bigint)
+ORDER BY t.created_at, t.id
+LIMIT $10
+`
+
+type ListUserTransfersAfterParams struct {
+	Owner                 string         `json:"owner"`
+	Direction             sql.NullString `json:"direction"`
+	CounterpartyAccountID sql.NullInt64  `json:"counterparty_account_id"`
+	MinAmount             sql.NullInt64  `json:"min_amount"`
+	MaxAmount             sql.NullInt64  `json:"max_amount"`
+	FromTime              sql.NullTime   `json:"from_time"`
+	ToTime                sql.NullTime   `json:"to_time"`
+	CursorCreatedAt       time.Time      `json:"cursor_created_at"`
+	CursorID              int64          `json:"cursor_id"`
+	Limit                 int32          `json:"limit"`
+}
+
+type ListUserTransfersAfterRow struct {
+	ID                int64         `json:"id"`
+	FromAccountID     sql.NullInt64 `json:"from_account_id"`
+	ToAccountID       sql.NullInt64 `json:"to_account_id"`
+	Amount            int64         `json:"amount"`
+	CreatedAt         time.Time     `json:"created_at"`
+	ExchangeRate      string        `json:"exchange_rate"`
+	SourceAmount      int64         `json:"source_amount"`
+	DestinationAmount int64         `json:"destination_amount"`
+	ReversalOf        sql.NullInt64 `json:"reversal_of"`
+	FromCurrency      string        `json:"from_currency"`
+	ToCurrency        string        `json:"to_currency"`
+}
+
+// Keyset pagination of ListUserTransfers after the (created_at, id) cursor.
+func (q *Queries) ListUserTransfersAfter(ctx context.Context, arg ListUserTransfersAfterParams) ([]ListUserTransfersAfterRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserTransfersAfter,
+		arg.Owner,
+		arg.Direction,
+		arg.CounterpartyAccountID,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.FromTime,
+		arg.ToTime,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUserTransfersAfterRow{}
+	for rows.Next() {
+		var i ListUserTransfersAfterRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.FromAccountID,
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ExchangeRate,
+			&i.SourceAmount,
+			&i.DestinationAmount,
+			&i.ReversalOf,
+			&i.FromCurrency,
+			&i.ToCurrency,
 		); err != nil {
 			return nil, err
 		}
@@ -53,10 +53,14 @@ func createRandomTransfer(t *testing.T, fromAccount Accounts, toAccount Accounts
 		Valid: true,
 	}
 
+	amount := util.RandomMoney()
 	arg := CreateTransferParams{
-		FromAccountID: fromID,
-		ToAccountID:   toID,
-		Amount:        util.RandomMoney(),
+		FromAccountID:     fromID,
+		ToAccountID:       toID,
+		Amount:            amount,
+		ExchangeRate:      "1",
+		SourceAmount:      amount,
+		DestinationAmount: amount,
 	}
 
 	transfer, err := testQueries.CreateTransfer(context.Background(), arg)
@@ -67,6 +71,8 @@ func createRandomTransfer(t *testing.T, fromAccount Accounts, toAccount Accounts
 	require.Equal(t, arg.FromAccountID.Int64, transfer.FromAccountID.Int64)
 	require.Equal(t, arg.ToAccountID.Int64, transfer.ToAccountID.Int64)
 	require.Equal(t, arg.Amount, transfer.Amount)
+	require.Equal(t, arg.SourceAmount, transfer.SourceAmount)
+	require.Equal(t, arg.DestinationAmount, transfer.DestinationAmount)
 	require.NotZero(t, transfer.ID)
 	require.NotZero(t, transfer.CreatedAt)
 
@@ -106,21 +112,13 @@ func TestQueries_ListTransfers(t *testing.T) {
 		createRandomTransfer(t, fromAccount, toAccount)
 	}
 
-	fromID := sql.NullInt64{
-		Int64: fromAccount.ID,
-		Valid: true,
-	}
-
-	toID := sql.NullInt64{
-		Int64: toAccount.ID,
-		Valid: true,
-	}
-
 	arg := ListTransfersParams{
-		FromAccountID: fromID,
-		ToAccountID:   toID,
-		Limit:         5,
-		Offset:        5,
+		AccountID: sql.NullInt64{
+			Int64: fromAccount.ID,
+			Valid: true,
+		},
+		Limit:  5,
+		Offset: 5,
 	}
 
 	transfers, err := testQueries.ListTransfers(context.Background(), arg)
@@ -132,3 +130,87 @@ func TestQueries_ListTransfers(t *testing.T) {
 		require.NotEmpty(t, transfer)
 	}
 }
+
+func TestQueries_ListUserTransfers(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	account3 := createRandomAccount(t)
+
+	outgoing := createRandomTransfer(t, account1, account2)
+	incoming := createRandomTransfer(t, account3, account1)
+	createRandomTransfer(t, account2, account3)
+
+	arg := ListUserTransfersParams{
+		Owner:  account1.Owner,
+		Limit:  10,
+		Offset: 0,
+	}
+
+	transfers, err := testQueries.ListUserTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+	require.Equal(t, outgoing.ID, transfers[0].ID)
+	require.Equal(t, incoming.ID, transfers[1].ID)
+
+	// only transfers leaving the owner's accounts
+	arg.Direction = sql.NullString{String: "out", Valid: true}
+	transfers, err = testQueries.ListUserTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	require.Equal(t, outgoing.ID, transfers[0].ID)
+
+	// only transfers with the given counterparty
+	arg.Direction = sql.NullString{}
+	arg.CounterpartyAccountID = sql.NullInt64{Int64: account3.ID, Valid: true}
+	transfers, err = testQueries.ListUserTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	require.Equal(t, incoming.ID, transfers[0].ID)
+}
+
+func TestQueries_ListUserTransfersAmountFilter(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	// 100 in the currency of account2 were received as 15000 in the currency of account1
+	incoming, err := testQueries.CreateTransfer(context.Background(), CreateTransferParams{
+		FromAccountID:     sql.NullInt64{Int64: account2.ID, Valid: true},
+		ToAccountID:       sql.NullInt64{Int64: account1.ID, Valid: true},
+		Amount:            100,
+		ExchangeRate:      "150",
+		SourceAmount:      100,
+		DestinationAmount: 15000,
+	})
+	require.NoError(t, err)
+
+	// the amounts of an incoming transfer are compared in the currency of the receiving account
+	for _, direction := range []sql.NullString{{}, {String: "in", Valid: true}} {
+		arg := ListUserTransfersParams{
+			Owner:     account1.Owner,
+			Direction: direction,
+			MinAmount: sql.NullInt64{Int64: 10000, Valid: true},
+			Limit:     10,
+		}
+		transfers, err := testQueries.ListUserTransfers(context.Background(), arg)
+		require.NoError(t, err)
+		require.Len(t, transfers, 1)
+		require.Equal(t, incoming.ID, transfers[0].ID)
+
+		arg.MinAmount = sql.NullInt64{}
+		arg.MaxAmount = sql.NullInt64{Int64: 1000, Valid: true}
+		transfers, err = testQueries.ListUserTransfers(context.Background(), arg)
+		require.NoError(t, err)
+		require.Empty(t, transfers)
+	}
+
+	// the sender sees the amount it sent
+	transfers, err := testQueries.ListUserTransfers(context.Background(), ListUserTransfersParams{
+		Owner:     account2.Owner,
+		Direction: sql.NullString{String: "out", Valid: true},
+		MaxAmount: sql.NullInt64{Int64: 1000, Valid: true},
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	require.Equal(t, incoming.ID, transfers[0].ID)
+}
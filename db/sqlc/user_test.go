@@ -2,8 +2,11 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 	"practice-docker/util"
+	"strings"
 	"testing"
 	"time"
 )
@@ -27,6 +30,8 @@ func createRandomUser(t *testing.T) Users {
 	require.Equal(t, arg.HashedPassword, user.HashedPassword)
 	require.Equal(t, arg.FullName, user.FullName)
 	require.Equal(t, arg.Email, user.Email)
+	require.Equal(t, util.UserRole, user.Role)
+	require.False(t, user.IsEmailVerified)
 
 	require.NotZero(t, user.CreatedAt)
 
@@ -50,3 +55,97 @@ func TestQueries_GetUser(t *testing.T) {
 	require.WithinDuration(t, user1.CreatedAt, user2.CreatedAt, time.Second)
 	require.WithinDuration(t, user1.PasswordChangedAt, user2.PasswordChangedAt, time.Second)
 }
+
+func TestQueries_ListUsers(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		createRandomUser(t)
+	}
+
+	users, err := testQueries.ListUsers(context.Background(), ListUsersParams{
+		Limit:  5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, users, 5)
+
+	for i := 1; i < len(users); i++ {
+		require.Less(t, users[i-1].Username, users[i].Username)
+	}
+}
+
+func TestQueries_UpdateUserPassword(t *testing.T) {
+	user1 := createRandomUser(t)
+
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	arg := UpdateUserPasswordParams{
+		Username:          user1.Username,
+		HashedPassword:    hashedPassword,
+		PasswordChangedAt: time.Now(),
+	}
+
+	user2, err := testQueries.UpdateUserPassword(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, user1.Username, user2.Username)
+	require.Equal(t, hashedPassword, user2.HashedPassword)
+	require.WithinDuration(t, arg.PasswordChangedAt, user2.PasswordChangedAt, time.Second)
+	require.True(t, user2.PasswordChangedAt.After(user1.PasswordChangedAt))
+}
+
+func TestQueries_GetUserByEmail(t *testing.T) {
+	user1 := createRandomUser(t)
+
+	// the email is matched without regard to case
+	user2, err := testQueries.GetUserByEmail(context.Background(), strings.ToUpper(user1.Email))
+	require.NoError(t, err)
+	require.Equal(t, user1.Username, user2.Username)
+	require.Equal(t, user1.Email, user2.Email)
+}
+
+func TestQueries_GetUserPasswordChangedAt(t *testing.T) {
+	user := createRandomUser(t)
+
+	passwordChangedAt, err := testQueries.GetUserPasswordChangedAt(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.WithinDuration(t, user.PasswordChangedAt, passwordChangedAt, time.Second)
+
+	_, err = testQueries.GetUserPasswordChangedAt(context.Background(), util.RandomOwner())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestQueries_CreateUserDuplicateEmail(t *testing.T) {
+	user := createRandomUser(t)
+
+	// emails are unique regardless of case
+	_, err := testQueries.CreateUser(context.Background(), CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: user.HashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          strings.ToUpper(user.Email),
+	})
+	require.Error(t, err)
+
+	pqErr, ok := err.(*pq.Error)
+	require.True(t, ok)
+	require.Equal(t, "unique_violation", pqErr.Code.Name())
+}
+
+func TestQueries_VerifyUserEmail(t *testing.T) {
+	user1 := createRandomUser(t)
+
+	// a link for another email doesn't verify the user
+	_, err := testQueries.VerifyUserEmail(context.Background(), VerifyUserEmailParams{
+		Username: user1.Username,
+		Email:    util.RandomEmail(),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	user2, err := testQueries.VerifyUserEmail(context.Background(), VerifyUserEmailParams{
+		Username: user1.Username,
+		Email:    user1.Email,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user1.Username, user2.Username)
+	require.True(t, user2.IsEmailVerified)
+}
@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"practice-docker/util"
+	"testing"
+	"time"
+)
+
+func createRandomWebhook(t *testing.T, owner string) Webhooks {
+	arg := CreateWebhookParams{
+		Owner:  owner,
+		Url:    "https://example.com/hooks/" + util.RandomString(6),
+		Secret: util.RandomString(32),
+	}
+
+	webhook, err := testQueries.CreateWebhook(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, webhook.ID)
+	require.Equal(t, arg.Owner, webhook.Owner)
+	require.Equal(t, arg.Url, webhook.Url)
+	require.Equal(t, arg.Secret, webhook.Secret)
+	require.NotZero(t, webhook.CreatedAt)
+
+	return webhook
+}
+
+func TestQueries_ListWebhooks(t *testing.T) {
+	user := createRandomUser(t)
+	webhook1 := createRandomWebhook(t, user.Username)
+	webhook2 := createRandomWebhook(t, user.Username)
+
+	webhooks, err := testQueries.ListWebhooks(context.Background(), ListWebhooksParams{
+		Owner:  user.Username,
+		Limit:  5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, webhooks, 2)
+	require.Equal(t, webhook1.ID, webhooks[0].ID)
+	require.Equal(t, webhook2.ID, webhooks[1].ID)
+}
+
+func TestQueries_DeleteWebhook(t *testing.T) {
+	user := createRandomUser(t)
+	webhook := createRandomWebhook(t, user.Username)
+
+	err := testQueries.DeleteWebhook(context.Background(), webhook.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetWebhook(context.Background(), webhook.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestStore_TransferTxWebhookDeliveries(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createRandomAccount(t)
+	toAccount := createRandomAccount(t)
+
+	// only the owner of the credited account is notified
+	toWebhook := createRandomWebhook(t, toAccount.Owner)
+	fromWebhook := createRandomWebhook(t, fromAccount.Owner)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	deliveries, err := testQueries.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		WebhookID: toWebhook.ID,
+		Limit:     5,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+
+	// the newest delivery comes first
+	require.Equal(t, WebhookAccountCredited, deliveries[0].EventType)
+	require.Equal(t, WebhookTransferCreated, deliveries[1].EventType)
+	for _, delivery := range deliveries {
+		require.Equal(t, WebhookDeliveryStatusPending, delivery.Status)
+		require.Zero(t, delivery.Attempts)
+	}
+
+	var credited AccountCreditedEvent
+	require.NoError(t, json.Unmarshal(deliveries[0].Payload, &credited))
+	require.Equal(t, AccountCreditedEvent{
+		AccountID:  toAccount.ID,
+		TransferID: result.Transfer.ID,
+		Amount:     10,
+		Currency:   toAccount.Currency,
+		Balance:    toAccount.Balance + 10,
+	}, credited)
+
+	var transfer Transfers
+	require.NoError(t, json.Unmarshal(deliveries[1].Payload, &transfer))
+	require.Equal(t, result.Transfer.ID, transfer.ID)
+
+	deliveries, err = testQueries.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		WebhookID: fromWebhook.ID,
+		Limit:     5,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Empty(t, deliveries)
+}
+
+func TestQueries_ClaimDueWebhookDelivery(t *testing.T) {
+	user := createRandomUser(t)
+	webhook := createRandomWebhook(t, user.Username)
+
+	err := testQueries.CreateWebhookDeliveries(context.Background(), CreateWebhookDeliveriesParams{
+		EventType: WebhookAccountCredited,
+		Payload:   json.RawMessage(`{}`),
+		Owner:     user.Username,
+	})
+	require.NoError(t, err)
+
+	deliveries, err := testQueries.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		WebhookID: webhook.ID,
+		Limit:     1,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+
+	// the delivery is due long before any delivery of the other tests
+	now := time.Now().AddDate(-30, 0, 0)
+	_, err = testQueries.UpdateWebhookDelivery(context.Background(), UpdateWebhookDeliveryParams{
+		ID:            deliveries[0].ID,
+		Status:        WebhookDeliveryStatusPending,
+		NextAttemptAt: now,
+	})
+	require.NoError(t, err)
+
+	arg := ClaimDueWebhookDeliveryParams{
+		LeaseUntil: now.Add(time.Minute),
+		Now:        now,
+	}
+	claimed, err := testQueries.ClaimDueWebhookDelivery(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, deliveries[0].ID, claimed.ID)
+	require.WithinDuration(t, arg.LeaseUntil, claimed.NextAttemptAt, time.Millisecond)
+
+	// the claim is committed, so the delivery is not due again until the lease ends
+	claimed, err = testQueries.ClaimDueWebhookDelivery(context.Background(), arg)
+	if err == nil {
+		require.NotEqual(t, deliveries[0].ID, claimed.ID)
+	} else {
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	}
+
+	delivered, err := testQueries.UpdateWebhookDelivery(context.Background(), UpdateWebhookDeliveryParams{
+		ID:             deliveries[0].ID,
+		Status:         WebhookDeliveryStatusSucceeded,
+		Attempts:       1,
+		NextAttemptAt:  arg.LeaseUntil,
+		ResponseStatus: sql.NullInt32{Int32: 200, Valid: true},
+		DeliveredAt:    sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliveryStatusSucceeded, delivered.Status)
+	require.Equal(t, int32(1), delivered.Attempts)
+	require.Equal(t, int32(200), delivered.ResponseStatus.Int32)
+}
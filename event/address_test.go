@@ -0,0 +1,74 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	for _, address := range []string{"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"} {
+		require.True(t, IsPublicIP(net.ParseIP(address)), address)
+	}
+
+	for _, address := range []string{
+		"127.0.0.1", "::1", "0.0.0.0", "::", "10.1.2.3", "172.16.0.1", "192.168.1.1",
+		"169.254.169.254", "fe80::1", "fd00::1", "100.64.0.1", "::ffff:127.0.0.1", "64:ff9b::a9fe:a9fe",
+	} {
+		require.False(t, IsPublicIP(net.ParseIP(address)), address)
+	}
+}
+
+// fakeResolver answers with the addresses of a map instead of DNS.
+type fakeResolver map[string][]string
+
+func (resolver fakeResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	addresses, ok := resolver[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+
+	addrs := make([]net.IPAddr, len(addresses))
+	for i, address := range addresses {
+		addrs[i] = net.IPAddr{IP: net.ParseIP(address)}
+	}
+	return addrs, nil
+}
+
+func TestCheckWebhookURL(t *testing.T) {
+	resolver := fakeResolver{
+		"hooks.example.com": {"93.184.216.34"},
+		"internal.example":  {"93.184.216.34", "10.0.0.5"},
+	}
+	ctx := context.Background()
+
+	require.NoError(t, CheckWebhookURL(ctx, resolver, "https://hooks.example.com/events"))
+	require.NoError(t, CheckWebhookURL(ctx, resolver, "https://93.184.216.34:8443/events"))
+
+	// a single private address is enough to reject the host
+	require.ErrorIs(t, CheckWebhookURL(ctx, resolver, "https://internal.example/events"), ErrNonPublicAddress)
+	require.ErrorIs(t, CheckWebhookURL(ctx, resolver, "http://169.254.169.254/latest/meta-data"), ErrNonPublicAddress)
+	require.ErrorIs(t, CheckWebhookURL(ctx, resolver, "http://[::1]:8080/"), ErrNonPublicAddress)
+
+	err := CheckWebhookURL(ctx, resolver, "https://unknown.example/events")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrNonPublicAddress)
+}
+
+func TestPublicWebhookSender_RefusesLoopback(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	// the test server listens on a loopback address, as would a service next to the bank
+	status, err := NewPublicWebhookSender().Send(context.Background(), server.URL, "secret", Event{ID: 1, Type: "test"})
+	require.ErrorIs(t, err, ErrNonPublicAddress)
+	require.Zero(t, status)
+	require.False(t, called)
+}
@@ -0,0 +1,121 @@
+package event
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultWebhookTimeout bounds a single delivery, so a slow endpoint does not hold the outbox row.
+const defaultWebhookTimeout = 10 * time.Second
+
+const (
+	// signatureHeader carries "sha256=" and the hex encoded HMAC of the timestamp and the body.
+	signatureHeader = "X-Event-Signature"
+	// timestampHeader carries the unix time the request was signed at.
+	timestampHeader = "X-Event-Timestamp"
+)
+
+// WebhookSender posts events as JSON. Any response other than 2xx is treated as a failed delivery.
+type WebhookSender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewWebhookSender creates a sender that gives up on a request after defaultWebhookTimeout.
+func NewWebhookSender() *WebhookSender {
+	return &WebhookSender{
+		client: &http.Client{Timeout: defaultWebhookTimeout},
+		now:    time.Now,
+	}
+}
+
+// NewPublicWebhookSender creates a sender for webhooks of users.
+// It only connects to public addresses, see IsPublicIP, and never through a proxy.
+func NewPublicWebhookSender() *WebhookSender {
+	dialer := &net.Dialer{
+		Timeout: defaultWebhookTimeout,
+		Control: dialPublicOnly,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &WebhookSender{
+		client: &http.Client{Timeout: defaultWebhookTimeout, Transport: transport},
+		now:    time.Now,
+	}
+}
+
+// Send posts the event to url and returns the status code of the response.
+// When secret is not empty the request is signed, see Sign.
+func (sender *WebhookSender) Send(ctx context.Context, url string, secret string, event Event) (int, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Id", strconv.FormatInt(event.ID, 10))
+	req.Header.Set("X-Event-Type", event.Type)
+	if secret != "" {
+		timestamp := sender.now().Unix()
+		req.Header.Set(timestampHeader, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(signatureHeader, "sha256="+Sign(secret, timestamp, body))
+	}
+
+	resp, err := sender.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 under secret of the timestamp, a dot and the body.
+// Receivers compute the same value and should reject requests with an old timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookPublisher posts every event to a single URL.
+type WebhookPublisher struct {
+	url    string
+	sender *WebhookSender
+}
+
+// NewWebhookPublisher creates a publisher that posts events to url.
+func NewWebhookPublisher(url string) *WebhookPublisher {
+	return &WebhookPublisher{
+		url:    url,
+		sender: NewWebhookSender(),
+	}
+}
+
+// Publish posts the event and waits for it to be accepted.
+func (publisher *WebhookPublisher) Publish(ctx context.Context, event Event) error {
+	_, err := publisher.sender.Send(ctx, publisher.url, "", event)
+	return err
+}
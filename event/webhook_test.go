@@ -0,0 +1,86 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookPublisher(t *testing.T) {
+	event := Event{
+		ID:        7,
+		Type:      "transfer.completed",
+		Payload:   json.RawMessage(`{"transfer":{"id":1}}`),
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+
+	var method string
+	var header http.Header
+	var received Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		header = r.Header
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	err := NewWebhookPublisher(server.URL).Publish(context.Background(), event)
+	require.NoError(t, err)
+	require.Equal(t, http.MethodPost, method)
+	require.Equal(t, "application/json", header.Get("Content-Type"))
+	require.Equal(t, "7", header.Get("X-Event-Id"))
+	require.Equal(t, event.Type, header.Get("X-Event-Type"))
+	require.Equal(t, event.ID, received.ID)
+	require.Equal(t, event.Type, received.Type)
+	require.JSONEq(t, string(event.Payload), string(received.Payload))
+	require.True(t, event.CreatedAt.Equal(received.CreatedAt))
+}
+
+func TestWebhookPublisher_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := NewWebhookPublisher(server.URL).Publish(context.Background(), Event{ID: 1})
+	require.EqualError(t, err, "webhook responded with status 503")
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":1}`)
+
+	signature := Sign("secret", 1700000000, body)
+	require.Len(t, signature, 64)
+	require.Equal(t, signature, Sign("secret", 1700000000, body))
+
+	// every part of the signed message changes the signature
+	require.NotEqual(t, signature, Sign("other", 1700000000, body))
+	require.NotEqual(t, signature, Sign("secret", 1700000001, body))
+	require.NotEqual(t, signature, Sign("secret", 1700000000, []byte(`{"id":2}`)))
+}
+
+func TestWebhookSender_Signed(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+	}))
+	defer server.Close()
+
+	sender := NewWebhookSender()
+	sender.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	event := Event{ID: 3, Type: "account.credited", Payload: json.RawMessage(`{}`)}
+	status, err := sender.Send(context.Background(), server.URL, "secret", event)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	body, err := json.Marshal(event)
+	require.NoError(t, err)
+	require.Equal(t, "1700000000", header.Get(timestampHeader))
+	require.Equal(t, "sha256="+Sign("secret", 1700000000, body), header.Get(signatureHeader))
+}
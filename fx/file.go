@@ -0,0 +1,81 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileProvider serves exchange rates from a JSON file of "FROM/TO" pairs to decimal rates.
+// The file is read again when its modification time changes, so rates can be updated without a restart.
+type FileProvider struct {
+	path string
+
+	mu      sync.RWMutex
+	rates   map[string]*big.Rat
+	modTime time.Time
+}
+
+// NewFileProvider creates a provider that reads the rates from the file at path.
+func NewFileProvider(path string) (*FileProvider, error) {
+	provider := &FileProvider{path: path}
+	err := provider.reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return provider, nil
+}
+
+func (provider *FileProvider) Rate(_ context.Context, from string, to string) (*big.Rat, error) {
+	err := provider.reload()
+	if err != nil {
+		return nil, err
+	}
+
+	provider.mu.RLock()
+	defer provider.mu.RUnlock()
+
+	return lookupRate(provider.rates, from, to)
+}
+
+// reload reads the file if it changed since it was last read.
+func (provider *FileProvider) reload() error {
+	info, err := os.Stat(provider.path)
+	if err != nil {
+		return err
+	}
+
+	provider.mu.RLock()
+	unchanged := provider.rates != nil && info.ModTime().Equal(provider.modTime)
+	provider.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	data, err := os.ReadFile(provider.path)
+	if err != nil {
+		return err
+	}
+
+	var table map[string]string
+	err = json.Unmarshal(data, &table)
+	if err != nil {
+		return err
+	}
+
+	rates, err := parseRates(table)
+	if err != nil {
+		return err
+	}
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	provider.rates = rates
+	provider.modTime = info.ModTime()
+	return nil
+}
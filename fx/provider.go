@@ -0,0 +1,119 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// RatePrecision is the number of decimal places kept for an exchange rate.
+const RatePrecision = 8
+
+// ErrRateNotFound is returned when no exchange rate is known for a currency pair.
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// ExchangeRateProvider looks up the rate to convert an amount from one currency to another.
+type ExchangeRateProvider interface {
+	// Rate returns how many units of the to currency one unit of the from currency buys.
+	Rate(ctx context.Context, from string, to string) (*big.Rat, error)
+}
+
+// Convert converts an amount with the given rate, rounding half away from zero.
+func Convert(amount int64, rate *big.Rat) int64 {
+	return roundRat(new(big.Rat).Mul(new(big.Rat).SetInt64(amount), rate)).Int64()
+}
+
+// FormatRate formats the rate as a decimal string with RatePrecision places.
+func FormatRate(rate *big.Rat) string {
+	return rate.FloatString(RatePrecision)
+}
+
+// ParseRate parses a positive decimal rate such as "0.92" and rounds it to RatePrecision places.
+func ParseRate(s string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(s)
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid exchange rate %q", s)
+	}
+
+	return roundRate(rate), nil
+}
+
+// pairKey is the key of a currency pair in a rate table, e.g. "USD/EUR".
+func pairKey(from string, to string) string {
+	return from + "/" + to
+}
+
+// lookupRate finds the rate of the pair in the table.
+// The same currency always converts at 1 and a missing pair falls back to the inverse of the reverse pair.
+func lookupRate(rates map[string]*big.Rat, from string, to string) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+
+	if rate, ok := rates[pairKey(from, to)]; ok {
+		return new(big.Rat).Set(rate), nil
+	}
+
+	if rate, ok := rates[pairKey(to, from)]; ok {
+		return roundRate(new(big.Rat).Inv(rate)), nil
+	}
+
+	return nil, fmt.Errorf("%w: %s to %s", ErrRateNotFound, from, to)
+}
+
+// parseRates parses a table of "FROM/TO" pairs to decimal rates.
+func parseRates(table map[string]string) (map[string]*big.Rat, error) {
+	rates := make(map[string]*big.Rat, len(table))
+	for pair, value := range table {
+		rate, err := ParseRate(value)
+		if err != nil {
+			return nil, fmt.Errorf("pair %s: %w", pair, err)
+		}
+		rates[pair] = rate
+	}
+
+	return rates, nil
+}
+
+// roundRate rounds the rate to RatePrecision decimal places.
+func roundRate(rate *big.Rat) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(RatePrecision), nil)
+	scaled := roundRat(new(big.Rat).Mul(rate, new(big.Rat).SetInt(scale)))
+	return new(big.Rat).SetFrac(scaled, scale)
+}
+
+// roundRat rounds to the nearest integer, half away from zero.
+func roundRat(r *big.Rat) *big.Int {
+	num := new(big.Int).Abs(r.Num())
+	// (2*|num| + den) / (2*den) rounds half up on the absolute value
+	num.Mul(num, big.NewInt(2)).Add(num, r.Denom())
+	den := new(big.Int).Mul(r.Denom(), big.NewInt(2))
+	result := num.Quo(num, den)
+	if r.Sign() < 0 {
+		result.Neg(result)
+	}
+
+	return result
+}
+
+// ConvertMinorUnits converts an amount between currencies with a different number of minor units.
+// The rate is quoted in major units, so 100 USD cents at a rate of 150 are 150 JPY.
+func ConvertMinorUnits(amount int64, rate *big.Rat, fromExponent int32, toExponent int32) int64 {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(toExponent-fromExponent))), nil)
+	scaled := new(big.Rat).Set(rate)
+	if toExponent > fromExponent {
+		scaled.Mul(scaled, new(big.Rat).SetInt(scale))
+	} else {
+		scaled.Quo(scaled, new(big.Rat).SetInt(scale))
+	}
+
+	return Convert(amount, scaled)
+}
+
+func abs(n int32) int32 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
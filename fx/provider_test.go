@@ -0,0 +1,105 @@
+package fx
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConvert(t *testing.T) {
+	testCases := []struct {
+		amount int64
+		rate   string
+		want   int64
+	}{
+		{amount: 100, rate: "1", want: 100},
+		{amount: 100, rate: "0.92", want: 92},
+		{amount: 105, rate: "0.5", want: 53},
+		{amount: -105, rate: "0.5", want: -53},
+		{amount: 333, rate: "1.33333333", want: 444},
+	}
+
+	for _, tc := range testCases {
+		rate, err := ParseRate(tc.rate)
+		require.NoError(t, err)
+		require.Equal(t, tc.want, Convert(tc.amount, rate))
+	}
+}
+
+func TestConvertMinorUnits(t *testing.T) {
+	rate, err := ParseRate("150")
+	require.NoError(t, err)
+
+	// 1.00 USD is 150 JPY
+	require.Equal(t, int64(150), ConvertMinorUnits(100, rate, 2, 0))
+
+	rate, err = ParseRate("0.3")
+	require.NoError(t, err)
+
+	// 1.00 USD is 0.300 KWD
+	require.Equal(t, int64(300), ConvertMinorUnits(100, rate, 2, 3))
+	require.Equal(t, int64(30), ConvertMinorUnits(100, rate, 2, 2))
+}
+
+func TestParseRate(t *testing.T) {
+	rate, err := ParseRate("1.123456789")
+	require.NoError(t, err)
+	require.Equal(t, "1.12345679", FormatRate(rate))
+
+	for _, invalid := range []string{"", "abc", "0", "-1.5"} {
+		_, err := ParseRate(invalid)
+		require.Error(t, err)
+	}
+}
+
+func TestStaticProvider(t *testing.T) {
+	provider, err := NewStaticProvider(map[string]string{"USD/EUR": "0.8"})
+	require.NoError(t, err)
+
+	rate, err := provider.Rate(context.Background(), "USD", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, "0.80000000", FormatRate(rate))
+
+	// the inverse of the reverse pair is used when the pair is missing
+	rate, err = provider.Rate(context.Background(), "EUR", "USD")
+	require.NoError(t, err)
+	require.Equal(t, "1.25000000", FormatRate(rate))
+
+	rate, err = provider.Rate(context.Background(), "CAD", "CAD")
+	require.NoError(t, err)
+	require.Zero(t, rate.Cmp(big.NewRat(1, 1)))
+
+	_, err = provider.Rate(context.Background(), "USD", "CAD")
+	require.ErrorIs(t, err, ErrRateNotFound)
+
+	_, err = NewStaticProvider(map[string]string{"USD/EUR": "zero"})
+	require.Error(t, err)
+}
+
+func TestFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"USD/CAD": "1.35"}`), 0o600))
+
+	provider, err := NewFileProvider(path)
+	require.NoError(t, err)
+
+	rate, err := provider.Rate(context.Background(), "USD", "CAD")
+	require.NoError(t, err)
+	require.Equal(t, "1.35000000", FormatRate(rate))
+
+	// the file is read again after it changes
+	require.NoError(t, os.WriteFile(path, []byte(`{"USD/CAD": "1.4"}`), 0o600))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	rate, err = provider.Rate(context.Background(), "USD", "CAD")
+	require.NoError(t, err)
+	require.Equal(t, "1.40000000", FormatRate(rate))
+
+	_, err = NewFileProvider(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
@@ -0,0 +1,25 @@
+package fx
+
+import (
+	"context"
+	"math/big"
+)
+
+// StaticProvider serves exchange rates from a fixed table.
+type StaticProvider struct {
+	rates map[string]*big.Rat
+}
+
+// NewStaticProvider creates a provider from a table of "FROM/TO" pairs to decimal rates, e.g. {"USD/EUR": "0.92"}.
+func NewStaticProvider(table map[string]string) (*StaticProvider, error) {
+	rates, err := parseRates(table)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StaticProvider{rates: rates}, nil
+}
+
+func (provider *StaticProvider) Rate(_ context.Context, from string, to string) (*big.Rat, error) {
+	return lookupRate(provider.rates, from, to)
+}
@@ -0,0 +1,54 @@
+package gapi
+
+import (
+	"context"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	db "practice-docker/db/sqlc"
+	"practice-docker/pb"
+)
+
+func convertUser(user db.Users) *pb.User {
+	return &pb.User{
+		Username:          user.Username,
+		FullName:          user.FullName,
+		Email:             user.Email,
+		Role:              user.Role,
+		PasswordChangedAt: timestamppb.New(user.PasswordChangedAt),
+		CreatedAt:         timestamppb.New(user.CreatedAt),
+	}
+}
+
+func (server *Server) convertAccount(ctx context.Context, account db.Accounts) *pb.Account {
+	return &pb.Account{
+		Id:               account.ID,
+		Owner:            account.Owner,
+		Balance:          account.Balance,
+		FormattedBalance: server.currencies.Format(ctx, account.Currency, account.Balance),
+		Currency:         account.Currency,
+		OverdraftLimit:   account.OverdraftLimit,
+		Status:           string(account.Status),
+		CreatedAt:        timestamppb.New(account.CreatedAt),
+	}
+}
+
+func convertTransfer(transfer db.Transfers) *pb.Transfer {
+	return &pb.Transfer{
+		Id:                transfer.ID,
+		FromAccountId:     transfer.FromAccountID.Int64,
+		ToAccountId:       transfer.ToAccountID.Int64,
+		SourceAmount:      transfer.SourceAmount,
+		DestinationAmount: transfer.DestinationAmount,
+		ExchangeRate:      transfer.ExchangeRate,
+		ReversalOf:        transfer.ReversalOf.Int64,
+		CreatedAt:         timestamppb.New(transfer.CreatedAt),
+	}
+}
+
+func convertEntry(entry db.Entries) *pb.Entry {
+	return &pb.Entry{
+		Id:        entry.ID,
+		AccountId: entry.AccountID.Int64,
+		Amount:    entry.Amount,
+		CreatedAt: timestamppb.New(entry.CreatedAt),
+	}
+}
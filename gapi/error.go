@@ -0,0 +1,76 @@
+package gapi
+
+import (
+	"errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"practice-docker/service"
+	"practice-docker/throttle"
+)
+
+func fieldViolation(field string, err error) *errdetails.BadRequest_FieldViolation {
+	return &errdetails.BadRequest_FieldViolation{
+		Field:       field,
+		Description: err.Error(),
+	}
+}
+
+// invalidArgumentError returns an InvalidArgument status that lists every invalid field.
+func invalidArgumentError(violations []*errdetails.BadRequest_FieldViolation) error {
+	badRequest := &errdetails.BadRequest{FieldViolations: violations}
+	statusInvalid := status.New(codes.InvalidArgument, "invalid parameters")
+
+	statusDetails, err := statusInvalid.WithDetails(badRequest)
+	if err != nil {
+		return statusInvalid.Err()
+	}
+
+	return statusDetails.Err()
+}
+
+// serviceError maps an error of the service layer to a gRPC status.
+func serviceError(err error) error {
+	switch service.ErrorCode(err) {
+	case service.CodeInvalidArgument, service.CodeCurrencyMismatch:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case service.CodeNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case service.CodeUnauthenticated:
+		return status.Error(codes.Unauthenticated, err.Error())
+	case service.CodeForbidden:
+		return status.Error(codes.PermissionDenied, err.Error())
+	case service.CodeAlreadyExists:
+		return status.Error(codes.AlreadyExists, err.Error())
+	case service.CodeConflict:
+		return status.Error(codes.Aborted, err.Error())
+	case service.CodeFailedPrecondition, service.CodeInsufficientFunds:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case service.CodeResourceExhausted:
+		return resourceExhaustedError(err)
+	}
+	return status.Errorf(codes.Internal, "internal error: %s", err)
+}
+
+// resourceExhaustedError returns a ResourceExhausted status that tells a throttled client when to retry.
+func resourceExhaustedError(err error) error {
+	statusExhausted := status.New(codes.ResourceExhausted, err.Error())
+
+	var throttled *throttle.ThrottledError
+	if !errors.As(err, &throttled) {
+		return statusExhausted.Err()
+	}
+
+	retryInfo := &errdetails.RetryInfo{RetryDelay: durationpb.New(throttled.RetryAfter)}
+	statusDetails, err := statusExhausted.WithDetails(retryInfo)
+	if err != nil {
+		return statusExhausted.Err()
+	}
+
+	return statusDetails.Err()
+}
+
+func unauthenticatedError(err error) error {
+	return status.Errorf(codes.Unauthenticated, "unauthorized: %s", err)
+}
@@ -0,0 +1,103 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	mockDB "practice-docker/db/mock"
+	db "practice-docker/db/sqlc"
+	"practice-docker/fx"
+	"practice-docker/mail"
+	"practice-docker/service"
+	"practice-docker/throttle"
+	"practice-docker/token"
+	"practice-docker/util"
+	"testing"
+	"time"
+)
+
+// testExchangeRates has no rate for CAD, so transfers into CAD accounts fail.
+var testExchangeRates = map[string]string{
+	util.USD + "/" + util.EUR: "0.9",
+}
+
+var testCurrencies = []util.Currency{
+	{Code: util.USD, Exponent: 2, Enabled: true},
+	{Code: util.EUR, Exponent: 2, Enabled: true},
+	{Code: util.CAD, Exponent: 2, Enabled: true},
+}
+
+func newTestServer(t *testing.T, store db.Store) *Server {
+	config := util.Config{
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenLifetime:  time.Minute,
+		RefreshTokenLifetime: time.Hour,
+	}
+
+	rates, err := fx.NewStaticProvider(testExchangeRates)
+	require.NoError(t, err)
+
+	server, err := NewServer(config, Dependencies{
+		Dependencies: service.Dependencies{
+			Store:       allowAuthentication(store),
+			Revocations: token.NewMemoryRevocationStore(),
+			Rates:       rates,
+			Currencies:  util.NewStaticCurrencyRegistry(testCurrencies...),
+			Mailer:      mail.NewMemoryMailer(),
+			Logins:      throttle.NewLoginThrottle(throttle.NewMemoryAttemptStore(), throttle.Policy{}),
+		},
+	})
+	require.NoErrorf(t, err, "failed to create server: %v", err)
+
+	return server
+}
+
+// allowAuthentication lets every access token of the test through the password change check,
+// unless the test expects its own calls of GetUserPasswordChangedAt first.
+func allowAuthentication(store db.Store) db.Store {
+	if mockStore, ok := store.(*mockDB.MockStore); ok {
+		mockStore.EXPECT().
+			GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(time.Time{}, nil)
+	}
+	return store
+}
+
+// newContextWithBearerToken returns an incoming context as the gRPC server sees it for an authorized call.
+func newContextWithBearerToken(t *testing.T, tokenMaker token.Maker, username string, duration time.Duration) context.Context {
+	accessToken, _, err := tokenMaker.CreateToken(username, util.UserRole, token.TokenTypeAccess, duration)
+	require.NoError(t, err)
+
+	md := metadata.MD{
+		authorizationHeader: []string{fmt.Sprintf("%s %s", authorizationBearer, accessToken)},
+	}
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+func randomUser(t *testing.T) (user db.Users, password string) {
+	password = util.RandomString(6)
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+
+	user = db.Users{
+		Username:       util.RandomOwner(),
+		HashedPassword: hashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+		Role:           util.UserRole,
+	}
+	return
+}
+
+func randomAccount(owner string, currency string) db.Accounts {
+	return db.Accounts{
+		ID:       util.RandomInt(1, 1000),
+		Owner:    owner,
+		Balance:  util.RandomMoney(),
+		Currency: currency,
+		Status:   db.AccountStatusActive,
+	}
+}
@@ -0,0 +1,104 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+	"strings"
+)
+
+const (
+	userAgentHeader     = "user-agent"
+	xForwardedForHeader = "x-forwarded-for"
+)
+
+// Metadata is the client information that is stored with a session.
+type Metadata struct {
+	UserAgent string
+	ClientIP  string
+}
+
+func (server *Server) extractMetadata(ctx context.Context) *Metadata {
+	mtdt := &Metadata{}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if userAgents := md.Get(userAgentHeader); len(userAgents) > 0 {
+		mtdt.UserAgent = userAgents[0]
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			host = p.Addr.String()
+		}
+		mtdt.ClientIP = server.clientIP(host, md.Get(xForwardedForHeader))
+	}
+
+	return mtdt
+}
+
+// clientIP returns the address of the client that connected from peerIP.
+// The x-forwarded-for metadata is set by the client, so it is only believed when the peer is a trusted proxy.
+// Like gin, it is read from right to left and the first address that isn't a trusted proxy is the client.
+func (server *Server) clientIP(peerIP string, forwarded []string) string {
+	if !server.isTrustedProxy(net.ParseIP(peerIP)) || len(forwarded) == 0 {
+		return peerIP
+	}
+
+	addresses := strings.Split(strings.Join(forwarded, ","), ",")
+	for i := len(addresses) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(addresses[i]))
+		if ip == nil {
+			return peerIP
+		}
+		if i == 0 || !server.isTrustedProxy(ip) {
+			return ip.String()
+		}
+	}
+
+	return peerIP
+}
+
+func (server *Server) isTrustedProxy(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range server.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies parses IPs and CIDRs, the way gin's SetTrustedProxies accepts them.
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy: %s", proxy)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy: %w", err)
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
@@ -0,0 +1,82 @@
+package gapi
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+	"testing"
+)
+
+func TestServer_extractMetadata(t *testing.T) {
+	proxies, err := parseTrustedProxies([]string{"127.0.0.1", "10.0.0.0/8"})
+	require.NoError(t, err)
+	server := &Server{trustedProxies: proxies}
+
+	testCases := []struct {
+		name      string
+		peerIP    string
+		forwarded []string
+		clientIP  string
+	}{
+		{
+			name:     "NoProxy",
+			peerIP:   "203.0.113.7",
+			clientIP: "203.0.113.7",
+		},
+		{
+			// anyone can send the metadata, so it is ignored unless a trusted proxy forwarded the call
+			name:      "UntrustedPeer",
+			peerIP:    "203.0.113.7",
+			forwarded: []string{"198.51.100.1"},
+			clientIP:  "203.0.113.7",
+		},
+		{
+			name:      "TrustedProxy",
+			peerIP:    "127.0.0.1",
+			forwarded: []string{"198.51.100.1"},
+			clientIP:  "198.51.100.1",
+		},
+		{
+			// the client can prepend any address, only the one the trusted proxies saw counts
+			name:      "SpoofedChain",
+			peerIP:    "10.0.0.2",
+			forwarded: []string{"192.0.2.99, 198.51.100.1, 10.0.0.3"},
+			clientIP:  "198.51.100.1",
+		},
+		{
+			name:      "InvalidAddress",
+			peerIP:    "127.0.0.1",
+			forwarded: []string{"not-an-ip"},
+			clientIP:  "127.0.0.1",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{
+				Addr: &net.TCPAddr{IP: net.ParseIP(tc.peerIP), Port: 50000},
+			})
+			md := metadata.MD{userAgentHeader: []string{"grpc-test"}}
+			if tc.forwarded != nil {
+				md[xForwardedForHeader] = tc.forwarded
+			}
+			ctx = metadata.NewIncomingContext(ctx, md)
+
+			mtdt := server.extractMetadata(ctx)
+			require.Equal(t, "grpc-test", mtdt.UserAgent)
+			require.Equal(t, tc.clientIP, mtdt.ClientIP)
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	_, err := parseTrustedProxies([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+
+	_, err = parseTrustedProxies([]string{"proxy.local"})
+	require.Error(t, err)
+}
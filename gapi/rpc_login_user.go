@@ -0,0 +1,50 @@
+package gapi
+
+import (
+	"context"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"practice-docker/pb"
+	"practice-docker/service"
+)
+
+func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	violations := validateLoginUserRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	// The session is shared with the HTTP API, so a refresh token from either can be renewed over either.
+	mtdt := server.extractMetadata(ctx)
+	result, err := server.bank.LoginUser(ctx, service.LoginUserParams{
+		Username:  req.GetUsername(),
+		Password:  req.GetPassword(),
+		UserAgent: mtdt.UserAgent,
+		ClientIP:  mtdt.ClientIP,
+	})
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	rsp := &pb.LoginUserResponse{
+		User:                  convertUser(result.User),
+		SessionId:             result.Session.ID.String(),
+		AccessToken:           result.AccessToken,
+		RefreshToken:          result.RefreshToken,
+		AccessTokenExpiresAt:  timestamppb.New(result.AccessPayload.ExpiredAt),
+		RefreshTokenExpiresAt: timestamppb.New(result.RefreshPayload.ExpiredAt),
+	}
+	return rsp, nil
+}
+
+func validateLoginUserRequest(req *pb.LoginUserRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validateUsername(req.GetUsername()); err != nil {
+		violations = append(violations, fieldViolation("username", err))
+	}
+
+	if err := validatePassword(req.GetPassword()); err != nil {
+		violations = append(violations, fieldViolation("password", err))
+	}
+
+	return violations
+}
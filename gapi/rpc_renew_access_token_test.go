@@ -0,0 +1,135 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	mockDB "practice-docker/db/mock"
+	db "practice-docker/db/sqlc"
+	"practice-docker/pb"
+	"practice-docker/token"
+	"practice-docker/util"
+	"testing"
+	"time"
+)
+
+func TestServer_RenewAccessToken(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		tokenType     token.TokenType
+		buildStubs    func(store *mockDB.MockStore, refreshToken string, payload *token.Payload)
+		checkResponse func(t *testing.T, server *Server, rsp *pb.RenewAccessTokenResponse, err error)
+	}{
+		{
+			name:      "OK",
+			tokenType: token.TokenTypeRefresh,
+			buildStubs: func(store *mockDB.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(db.Sessions{
+						ID:           payload.ID,
+						Username:     payload.Username,
+						RefreshToken: refreshToken,
+						ExpiresAt:    payload.ExpiredAt,
+					}, nil)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, rsp *pb.RenewAccessTokenResponse, err error) {
+				require.NoError(t, err)
+
+				payload, err := server.tokenMaker.VerifyToken(rsp.GetAccessToken())
+				require.NoError(t, err)
+				require.Equal(t, user.Username, payload.Username)
+				require.Equal(t, token.TokenTypeAccess, payload.Type)
+				require.WithinDuration(t, payload.ExpiredAt, rsp.GetAccessTokenExpiresAt().AsTime(), time.Second)
+			},
+		},
+		{
+			name:      "RoleChanged",
+			tokenType: token.TokenTypeRefresh,
+			buildStubs: func(store *mockDB.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(db.Sessions{
+						ID:           payload.ID,
+						Username:     payload.Username,
+						RefreshToken: refreshToken,
+						ExpiresAt:    payload.ExpiredAt,
+					}, nil)
+
+				// the user was made an admin after the login
+				admin := user
+				admin.Role = util.AdminRole
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(admin, nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, rsp *pb.RenewAccessTokenResponse, err error) {
+				require.NoError(t, err)
+
+				payload, err := server.tokenMaker.VerifyToken(rsp.GetAccessToken())
+				require.NoError(t, err)
+				require.Equal(t, util.AdminRole, payload.Role)
+			},
+		},
+		{
+			name:      "AccessToken",
+			tokenType: token.TokenTypeAccess,
+			buildStubs: func(store *mockDB.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, rsp *pb.RenewAccessTokenResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name:      "SessionNotFound",
+			tokenType: token.TokenTypeRefresh,
+			buildStubs: func(store *mockDB.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(db.Sessions{}, sql.ErrNoRows)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, rsp *pb.RenewAccessTokenResponse, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockDB.NewMockStore(ctrl)
+
+			server := newTestServer(t, store)
+			refreshToken, payload, err := server.tokenMaker.CreateToken(user.Username, user.Role, tc.tokenType, time.Minute)
+			require.NoError(t, err)
+
+			tc.buildStubs(store, refreshToken, payload)
+
+			rsp, err := server.RenewAccessToken(context.Background(), &pb.RenewAccessTokenRequest{RefreshToken: refreshToken})
+			tc.checkResponse(t, server, rsp, err)
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package gapi
+
+import (
+	"context"
+	"practice-docker/pb"
+)
+
+func (server *Server) ResendVerificationEmail(ctx context.Context, req *pb.ResendVerificationEmailRequest) (*pb.ResendVerificationEmailResponse, error) {
+	authPayload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	// the link in the mail points to the HTTP API, see GET /users/verify_email
+	err = server.bank.ResendVerificationEmail(ctx, authPayload.Username)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return &pb.ResendVerificationEmailResponse{}, nil
+}
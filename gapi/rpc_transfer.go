@@ -0,0 +1,88 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"practice-docker/pb"
+	"practice-docker/service"
+)
+
+func (server *Server) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	authPayload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := server.validateCreateTransferRequest(ctx, req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	// the service converts the amount when the to account is in another currency
+	result, err := server.bank.CreateTransfer(ctx, service.CreateTransferParams{
+		Username:      authPayload.Username,
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID:   req.GetToAccountId(),
+		Amount:        req.GetAmount(),
+		Currency:      req.GetCurrency(),
+	})
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	rsp := &pb.CreateTransferResponse{
+		Transfer:    convertTransfer(result.Transfer),
+		FromAccount: server.convertAccount(ctx, result.FromAccount),
+		ToAccount:   server.convertAccount(ctx, result.ToAccount),
+		FromEntry:   convertEntry(result.FromEntry),
+		ToEntry:     convertEntry(result.ToEntry),
+	}
+	return rsp, nil
+}
+
+func (server *Server) validateCreateTransferRequest(
+	ctx context.Context,
+	req *pb.CreateTransferRequest,
+) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validateID(req.GetFromAccountId()); err != nil {
+		violations = append(violations, fieldViolation("from_account_id", err))
+	}
+
+	if err := validateID(req.GetToAccountId()); err != nil {
+		violations = append(violations, fieldViolation("to_account_id", err))
+	}
+
+	if err := validatePositive(req.GetAmount()); err != nil {
+		violations = append(violations, fieldViolation("amount", err))
+	}
+
+	if !server.currencies.IsSupported(ctx, req.GetCurrency()) {
+		err := fmt.Errorf("unsupported currency %q", req.GetCurrency())
+		violations = append(violations, fieldViolation("currency", err))
+	}
+
+	return violations
+}
+
+func (server *Server) GetTransfer(ctx context.Context, req *pb.GetTransferRequest) (*pb.GetTransferResponse, error) {
+	authPayload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	if err := validateID(req.GetId()); err != nil {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{fieldViolation("id", err)})
+	}
+
+	// The user must own the account on either side of the transfer.
+	result, err := server.bank.GetTransfer(ctx, authPayload.Username, req.GetId())
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	rsp := &pb.GetTransferResponse{
+		Transfer: convertTransfer(result.Transfer),
+	}
+	return rsp, nil
+}
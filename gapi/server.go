@@ -0,0 +1,53 @@
+package gapi
+
+import (
+	"net"
+	"practice-docker/pb"
+	"practice-docker/service"
+	"practice-docker/token"
+	"practice-docker/util"
+)
+
+// Server serves gRPC requests for the bank.
+// The rules live in service.Bank, which the HTTP server calls as well.
+type Server struct {
+	pb.UnimplementedBankServer
+	config     util.Config
+	tokenMaker token.Maker
+	currencies *util.CurrencyRegistry
+	// trustedProxies may tell the client IP in the x-forwarded-for metadata.
+	trustedProxies []*net.IPNet
+	bank           *service.Bank
+}
+
+// Dependencies are what the gRPC server is built on besides the config.
+type Dependencies struct {
+	service.Dependencies
+}
+
+// NewServer creates a new gRPC server.
+func NewServer(config util.Config, deps Dependencies) (*Server, error) {
+	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	if err != nil {
+		return nil, err
+	}
+
+	trustedProxies := config.TrustedProxies
+	if len(trustedProxies) == 0 {
+		trustedProxies = util.DefaultTrustedProxies
+	}
+	proxies, err := parseTrustedProxies(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &Server{
+		config:         config,
+		tokenMaker:     tokenMaker,
+		currencies:     deps.Currencies,
+		trustedProxies: proxies,
+		bank:           service.NewBank(config, tokenMaker, deps.Dependencies),
+	}
+
+	return server, nil
+}
@@ -0,0 +1,63 @@
+package gapi
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+)
+
+var isValidUsername = regexp.MustCompile(`^[a-zA-Z0-9]+$`).MatchString
+
+// The rules below match the binding tags of the HTTP requests.
+
+func validateUsername(value string) error {
+	if value == "" {
+		return fmt.Errorf("must not be empty")
+	}
+	if !isValidUsername(value) {
+		return fmt.Errorf("must contain only letters and digits")
+	}
+	return nil
+}
+
+func validatePassword(value string) error {
+	if len(value) < 6 {
+		return fmt.Errorf("must be at least 6 characters")
+	}
+	return nil
+}
+
+func validateFullName(value string) error {
+	if value == "" {
+		return fmt.Errorf("must not be empty")
+	}
+	return nil
+}
+
+func validateEmail(value string) error {
+	if _, err := mail.ParseAddress(value); err != nil {
+		return fmt.Errorf("is not a valid email address")
+	}
+	return nil
+}
+
+func validateID(value int64) error {
+	if value < 1 {
+		return fmt.Errorf("must be at least 1")
+	}
+	return nil
+}
+
+func validatePositive(value int64) error {
+	if value <= 0 {
+		return fmt.Errorf("must be greater than 0")
+	}
+	return nil
+}
+
+func validatePageSize(value int32) error {
+	if value < 1 || value > 100 {
+		return fmt.Errorf("must be between 1 and 100")
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// FileMailer writes every message to its own file in a directory instead of sending it.
+// It is meant for development, where the mails can be read from the directory.
+type FileMailer struct {
+	dir  string
+	from string
+	now  func() time.Time
+	seq  atomic.Int64
+}
+
+// NewFileMailer creates a mailer that writes messages from the given sender to dir.
+// The directory is created if it does not exist.
+func NewFileMailer(dir string, from string) (*FileMailer, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileMailer{
+		dir:  dir,
+		from: from,
+		now:  time.Now,
+	}, nil
+}
+
+// Send writes the message as an .eml file named after the time it was sent.
+func (mailer *FileMailer) Send(_ context.Context, message Message) error {
+	now := mailer.now()
+	name := fmt.Sprintf("%s-%d.eml", now.UTC().Format("20060102T150405.000000000Z"), mailer.seq.Add(1))
+
+	return os.WriteFile(filepath.Join(mailer.dir, name), format(mailer.from, message, now), 0o600)
+}
@@ -0,0 +1,59 @@
+package mail
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryMailer(t *testing.T) {
+	mailer := NewMemoryMailer()
+	message := Message{To: "user@example.com", Subject: "Hello", Body: "Hi"}
+
+	err := mailer.Send(context.Background(), message)
+	require.NoError(t, err)
+	require.Equal(t, []Message{message}, mailer.Messages())
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer, err := NewFileMailer(dir, "bank@example.com")
+	require.NoError(t, err)
+
+	message := Message{To: "user@example.com", Subject: "Hello", Body: "first line\nsecond line"}
+	err = mailer.Send(context.Background(), message)
+	require.NoError(t, err)
+	err = mailer.Send(context.Background(), message)
+	require.NoError(t, err)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+
+	content := string(data)
+	require.Contains(t, content, "From: bank@example.com\r\n")
+	require.Contains(t, content, "To: user@example.com\r\n")
+	require.Contains(t, content, "Subject: Hello\r\n")
+	require.True(t, strings.HasSuffix(content, "\r\n\r\nfirst line\r\nsecond line"))
+}
+
+func TestFormat(t *testing.T) {
+	date := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	data := format("bank@example.com", Message{To: "user@example.com", Subject: "Hello", Body: "a\r\nb\nc"}, date)
+
+	require.Equal(t, "From: bank@example.com\r\n"+
+		"To: user@example.com\r\n"+
+		"Subject: Hello\r\n"+
+		"Date: Tue, 02 Jan 2024 03:04:05 +0000\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=UTF-8\r\n"+
+		"\r\n"+
+		"a\r\nb\r\nc", string(data))
+}
@@ -0,0 +1,105 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// defaultSMTPTimeout bounds a single message, so a slow server does not hold the request.
+const defaultSMTPTimeout = 10 * time.Second
+
+// SMTPMailer sends messages through an SMTP server. STARTTLS is used when the server offers it.
+type SMTPMailer struct {
+	address string
+	auth    smtp.Auth
+	from    string
+	now     func() time.Time
+}
+
+// NewSMTPMailer creates a mailer that sends messages from the given sender through the server at address ("host:port").
+// Without a username the messages are sent without authentication.
+func NewSMTPMailer(address string, username string, password string, from string) *SMTPMailer {
+	mailer := &SMTPMailer{
+		address: address,
+		from:    from,
+		now:     time.Now,
+	}
+
+	if username != "" {
+		host, _, _ := net.SplitHostPort(address)
+		mailer.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return mailer
+}
+
+// Send delivers the message to the SMTP server and waits for it to be accepted.
+func (mailer *SMTPMailer) Send(ctx context.Context, message Message) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultSMTPTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", mailer.address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		return err
+	}
+
+	host, _, _ := net.SplitHostPort(mailer.address)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: host})
+		if err != nil {
+			return fmt.Errorf("cannot start TLS: %w", err)
+		}
+	}
+
+	if mailer.auth != nil {
+		err = client.Auth(mailer.auth)
+		if err != nil {
+			return fmt.Errorf("cannot authenticate: %w", err)
+		}
+	}
+
+	err = client.Mail(mailer.from)
+	if err != nil {
+		return err
+	}
+
+	err = client.Rcpt(message.To)
+	if err != nil {
+		return err
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(format(mailer.from, message, mailer.now()))
+	if err != nil {
+		return err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,100 @@
+package mail
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// smtpTranscript is what the fake SMTP server received.
+type smtpTranscript struct {
+	from string
+	to   string
+	data string
+}
+
+// startSMTPServer accepts a single SMTP session without STARTTLS or authentication.
+func startSMTPServer(t *testing.T) (string, <-chan smtpTranscript) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan smtpTranscript, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		text := textproto.NewConn(conn)
+		var transcript smtpTranscript
+		_ = text.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+
+			command := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				_ = text.PrintfLine("250 localhost")
+			case strings.HasPrefix(command, "MAIL FROM:"):
+				transcript.from = line[len("MAIL FROM:"):]
+				_ = text.PrintfLine("250 OK")
+			case strings.HasPrefix(command, "RCPT TO:"):
+				transcript.to = line[len("RCPT TO:"):]
+				_ = text.PrintfLine("250 OK")
+			case command == "DATA":
+				_ = text.PrintfLine("354 go ahead")
+				data, err := text.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				transcript.data = string(data)
+				_ = text.PrintfLine("250 OK")
+			case command == "QUIT":
+				_ = text.PrintfLine("221 bye")
+				received <- transcript
+				return
+			default:
+				_ = text.PrintfLine("502 not implemented")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), received
+}
+
+func TestSMTPMailer(t *testing.T) {
+	address, received := startSMTPServer(t)
+
+	mailer := NewSMTPMailer(address, "", "", "bank@example.com")
+	err := mailer.Send(context.Background(), Message{To: "user@example.com", Subject: "Hello", Body: "Hi\nthere"})
+	require.NoError(t, err)
+
+	transcript := <-received
+	require.Equal(t, "<bank@example.com>", transcript.from)
+	require.Equal(t, "<user@example.com>", transcript.to)
+
+	// ReadDotBytes turns the CRLF line endings into LF
+	headers, body, found := strings.Cut(transcript.data, "\n\n")
+	require.True(t, found)
+	require.Contains(t, headers, "Subject: Hello")
+	require.Equal(t, "Hi\nthere\n", body)
+}
+
+func TestSMTPMailer_Unreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+
+	mailer := NewSMTPMailer(address, "", "", "bank@example.com")
+	err = mailer.Send(context.Background(), Message{To: "user@example.com"})
+	require.Error(t, err)
+}
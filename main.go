@@ -1,14 +1,62 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"flag"
 	_ "github.com/lib/pq"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"practice-docker/activity"
 	"practice-docker/api"
 	db "practice-docker/db/sqlc"
+	"practice-docker/event"
+	"practice-docker/fx"
+	"practice-docker/gapi"
+	"practice-docker/mail"
+	"practice-docker/pb"
+	"practice-docker/ratelimit"
+	"practice-docker/reconcile"
+	"practice-docker/service"
+	"practice-docker/throttle"
+	"practice-docker/token"
 	"practice-docker/util"
+	"practice-docker/worker"
+	"time"
 )
 
+// defaultRevocationSweepInterval is used when REVOCATION_SWEEP_INTERVAL is not set.
+const defaultRevocationSweepInterval = 10 * time.Minute
+
+// defaultCurrencyCacheTTL is used when CURRENCY_CACHE_TTL is not set.
+const defaultCurrencyCacheTTL = 5 * time.Minute
+
+// defaultScheduledTransferInterval is used when SCHEDULED_TRANSFER_INTERVAL is not set.
+const defaultScheduledTransferInterval = time.Minute
+
+// defaultOutboxRelayInterval is used when OUTBOX_RELAY_INTERVAL is not set.
+const defaultOutboxRelayInterval = 5 * time.Second
+
+// defaultWebhookDispatchInterval is used when WEBHOOK_DISPATCH_INTERVAL is not set.
+const defaultWebhookDispatchInterval = 5 * time.Second
+
+// defaultLoginFailureSweepInterval is how often failed logins that no longer count are deleted.
+const defaultLoginFailureSweepInterval = 10 * time.Minute
+
+// defaultRateLimitSweepInterval is how often rate limit buckets that are full again are deleted.
+const defaultRateLimitSweepInterval = 10 * time.Minute
+
+// defaultMailDir is the directory in the temporary directory used when neither SMTP_ADDRESS nor MAIL_DIR is set.
+const defaultMailDir = "simple-bank-mail"
+
+// defaultMailSender is used when MAIL_SENDER is not set.
+const defaultMailSender = "no-reply@simple-bank.local"
+
 func main() {
 	config, err := util.LoadConfig(".") // config file is in the same directory as main.go
 	if err != nil {
@@ -22,7 +70,112 @@ func main() {
 	}
 
 	store := db.NewStore(conn)
-	server, err := api.NewServer(config, store)
+
+	// "reconcile [--fix]" checks the ledger and exits instead of starting the server.
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		os.Exit(runReconcile(store, os.Args[2:]))
+	}
+
+	// Revoked tokens are shared through the database and swept once they expire.
+	revocations := token.NewPostgresRevocationStore(store)
+	sweepInterval := config.RevocationSweepInterval
+	if sweepInterval <= 0 {
+		sweepInterval = defaultRevocationSweepInterval
+	}
+	go token.SweepRevocations(context.Background(), revocations, sweepInterval)
+
+	// Outbox events are claimed the same way and published at least once.
+	if config.OutboxWebhookURL != "" {
+		outboxRelayInterval := config.OutboxRelayInterval
+		if outboxRelayInterval <= 0 {
+			outboxRelayInterval = defaultOutboxRelayInterval
+		}
+		publisher := event.NewWebhookPublisher(config.OutboxWebhookURL)
+		go worker.NewOutboxRelay(store, publisher, outboxRelayInterval).Run(context.Background())
+	}
+
+	// Deliveries to the webhooks of the users are queued by transfers and claimed the same way.
+	webhookDispatchInterval := config.WebhookDispatchInterval
+	if webhookDispatchInterval <= 0 {
+		webhookDispatchInterval = defaultWebhookDispatchInterval
+	}
+	go worker.NewWebhookDispatcher(store, webhookDispatchInterval).Run(context.Background())
+
+	rates, err := newExchangeRateProvider(config)
+	if err != nil {
+		log.Fatalln("Failed to load exchange rates: ", err)
+	}
+
+	// Currencies are read from the database and cached, so enabling one does not need a restart.
+	currencyCacheTTL := config.CurrencyCacheTTL
+	if currencyCacheTTL <= 0 {
+		currencyCacheTTL = defaultCurrencyCacheTTL
+	}
+	currencies := util.NewCurrencyRegistry(currencyLoader(store), currencyCacheTTL)
+
+	// Every replica listens for the account activity of all transfers and streams it to its own clients.
+	broker := activity.NewBroker()
+	go func() {
+		err := activity.ListenPostgres(context.Background(), config.DBSource, broker)
+		if err != nil {
+			log.Println("Failed to listen for account activity: ", err)
+		}
+	}()
+
+	mailer, err := newMailer(config)
+	if err != nil {
+		log.Fatalln("Failed to create mailer: ", err)
+	}
+
+	// Failed logins are counted in the database, so a client can't spread its guesses over the replicas.
+	logins := throttle.NewLoginThrottle(throttle.NewPostgresAttemptStore(store), throttle.Policy{
+		Window:                 config.LoginFailureWindow,
+		Delay:                  config.LoginFailureDelay,
+		MaxDelay:               config.LoginMaxFailureDelay,
+		Lockout:                config.LoginLockoutDuration,
+		MaxFailuresPerUsername: config.LoginMaxFailuresPerUsername,
+		MaxFailuresPerIP:       config.LoginMaxFailuresPerIP,
+	})
+	go logins.Sweep(context.Background(), defaultLoginFailureSweepInterval)
+
+	// Rate limit buckets are kept in the database as well, so the limits hold across the replicas.
+	limiter := ratelimit.NewLimiter(ratelimit.NewPostgresStore(store), config.RateLimitPeriod)
+	go limiter.Sweep(context.Background(), defaultRateLimitSweepInterval)
+
+	// The gRPC server shares the store, the revoked tokens and the currencies with the HTTP server.
+	bankDeps := service.Dependencies{
+		Store:       store,
+		Revocations: revocations,
+		Rates:       rates,
+		Currencies:  currencies,
+		Mailer:      mailer,
+		Logins:      logins,
+	}
+
+	// Due scheduled transfers are claimed with SKIP LOCKED, so every instance can run a worker.
+	// Each occurrence is sent through a Bank, with the same checks as a transfer of the API.
+	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	if err != nil {
+		log.Fatalln("Failed to create token maker: ", err)
+	}
+	scheduledTransferInterval := config.ScheduledTransferInterval
+	if scheduledTransferInterval <= 0 {
+		scheduledTransferInterval = defaultScheduledTransferInterval
+	}
+	bank := service.NewBank(config, tokenMaker, bankDeps)
+	go worker.NewScheduledTransferWorker(store, bank, scheduledTransferInterval).Run(context.Background())
+
+	if config.GRPCServerAddress != "" {
+		go runGRPCServer(config, gapi.Dependencies{
+			Dependencies: bankDeps,
+		})
+	}
+
+	server, err := api.NewServer(config, api.Dependencies{
+		Dependencies: bankDeps,
+		Activity:     broker,
+		Limiter:      limiter,
+	})
 
 	if err != nil {
 		log.Fatalln("Failed to create server: ", err)
@@ -34,3 +187,101 @@ func main() {
 		log.Fatalln("Failed to start server: ", err)
 	}
 }
+
+// runGRPCServer serves the gRPC API on GRPC_SERVER_ADDRESS until it fails.
+func runGRPCServer(config util.Config, deps gapi.Dependencies) {
+	server, err := gapi.NewServer(config, deps)
+	if err != nil {
+		log.Fatalln("Failed to create gRPC server: ", err)
+	}
+
+	grpcServer := grpc.NewServer()
+	pb.RegisterBankServer(grpcServer, server)
+	reflection.Register(grpcServer)
+
+	listener, err := net.Listen("tcp", config.GRPCServerAddress)
+	if err != nil {
+		log.Fatalln("Failed to listen for gRPC: ", err)
+	}
+
+	log.Printf("Starting gRPC server at %s", listener.Addr())
+	err = grpcServer.Serve(listener)
+	if err != nil {
+		log.Fatalln("Failed to start gRPC server: ", err)
+	}
+}
+
+// newExchangeRateProvider reads the rates from EXCHANGE_RATE_FILE, or uses an empty table when it is not set.
+func newExchangeRateProvider(config util.Config) (fx.ExchangeRateProvider, error) {
+	if config.ExchangeRateFile == "" {
+		return fx.NewStaticProvider(nil)
+	}
+
+	return fx.NewFileProvider(config.ExchangeRateFile)
+}
+
+// newMailer sends mails through SMTP_ADDRESS, or writes them to MAIL_DIR when it is not set.
+func newMailer(config util.Config) (mail.Mailer, error) {
+	sender := config.MailSender
+	if sender == "" {
+		sender = defaultMailSender
+	}
+
+	if config.SMTPAddress != "" {
+		return mail.NewSMTPMailer(config.SMTPAddress, config.SMTPUsername, config.SMTPPassword, sender), nil
+	}
+
+	mailDir := config.MailDir
+	if mailDir == "" {
+		mailDir = filepath.Join(os.TempDir(), defaultMailDir)
+	}
+	log.Printf("Writing mails to %s", mailDir)
+	return mail.NewFileMailer(mailDir, sender)
+}
+
+// currencyLoader loads the currencies table for the currency registry.
+func currencyLoader(store db.Store) util.CurrencyLoader {
+	return func(ctx context.Context) ([]util.Currency, error) {
+		rows, err := store.ListCurrencies(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		currencies := make([]util.Currency, len(rows))
+		for i, row := range rows {
+			currencies[i] = util.Currency{
+				Code:     row.Code,
+				Exponent: row.Exponent,
+				Enabled:  row.Enabled,
+			}
+		}
+		return currencies, nil
+	}
+}
+
+// runReconcile prints the reconciliation report as JSON and returns the exit code of the command.
+// The exit code is 1 when the report found anything, even if the drift was corrected with --fix.
+func runReconcile(store db.Store, args []string) int {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	fix := flags.Bool("fix", false, "post correcting entries for accounts whose balance drifted from their entries")
+	_ = flags.Parse(args)
+
+	report, err := reconcile.Run(context.Background(), store, *fix)
+	if err != nil {
+		log.Println("Failed to reconcile the ledger: ", err)
+		return 2
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(report)
+	if err != nil {
+		log.Println("Failed to write the reconciliation report: ", err)
+		return 2
+	}
+
+	if !report.Clean() {
+		return 1
+	}
+	return 0
+}
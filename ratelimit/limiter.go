@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+)
+
+// DefaultPeriod is how long an empty bucket takes to fill up again when the Limiter is created without a period.
+const DefaultPeriod = time.Minute
+
+// Result is the state of a bucket after a request.
+type Result struct {
+	// Allowed is false when the bucket was empty.
+	Allowed bool
+	// Limit is the number of requests of a full bucket.
+	Limit int32
+	// Remaining is the number of requests that can be made right away.
+	Remaining int32
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next request is allowed. It is zero while requests are allowed.
+	RetryAfter time.Duration
+}
+
+// Limiter takes a token from the bucket of a key for every request.
+// Every bucket fills up again within the same period, at a rate that depends on its limit.
+type Limiter struct {
+	store  Store
+	period time.Duration
+	now    func() time.Time
+}
+
+// NewLimiter creates a Limiter that keeps the buckets in store.
+func NewLimiter(store Store, period time.Duration) *Limiter {
+	if period <= 0 {
+		period = DefaultPeriod
+	}
+
+	return &Limiter{
+		store:  store,
+		period: period,
+		now:    time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of the key, which holds limit tokens when it is full.
+func (limiter *Limiter) Allow(ctx context.Context, key string, limit int32) (Result, error) {
+	budget := Budget{Burst: limit, Period: limiter.period}
+
+	bucket, allowed, err := limiter.store.Take(ctx, key, budget, limiter.now())
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: int32(math.Floor(bucket.Tokens)),
+		Reset:     budget.duration(float64(limit) - bucket.Tokens),
+	}
+	if !allowed {
+		result.RetryAfter = budget.duration(1 - bucket.Tokens)
+	}
+
+	return result, nil
+}
+
+// Sweep deletes the buckets that were not used for a period every interval until the context is done.
+// Such buckets are full again, so they are no different from a new one.
+func (limiter *Limiter) Sweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := limiter.store.DeleteStale(ctx, limiter.now().Add(-limiter.period))
+			if err != nil {
+				log.Println("Failed to delete stale rate limit buckets: ", err)
+			}
+		}
+	}
+}
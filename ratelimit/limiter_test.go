@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// newTestLimiter creates a limiter whose clock is moved by the returned function.
+func newTestLimiter(period time.Duration) (*Limiter, func(time.Duration)) {
+	limiter := NewLimiter(NewMemoryStore(), period)
+
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+	return limiter, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	// 4 requests per 4 seconds refill a token every second
+	limiter, advance := newTestLimiter(4 * time.Second)
+	ctx := context.Background()
+
+	for remaining := int32(3); remaining >= 0; remaining-- {
+		result, err := limiter.Allow(ctx, "transfers:user:alice", 4)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, int32(4), result.Limit)
+		require.Equal(t, remaining, result.Remaining)
+		require.Equal(t, time.Duration(4-remaining)*time.Second, result.Reset)
+		require.Zero(t, result.RetryAfter)
+	}
+
+	// the bucket is empty
+	result, err := limiter.Allow(ctx, "transfers:user:alice", 4)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Zero(t, result.Remaining)
+	require.Equal(t, 4*time.Second, result.Reset)
+	require.Equal(t, time.Second, result.RetryAfter)
+
+	// other keys have their own bucket
+	result, err = limiter.Allow(ctx, "transfers:user:bob", 4)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	advance(500 * time.Millisecond)
+	result, err = limiter.Allow(ctx, "transfers:user:alice", 4)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+	// a token is refilled every second
+	advance(500 * time.Millisecond)
+	result, err = limiter.Allow(ctx, "transfers:user:alice", 4)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Zero(t, result.Remaining)
+
+	// the bucket holds no more than the limit
+	advance(time.Hour)
+	result, err = limiter.Allow(ctx, "transfers:user:alice", 4)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, int32(3), result.Remaining)
+}
+
+func TestMemoryStore_DeleteStale(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	budget := Budget{Burst: 1, Period: time.Minute}
+	now := time.Now()
+
+	_, allowed, err := store.Take(ctx, "users:ip:10.0.0.1", budget, now.Add(-time.Hour))
+	require.NoError(t, err)
+	require.True(t, allowed)
+	_, allowed, err = store.Take(ctx, "users:ip:10.0.0.2", budget, now)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	deleted, err := store.DeleteStale(ctx, now.Add(-time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+
+	// the deleted bucket starts full again, the other one is still empty
+	_, allowed, err = store.Take(ctx, "users:ip:10.0.0.1", budget, now)
+	require.NoError(t, err)
+	require.True(t, allowed)
+	_, allowed, err = store.Take(ctx, "users:ip:10.0.0.2", budget, now)
+	require.NoError(t, err)
+	require.False(t, allowed)
+}
@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Budget is the size of a token bucket and how fast it refills.
+type Budget struct {
+	// Burst is the number of tokens in a full bucket.
+	Burst int32
+	// Period is how long an empty bucket takes to fill up again.
+	Period time.Duration
+}
+
+// rate returns the tokens added to a bucket per second.
+func (budget Budget) rate() float64 {
+	return float64(budget.Burst) / budget.Period.Seconds()
+}
+
+// duration returns how long a bucket takes to refill the tokens.
+func (budget Budget) duration(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(tokens * float64(budget.Period) / float64(budget.Burst))
+}
+
+// Bucket is the token bucket of a key, e.g. a user on a route group.
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// refill returns the bucket with the tokens added since it was last updated.
+func (bucket Bucket) refill(budget Budget, now time.Time) Bucket {
+	elapsed := now.Sub(bucket.UpdatedAt)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	tokens := bucket.Tokens + budget.rate()*elapsed.Seconds()
+	return Bucket{
+		Tokens:    math.Min(tokens, float64(budget.Burst)),
+		UpdatedAt: now,
+	}
+}
+
+// Store keeps the token buckets of the keys.
+type Store interface {
+	// Take refills the bucket of the key for the time since it was last updated and takes a token when one is left.
+	// It returns the bucket afterwards and whether a token was taken. A new key starts with a full bucket.
+	Take(ctx context.Context, key string, budget Budget, now time.Time) (Bucket, bool, error)
+
+	// DeleteStale removes the buckets that were last updated before the given time.
+	DeleteStale(ctx context.Context, before time.Time) (int64, error)
+}
+
+// MemoryStore is an in-memory implementation of Store.
+// It is meant for tests and single instance deployments.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]Bucket
+}
+
+// NewMemoryStore creates a new MemoryStore.
+func NewMemoryStore() Store {
+	return &MemoryStore{
+		buckets: make(map[string]Bucket),
+	}
+}
+
+func (store *MemoryStore) Take(_ context.Context, key string, budget Budget, now time.Time) (Bucket, bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	bucket, ok := store.buckets[key]
+	if !ok {
+		bucket = Bucket{Tokens: float64(budget.Burst), UpdatedAt: now}
+	}
+
+	bucket = bucket.refill(budget, now)
+	if bucket.Tokens < 1 {
+		return bucket, false, nil
+	}
+
+	bucket.Tokens--
+	store.buckets[key] = bucket
+	return bucket, true, nil
+}
+
+func (store *MemoryStore) DeleteStale(_ context.Context, before time.Time) (int64, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var deleted int64
+	for key, bucket := range store.buckets {
+		if bucket.UpdatedAt.Before(before) {
+			delete(store.buckets, key)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	db "practice-docker/db/sqlc"
+	"time"
+)
+
+// PostgresStore is a Store backed by the rate_limit_buckets table,
+// so the limits hold across every server instance.
+type PostgresStore struct {
+	store db.Store
+}
+
+// NewPostgresStore creates a new PostgresStore.
+func NewPostgresStore(store db.Store) Store {
+	return &PostgresStore{store: store}
+}
+
+func (p *PostgresStore) Take(ctx context.Context, key string, budget Budget, now time.Time) (Bucket, bool, error) {
+	row, err := p.store.TakeRateLimitToken(ctx, db.TakeRateLimitTokenParams{
+		Key:   key,
+		Burst: float64(budget.Burst),
+		Now:   now,
+		Rate:  budget.rate(),
+	})
+	if err == nil {
+		return Bucket{Tokens: row.Tokens, UpdatedAt: row.UpdatedAt}, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return Bucket{}, false, err
+	}
+
+	// The bucket is empty. Read it to tell when the next token is there.
+	row, err = p.store.GetRateLimitBucket(ctx, key)
+	if err != nil {
+		return Bucket{}, false, err
+	}
+
+	bucket := Bucket{Tokens: row.Tokens, UpdatedAt: row.UpdatedAt}
+	return bucket.refill(budget, now), false, nil
+}
+
+func (p *PostgresStore) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	return p.store.DeleteStaleRateLimitBuckets(ctx, before)
+}
@@ -0,0 +1,72 @@
+package reconcile
+
+import (
+	"context"
+	db "practice-docker/db/sqlc"
+	"time"
+)
+
+// Report lists where the ledger does not add up.
+type Report struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	// AccountDrift lists the accounts whose balance is not the sum of their entries.
+	AccountDrift []db.ListAccountBalanceDriftRow `json:"account_drift"`
+	// OrphanEntries lists the entries that belong to no transfer and are not corrections.
+	OrphanEntries []db.Entries `json:"orphan_entries"`
+	// UnmatchedTransfers lists the transfers without exactly two matching entries.
+	UnmatchedTransfers []db.ListUnmatchedTransfersRow `json:"unmatched_transfers"`
+	// Corrections lists the correcting entries posted in fix mode.
+	Corrections []db.Entries `json:"corrections"`
+}
+
+// Clean reports whether nothing is left to look at.
+// Drift that was corrected in fix mode still counts until the next report.
+func (report Report) Clean() bool {
+	return len(report.AccountDrift) == 0 &&
+		len(report.OrphanEntries) == 0 &&
+		len(report.UnmatchedTransfers) == 0
+}
+
+// Run checks that every balance is the sum of its entries and that every transfer has its two entries.
+// With fix set, a correcting entry is posted for every account that drifted, which makes its entries
+// add up to the balance. Orphan entries and unmatched transfers are only reported.
+func Run(ctx context.Context, store db.Store, fix bool) (Report, error) {
+	report := Report{
+		GeneratedAt: time.Now(),
+		Corrections: []db.Entries{},
+	}
+
+	var err error
+	report.AccountDrift, err = store.ListAccountBalanceDrift(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	report.OrphanEntries, err = store.ListOrphanEntries(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	report.UnmatchedTransfers, err = store.ListUnmatchedTransfers(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	if !fix {
+		return report, nil
+	}
+
+	// the drift is computed again under a lock, it may have changed since the report
+	for _, drift := range report.AccountDrift {
+		result, err := store.CorrectAccountDriftTx(ctx, drift.AccountID)
+		if err != nil {
+			return report, err
+		}
+
+		if result.Drift != 0 {
+			report.Corrections = append(report.Corrections, result.Entry)
+		}
+	}
+
+	return report, nil
+}
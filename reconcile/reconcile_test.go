@@ -0,0 +1,112 @@
+package reconcile
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockDB "practice-docker/db/mock"
+	db "practice-docker/db/sqlc"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	drift := []db.ListAccountBalanceDriftRow{
+		{AccountID: 1, Balance: 100, EntriesTotal: 90, Drift: 10},
+		{AccountID: 2, Balance: 50, EntriesTotal: 60, Drift: -10},
+	}
+	orphans := []db.Entries{
+		{ID: 7, AccountID: sql.NullInt64{Int64: 1, Valid: true}, Amount: 5},
+	}
+	unmatched := []db.ListUnmatchedTransfersRow{
+		{ID: 3, SourceAmount: 20, DestinationAmount: 20, MatchingEntries: 1},
+	}
+
+	testCases := []struct {
+		name       string
+		fix        bool
+		buildStubs func(store *mockDB.MockStore)
+		check      func(t *testing.T, report Report, err error)
+	}{
+		{
+			name: "Report",
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().ListAccountBalanceDrift(gomock.Any()).Times(1).Return(drift, nil)
+				store.EXPECT().ListOrphanEntries(gomock.Any()).Times(1).Return(orphans, nil)
+				store.EXPECT().ListUnmatchedTransfers(gomock.Any()).Times(1).Return(unmatched, nil)
+				store.EXPECT().CorrectAccountDriftTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, report Report, err error) {
+				require.NoError(t, err)
+				require.False(t, report.Clean())
+				require.Equal(t, drift, report.AccountDrift)
+				require.Equal(t, orphans, report.OrphanEntries)
+				require.Equal(t, unmatched, report.UnmatchedTransfers)
+				require.Empty(t, report.Corrections)
+			},
+		},
+		{
+			name: "Fix",
+			fix:  true,
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().ListAccountBalanceDrift(gomock.Any()).Times(1).Return(drift, nil)
+				store.EXPECT().ListOrphanEntries(gomock.Any()).Times(1).Return([]db.Entries{}, nil)
+				store.EXPECT().ListUnmatchedTransfers(gomock.Any()).Times(1).Return([]db.ListUnmatchedTransfersRow{}, nil)
+
+				store.EXPECT().
+					CorrectAccountDriftTx(gomock.Any(), gomock.Eq(int64(1))).
+					Times(1).
+					Return(db.CorrectAccountDriftTxResult{Drift: 10, Entry: db.Entries{ID: 8, Amount: 10, IsCorrection: true}}, nil)
+
+				// the second account was fixed in the meantime
+				store.EXPECT().
+					CorrectAccountDriftTx(gomock.Any(), gomock.Eq(int64(2))).
+					Times(1).
+					Return(db.CorrectAccountDriftTxResult{}, nil)
+			},
+			check: func(t *testing.T, report Report, err error) {
+				require.NoError(t, err)
+				require.Len(t, report.Corrections, 1)
+				require.Equal(t, int64(10), report.Corrections[0].Amount)
+				require.True(t, report.Corrections[0].IsCorrection)
+			},
+		},
+		{
+			name: "Clean",
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().ListAccountBalanceDrift(gomock.Any()).Times(1).Return([]db.ListAccountBalanceDriftRow{}, nil)
+				store.EXPECT().ListOrphanEntries(gomock.Any()).Times(1).Return([]db.Entries{}, nil)
+				store.EXPECT().ListUnmatchedTransfers(gomock.Any()).Times(1).Return([]db.ListUnmatchedTransfersRow{}, nil)
+			},
+			check: func(t *testing.T, report Report, err error) {
+				require.NoError(t, err)
+				require.True(t, report.Clean())
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().ListAccountBalanceDrift(gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+				store.EXPECT().ListOrphanEntries(gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, report Report, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockDB.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			report, err := Run(context.Background(), store, tc.fix)
+			tc.check(t, report, err)
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	db "practice-docker/db/sqlc"
+	"time"
+)
+
+// CreateAccount opens an empty account of the owner in the currency.
+func (bank *Bank) CreateAccount(ctx context.Context, owner string, currency string) (db.Accounts, error) {
+	err := bank.checkCanCreateAccount(ctx, owner)
+	if err != nil {
+		return db.Accounts{}, err
+	}
+
+	account, err := bank.store.CreateAccountTx(ctx, db.CreateAccountsParams{
+		Owner:    owner,
+		Currency: currency,
+		Balance:  0,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "foreign_key_violation": // error code 23503
+				return db.Accounts{}, newError(CodeFailedPrecondition, err)
+			case "unique_violation": // error code 23505
+				return db.Accounts{}, newError(CodeAlreadyExists, err)
+			}
+		}
+		return db.Accounts{}, err
+	}
+
+	return account, nil
+}
+
+// GetAccount returns the account if it belongs to the user.
+func (bank *Bank) GetAccount(ctx context.Context, username string, accountID int64) (db.Accounts, error) {
+	account, err := bank.FindAccount(ctx, accountID)
+	if err != nil {
+		return account, err
+	}
+
+	if account.Owner != username {
+		return account, errorf(CodeForbidden, "account doesn't belong to the authenticated user")
+	}
+
+	return account, nil
+}
+
+type ListAccountsParams struct {
+	Owner    string
+	PageID   int32
+	PageSize int32
+}
+
+// ListAccounts returns a page of the accounts of the owner.
+func (bank *Bank) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]db.Accounts, error) {
+	return bank.store.GetAccounts(ctx, db.GetAccountsParams{
+		Owner:  arg.Owner,
+		Limit:  arg.PageSize,
+		Offset: (arg.PageID - 1) * arg.PageSize,
+	})
+}
+
+type ListAccountsAfterParams struct {
+	Owner string
+	// CursorCreatedAt and CursorID are the position of the last account of the previous page.
+	// A zero cursor starts at the first account.
+	CursorCreatedAt time.Time
+	CursorID        int64
+	Limit           int32
+}
+
+// ListAccountsAfter returns the accounts of the owner that come after the cursor.
+func (bank *Bank) ListAccountsAfter(ctx context.Context, arg ListAccountsAfterParams) ([]db.Accounts, error) {
+	return bank.store.GetAccountsAfter(ctx, db.GetAccountsAfterParams{
+		Owner:           arg.Owner,
+		CursorCreatedAt: arg.CursorCreatedAt,
+		CursorID:        arg.CursorID,
+		Limit:           arg.Limit,
+	})
+}
+
+type ListAllAccountsParams struct {
+	PageID   int32
+	PageSize int32
+}
+
+// ListAllAccounts returns a page of the accounts of every owner. It is only for admins.
+func (bank *Bank) ListAllAccounts(ctx context.Context, arg ListAllAccountsParams) ([]db.Accounts, error) {
+	return bank.store.ListAllAccounts(ctx, db.ListAllAccountsParams{
+		Limit:  arg.PageSize,
+		Offset: (arg.PageID - 1) * arg.PageSize,
+	})
+}
+
+// FindAccount returns the account of any user.
+func (bank *Bank) FindAccount(ctx context.Context, accountID int64) (db.Accounts, error) {
+	account, err := bank.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return account, newError(CodeNotFound, err)
+		}
+		return account, err
+	}
+
+	return account, nil
+}
+
+// ValidAccount returns the account if it exists and is in the currency.
+func (bank *Bank) ValidAccount(ctx context.Context, accountID int64, currency string) (db.Accounts, error) {
+	account, err := bank.FindAccount(ctx, accountID)
+	if err != nil {
+		return account, err
+	}
+
+	if account.Currency != currency {
+		return account, errorf(CodeCurrencyMismatch,
+			"account [%d] currency mismatch: %s vs %s", accountID, account.Currency, currency)
+	}
+
+	return account, nil
+}
+
+// ErrAccountNotEmpty is returned when an account with money in it would be closed.
+var ErrAccountNotEmpty = errors.New("account balance must be zero to close it")
+
+// accountStatusTransitions lists every allowed status change.
+var accountStatusTransitions = map[db.AccountStatus][]db.AccountStatus{
+	db.AccountStatusActive: {db.AccountStatusFrozen, db.AccountStatusClosed},
+	db.AccountStatusFrozen: {db.AccountStatusActive, db.AccountStatusClosed},
+	db.AccountStatusClosed: {db.AccountStatusActive},
+}
+
+// ownerStatusTransitions lists the status changes an owner can make.
+// Only admins can unfreeze an account or close a frozen one.
+var ownerStatusTransitions = map[db.AccountStatus][]db.AccountStatus{
+	db.AccountStatusActive: {db.AccountStatusFrozen, db.AccountStatusClosed},
+	db.AccountStatusClosed: {db.AccountStatusActive},
+}
+
+type ChangeAccountStatusParams struct {
+	Username string
+	// IsAdmin allows changing the status of any account, with every transition.
+	IsAdmin   bool
+	AccountID int64
+	Status    db.AccountStatus
+}
+
+// ChangeAccountStatus moves the account to the status if the transition is allowed.
+func (bank *Bank) ChangeAccountStatus(ctx context.Context, arg ChangeAccountStatusParams) (db.Accounts, error) {
+	account, err := bank.FindAccount(ctx, arg.AccountID)
+	if err != nil {
+		return account, err
+	}
+
+	if !arg.IsAdmin && account.Owner != arg.Username {
+		return account, errorf(CodeForbidden, "account doesn't belong to the authenticated user")
+	}
+
+	if !canChangeStatus(accountStatusTransitions, account.Status, arg.Status) {
+		return account, errorf(CodeConflict, "cannot change account status from %s to %s", account.Status, arg.Status)
+	}
+
+	if !arg.IsAdmin && !canChangeStatus(ownerStatusTransitions, account.Status, arg.Status) {
+		return account, errorf(CodeFailedPrecondition,
+			"only an admin can change account status from %s to %s", account.Status, arg.Status)
+	}
+
+	// The database also rejects closing an account with money in it.
+	if arg.Status == db.AccountStatusClosed && account.Balance != 0 {
+		return account, newError(CodeInsufficientFunds, ErrAccountNotEmpty)
+	}
+
+	updated, err := bank.store.UpdateAccountStatus(ctx, db.UpdateAccountStatusParams{
+		ID:            account.ID,
+		Status:        arg.Status,
+		CurrentStatus: account.Status,
+	})
+	if err != nil {
+		// The status or the balance changed since the account was read.
+		if err == sql.ErrNoRows {
+			return account, errorf(CodeConflict, "account status was changed by another request")
+		}
+
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "check_violation" {
+			return account, newError(CodeInsufficientFunds, ErrAccountNotEmpty)
+		}
+		return account, err
+	}
+
+	return updated, nil
+}
+
+// canChangeStatus checks if the transitions allow moving from one status to another.
+func canChangeStatus(transitions map[db.AccountStatus][]db.AccountStatus, from db.AccountStatus, to db.AccountStatus) bool {
+	for _, status := range transitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrOverdrawnBeyondLimit is returned when an overdraft limit would be lowered below the current overdraft.
+var ErrOverdrawnBeyondLimit = errors.New("account is overdrawn beyond the overdraft limit")
+
+// SetOverdraftLimit changes how far below zero the balance of an account may go. It is only for admins.
+// Transfers out of the account are checked against the limit, transfers into it are not.
+func (bank *Bank) SetOverdraftLimit(ctx context.Context, accountID int64, overdraftLimit int64) (db.Accounts, error) {
+	if overdraftLimit < 0 {
+		return db.Accounts{}, errorf(CodeInvalidArgument, "overdraft limit must not be negative")
+	}
+
+	account, err := bank.store.UpdateAccountOverdraftLimit(ctx, db.UpdateAccountOverdraftLimitParams{
+		ID:             accountID,
+		OverdraftLimit: overdraftLimit,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return account, newError(CodeNotFound, err)
+		}
+
+		// the balance is already below the new limit
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "check_violation" {
+			return account, newError(CodeInsufficientFunds, ErrOverdrawnBeyondLimit)
+		}
+		return account, err
+	}
+
+	return account, nil
+}
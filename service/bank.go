@@ -0,0 +1,62 @@
+package service
+
+import (
+	"net"
+	db "practice-docker/db/sqlc"
+	"practice-docker/event"
+	"practice-docker/fx"
+	"practice-docker/mail"
+	"practice-docker/throttle"
+	"practice-docker/token"
+	"practice-docker/util"
+)
+
+// Bank implements the rules for users, accounts and transfers.
+// The HTTP and the gRPC servers parse requests, call the Bank and map its errors to their status codes.
+type Bank struct {
+	config     util.Config
+	store      db.Store
+	tokenMaker token.Maker
+	// revocations holds the tokens revoked by a logout.
+	revocations token.RevocationStore
+	rates       fx.ExchangeRateProvider
+	currencies  *util.CurrencyRegistry
+	mailer      mail.Mailer
+	logins      *throttle.LoginThrottle
+	// resolver looks up the hosts of webhook urls.
+	resolver event.Resolver
+}
+
+// Dependencies are the stores and providers a Bank is built on.
+// The HTTP and the gRPC servers share them.
+type Dependencies struct {
+	Store db.Store
+	// Revocations holds the tokens revoked by a logout, so the servers share them.
+	Revocations token.RevocationStore
+	Rates       fx.ExchangeRateProvider
+	Currencies  *util.CurrencyRegistry
+	Mailer      mail.Mailer
+	Logins      *throttle.LoginThrottle
+	// Resolver looks up the hosts of webhook urls. It defaults to net.DefaultResolver.
+	Resolver event.Resolver
+}
+
+// NewBank creates a new Bank.
+func NewBank(config util.Config, tokenMaker token.Maker, deps Dependencies) *Bank {
+	resolver := deps.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	return &Bank{
+		config:      config,
+		store:       deps.Store,
+		tokenMaker:  tokenMaker,
+		revocations: deps.Revocations,
+		rates:       deps.Rates,
+		currencies:  deps.Currencies,
+		mailer:      deps.Mailer,
+		logins:      deps.Logins,
+		resolver:    resolver,
+	}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	db "practice-docker/db/sqlc"
+	"practice-docker/mail"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultEmailVerificationLinkLifetime is used when EMAIL_VERIFICATION_LINK_LIFETIME is not set.
+const defaultEmailVerificationLinkLifetime = 48 * time.Hour
+
+var (
+	// ErrInvalidEmailVerificationLink is returned for a link that was tampered with, has expired or is for an old email.
+	ErrInvalidEmailVerificationLink = errors.New("email verification link is invalid or has expired")
+	// ErrEmailNotVerified is returned when the policy requires a verified email for the action.
+	ErrEmailNotVerified = errors.New("email is not verified")
+)
+
+// sendVerificationEmail mails the user a signed link to GET /users/verify_email.
+func (bank *Bank) sendVerificationEmail(ctx context.Context, user db.Users) error {
+	lifetime := bank.config.EmailVerificationLinkLifetime
+	if lifetime <= 0 {
+		lifetime = defaultEmailVerificationLinkLifetime
+	}
+	expiresAt := time.Now().Add(lifetime).Unix()
+
+	query := url.Values{}
+	query.Set("username", user.Username)
+	query.Set("email", user.Email)
+	query.Set("expires_at", strconv.FormatInt(expiresAt, 10))
+	query.Set("signature", bank.signEmailVerification(user.Username, user.Email, expiresAt))
+
+	link := fmt.Sprintf("%s/users/verify_email?%s", bank.publicBaseURL(), query.Encode())
+
+	return bank.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nOpen this link to verify your email:\n\n%s\n\nIt expires at %s.\n",
+			user.FullName,
+			link,
+			time.Unix(expiresAt, 0).UTC().Format(time.RFC1123),
+		),
+	})
+}
+
+// ResendVerificationEmail mails the user a new verification link, e.g. when the mail sent at sign up was lost.
+// Links mailed before stay valid until they expire.
+func (bank *Bank) ResendVerificationEmail(ctx context.Context, username string) error {
+	user, err := bank.store.GetUser(ctx, username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return newError(CodeNotFound, err)
+		}
+		return err
+	}
+
+	if user.IsEmailVerified {
+		return errorf(CodeFailedPrecondition, "email is already verified")
+	}
+
+	return bank.sendVerificationEmail(ctx, user)
+}
+
+type VerifyEmailParams struct {
+	Username  string
+	Email     string
+	ExpiresAt int64
+	Signature string
+}
+
+// VerifyEmail checks the signed link of a verification mail and marks the email of the user as verified.
+func (bank *Bank) VerifyEmail(ctx context.Context, arg VerifyEmailParams) (db.Users, error) {
+	if time.Now().Unix() > arg.ExpiresAt {
+		return db.Users{}, newError(CodeInvalidArgument, ErrInvalidEmailVerificationLink)
+	}
+
+	signature := bank.signEmailVerification(arg.Username, arg.Email, arg.ExpiresAt)
+	if !hmac.Equal([]byte(signature), []byte(arg.Signature)) {
+		return db.Users{}, newError(CodeInvalidArgument, ErrInvalidEmailVerificationLink)
+	}
+
+	user, err := bank.store.VerifyUserEmail(ctx, db.VerifyUserEmailParams{
+		Username: arg.Username,
+		Email:    arg.Email,
+	})
+	if err != nil {
+		// the user is gone or has another email by now
+		if err == sql.ErrNoRows {
+			return db.Users{}, newError(CodeInvalidArgument, ErrInvalidEmailVerificationLink)
+		}
+		return db.Users{}, err
+	}
+
+	return user, nil
+}
+
+// CheckCanSendTransfers returns a FailedPrecondition error when the policy requires a verified email
+// to send transfers and the user hasn't verified theirs.
+func (bank *Bank) CheckCanSendTransfers(ctx context.Context, username string) error {
+	if !bank.config.RequireVerifiedEmailForTransfers {
+		return nil
+	}
+	return bank.requireVerifiedEmail(ctx, username)
+}
+
+// checkCanCreateAccount is CheckCanSendTransfers for opening accounts.
+func (bank *Bank) checkCanCreateAccount(ctx context.Context, username string) error {
+	if !bank.config.RequireVerifiedEmailForAccounts {
+		return nil
+	}
+	return bank.requireVerifiedEmail(ctx, username)
+}
+
+func (bank *Bank) requireVerifiedEmail(ctx context.Context, username string) error {
+	user, err := bank.store.GetUser(ctx, username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return newError(CodeNotFound, err)
+		}
+		return err
+	}
+
+	if !user.IsEmailVerified {
+		return newError(CodeFailedPrecondition, ErrEmailNotVerified)
+	}
+	return nil
+}
+
+// signEmailVerification returns the hex encoded HMAC-SHA256 of the link fields under the token key.
+// The purpose is signed as well, so the signature can't be mistaken for one of another feature.
+func (bank *Bank) signEmailVerification(username string, email string, expiresAt int64) string {
+	mac := hmac.New(sha256.New, []byte(bank.config.TokenSymmetricKey))
+	mac.Write([]byte("verify_email\n"))
+	mac.Write([]byte(username + "\n"))
+	mac.Write([]byte(email + "\n"))
+	mac.Write([]byte(strconv.FormatInt(expiresAt, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// publicBaseURL returns PUBLIC_BASE_URL without a trailing slash, or the server address when it is not set.
+func (bank *Bank) publicBaseURL() string {
+	if bank.config.PublicBaseURL != "" {
+		return strings.TrimRight(bank.config.PublicBaseURL, "/")
+	}
+	return "http://" + bank.config.ServerAddress
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockDB "practice-docker/db/mock"
+	db "practice-docker/db/sqlc"
+	"practice-docker/mail"
+	"practice-docker/util"
+	"testing"
+	"time"
+)
+
+func TestBank_VerifyEmail(t *testing.T) {
+	user := db.Users{Username: util.RandomOwner(), Email: util.RandomEmail()}
+	expiresAt := time.Now().Add(time.Hour).Unix()
+
+	testCases := []struct {
+		name       string
+		arg        func(bank *Bank) VerifyEmailParams
+		buildStubs func(store *mockDB.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name: "OK",
+			arg: func(bank *Bank) VerifyEmailParams {
+				return VerifyEmailParams{user.Username, user.Email, expiresAt, bank.signEmailVerification(user.Username, user.Email, expiresAt)}
+			},
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().
+					VerifyUserEmail(gomock.Any(), gomock.Eq(db.VerifyUserEmailParams{Username: user.Username, Email: user.Email})).
+					Times(1).
+					Return(db.Users{Username: user.Username, Email: user.Email, IsEmailVerified: true}, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "OtherEmail",
+			arg: func(bank *Bank) VerifyEmailParams {
+				return VerifyEmailParams{user.Username, util.RandomEmail(), expiresAt, bank.signEmailVerification(user.Username, user.Email, expiresAt)}
+			},
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().VerifyUserEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidEmailVerificationLink)
+				require.Equal(t, CodeInvalidArgument, ErrorCode(err))
+			},
+		},
+		{
+			name: "Expired",
+			arg: func(bank *Bank) VerifyEmailParams {
+				expired := time.Now().Add(-time.Minute).Unix()
+				return VerifyEmailParams{user.Username, user.Email, expired, bank.signEmailVerification(user.Username, user.Email, expired)}
+			},
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().VerifyUserEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidEmailVerificationLink)
+			},
+		},
+		{
+			name: "EmailChanged",
+			arg: func(bank *Bank) VerifyEmailParams {
+				return VerifyEmailParams{user.Username, user.Email, expiresAt, bank.signEmailVerification(user.Username, user.Email, expiresAt)}
+			},
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().VerifyUserEmail(gomock.Any(), gomock.Any()).Times(1).Return(db.Users{}, sql.ErrNoRows)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidEmailVerificationLink)
+				require.Equal(t, CodeInvalidArgument, ErrorCode(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockDB.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			bank := newTestBank(t, store)
+			_, err := bank.VerifyEmail(context.Background(), tc.arg(bank))
+			tc.checkError(t, err)
+		})
+	}
+}
+
+func TestBank_CreateUserSendsVerificationEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := db.Users{Username: util.RandomOwner(), FullName: util.RandomOwner(), Email: util.RandomEmail()}
+	store := mockDB.NewMockStore(ctrl)
+	store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+
+	bank := newTestBank(t, store)
+	bank.config.PublicBaseURL = "https://bank.example.com/"
+
+	_, err := bank.CreateUser(context.Background(), CreateUserParams{
+		Username: user.Username,
+		Password: util.RandomString(6),
+		FullName: user.FullName,
+		Email:    user.Email,
+	})
+	require.NoError(t, err)
+
+	messages := bank.mailer.(*mail.MemoryMailer).Messages()
+	require.Len(t, messages, 1)
+	require.Equal(t, user.Email, messages[0].To)
+	require.Contains(t, messages[0].Body, "https://bank.example.com/users/verify_email?")
+}
+
+func TestBank_EmailVerificationPolicy(t *testing.T) {
+	owner := util.RandomOwner()
+	unverified := db.Users{Username: owner}
+	verified := db.Users{Username: owner, IsEmailVerified: true}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockDB.NewMockStore(ctrl)
+	bank := newTestBank(t, store)
+
+	// without the policy the user is not even looked up
+	require.NoError(t, bank.CheckCanSendTransfers(context.Background(), owner))
+	require.NoError(t, bank.checkCanCreateAccount(context.Background(), owner))
+
+	bank.config.RequireVerifiedEmailForAccounts = true
+	bank.config.RequireVerifiedEmailForTransfers = true
+
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(owner)).Times(2).Return(unverified, nil)
+	store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(0)
+	_, err := bank.CreateAccount(context.Background(), owner, util.USD)
+	require.ErrorIs(t, err, ErrEmailNotVerified)
+	require.Equal(t, CodeFailedPrecondition, ErrorCode(err))
+
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+	_, err = bank.CreateTransfer(context.Background(), CreateTransferParams{Username: owner, Amount: 10, Currency: util.USD})
+	require.ErrorIs(t, err, ErrEmailNotVerified)
+
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(owner)).Times(1).Return(verified, nil)
+	require.NoError(t, bank.CheckCanSendTransfers(context.Background(), owner))
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	db "practice-docker/db/sqlc"
+	"time"
+)
+
+// AccountStatement is a page of the entries of an account with their running balance.
+type AccountStatement struct {
+	Account db.Accounts
+	Entries []db.ListAccountStatementRow
+}
+
+type ListAccountStatementParams struct {
+	Username  string
+	AccountID int64
+	// FromTime and ToTime are optional. FromTime is inclusive and ToTime exclusive.
+	FromTime time.Time
+	ToTime   time.Time
+	PageID   int32
+	PageSize int32
+}
+
+// ListAccountStatement returns a page of the statement of an account of the user.
+func (bank *Bank) ListAccountStatement(ctx context.Context, arg ListAccountStatementParams) (AccountStatement, error) {
+	account, err := bank.statementAccount(ctx, arg.Username, arg.AccountID, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return AccountStatement{}, err
+	}
+
+	entries, err := bank.store.ListAccountStatement(ctx, db.ListAccountStatementParams{
+		AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+		FromTime:  sql.NullTime{Time: arg.FromTime, Valid: !arg.FromTime.IsZero()},
+		ToTime:    sql.NullTime{Time: arg.ToTime, Valid: !arg.ToTime.IsZero()},
+		Limit:     arg.PageSize,
+		Offset:    (arg.PageID - 1) * arg.PageSize,
+	})
+	if err != nil {
+		return AccountStatement{}, err
+	}
+
+	return AccountStatement{Account: account, Entries: entries}, nil
+}
+
+type ListAccountStatementAfterParams struct {
+	Username  string
+	AccountID int64
+	// FromTime and ToTime are optional. FromTime is inclusive and ToTime exclusive.
+	FromTime time.Time
+	ToTime   time.Time
+	// CursorCreatedAt and CursorID are the position of the last entry of the previous page.
+	// A zero cursor starts at the first entry.
+	CursorCreatedAt time.Time
+	CursorID        int64
+	Limit           int32
+}
+
+// ListAccountStatementAfter returns the statement of an account of the user after the cursor.
+func (bank *Bank) ListAccountStatementAfter(ctx context.Context, arg ListAccountStatementAfterParams) (AccountStatement, error) {
+	account, err := bank.statementAccount(ctx, arg.Username, arg.AccountID, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return AccountStatement{}, err
+	}
+
+	rows, err := bank.store.ListAccountStatementAfter(ctx, db.ListAccountStatementAfterParams{
+		AccountID:       sql.NullInt64{Int64: account.ID, Valid: true},
+		FromTime:        sql.NullTime{Time: arg.FromTime, Valid: !arg.FromTime.IsZero()},
+		ToTime:          sql.NullTime{Time: arg.ToTime, Valid: !arg.ToTime.IsZero()},
+		CursorCreatedAt: arg.CursorCreatedAt,
+		CursorID:        arg.CursorID,
+		Limit:           arg.Limit,
+	})
+	if err != nil {
+		return AccountStatement{}, err
+	}
+
+	// both queries return the same columns
+	entries := make([]db.ListAccountStatementRow, len(rows))
+	for i, row := range rows {
+		entries[i] = db.ListAccountStatementRow(row)
+	}
+
+	return AccountStatement{Account: account, Entries: entries}, nil
+}
+
+// statementAccount checks the time range of a statement and returns the account if it belongs to the user.
+func (bank *Bank) statementAccount(ctx context.Context, username string, accountID int64, fromTime, toTime time.Time) (db.Accounts, error) {
+	if !fromTime.IsZero() && !toTime.IsZero() && !fromTime.Before(toTime) {
+		return db.Accounts{}, errorf(CodeInvalidArgument, "from_time must be before to_time")
+	}
+
+	return bank.GetAccount(ctx, username, accountID)
+}
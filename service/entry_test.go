@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockDB "practice-docker/db/mock"
+	db "practice-docker/db/sqlc"
+	"practice-docker/util"
+	"testing"
+	"time"
+)
+
+func TestBank_ListAccountStatement(t *testing.T) {
+	owner := util.RandomOwner()
+	account := randomAccount(owner, util.USD)
+	now := time.Now()
+
+	testCases := []struct {
+		name       string
+		arg        ListAccountStatementParams
+		buildStubs func(store *mockDB.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name: "OK",
+			arg: ListAccountStatementParams{
+				Username:  owner,
+				AccountID: account.ID,
+				FromTime:  now.Add(-time.Hour),
+				PageID:    2,
+				PageSize:  5,
+			},
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					ListAccountStatement(gomock.Any(), gomock.Eq(db.ListAccountStatementParams{
+						AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+						FromTime:  sql.NullTime{Time: now.Add(-time.Hour), Valid: true},
+						Limit:     5,
+						Offset:    5,
+					})).
+					Times(1)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "InvalidTimeRange",
+			arg: ListAccountStatementParams{
+				Username:  owner,
+				AccountID: account.ID,
+				FromTime:  now,
+				ToTime:    now.Add(-time.Hour),
+				PageID:    1,
+				PageSize:  5,
+			},
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.Equal(t, CodeInvalidArgument, ErrorCode(err))
+			},
+		},
+		{
+			name: "NotOwner",
+			arg: ListAccountStatementParams{
+				Username:  util.RandomOwner(),
+				AccountID: account.ID,
+				PageID:    1,
+				PageSize:  5,
+			},
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountStatement(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.Equal(t, CodeForbidden, ErrorCode(err))
+			},
+		},
+		{
+			name: "AccountNotFound",
+			arg: ListAccountStatementParams{
+				Username:  owner,
+				AccountID: account.ID,
+				PageID:    1,
+				PageSize:  5,
+			},
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Accounts{}, sql.ErrNoRows)
+				store.EXPECT().ListAccountStatement(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.Equal(t, CodeNotFound, ErrorCode(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockDB.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			bank := newTestBank(t, store)
+			_, err := bank.ListAccountStatement(context.Background(), tc.arg)
+			tc.checkError(t, err)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Code classifies an error of the Bank independently of the transport.
+// The HTTP and the gRPC servers map each code to a status of their own.
+type Code int
+
+const (
+	// CodeInternal is any failure that is not caused by the request, e.g. a lost database connection.
+	CodeInternal Code = iota
+	// CodeInvalidArgument is a request that can never succeed as it is.
+	CodeInvalidArgument
+	// CodeCurrencyMismatch is an account that is not in the currency of the request.
+	CodeCurrencyMismatch
+	// CodeNotFound is a user, account or transfer that does not exist.
+	CodeNotFound
+	// CodeUnauthenticated is a wrong username or password.
+	CodeUnauthenticated
+	// CodeForbidden is an account or transfer that belongs to another user.
+	CodeForbidden
+	// CodeAlreadyExists is a user or account that would be created twice.
+	CodeAlreadyExists
+	// CodeConflict is an idempotency key that was already used for a different request.
+	CodeConflict
+	// CodeFailedPrecondition is a request that the current state does not allow, e.g. a frozen account.
+	CodeFailedPrecondition
+	// CodeInsufficientFunds is a balance that does not allow the request, e.g. a transfer below the overdraft limit
+	// or closing an account that still holds money.
+	CodeInsufficientFunds
+	// CodeResourceExhausted is a client that has to wait before it tries again, e.g. after too many failed logins.
+	CodeResourceExhausted
+)
+
+// Error is an error of the Bank with the code that classifies it.
+type Error struct {
+	Code Code
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode returns the code of an error of the Bank, or CodeInternal for any other error.
+func ErrorCode(err error) Code {
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Code
+	}
+	return CodeInternal
+}
+
+func newError(code Code, err error) error {
+	return &Error{Code: code, Err: err}
+}
+
+func errorf(code Code, format string, args ...interface{}) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
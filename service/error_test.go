@@ -0,0 +1,22 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestErrorCode(t *testing.T) {
+	err := newError(CodeNotFound, sql.ErrNoRows)
+	require.Equal(t, CodeNotFound, ErrorCode(err))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	// the code survives wrapping
+	wrapped := fmt.Errorf("get account: %w", err)
+	require.Equal(t, CodeNotFound, ErrorCode(wrapped))
+
+	require.Equal(t, CodeInternal, ErrorCode(sql.ErrConnDone))
+	require.Equal(t, CodeInternal, ErrorCode(nil))
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	db "practice-docker/db/sqlc"
+)
+
+// MaxIdempotencyKeyLength is the longest idempotency key the API accepts, in bytes.
+// The idempotency_keys.idempotency_key column is unbounded, the limit keeps its index small.
+const MaxIdempotencyKeyLength = 255
+
+// NewIdempotencyParams returns the params to store the result of a request under the key of the user.
+// It returns nil params when the key is empty.
+func NewIdempotencyParams(username string, key string, req interface{}) (*db.IdempotencyParams, error) {
+	if key == "" {
+		return nil, nil
+	}
+
+	if len(key) > MaxIdempotencyKeyLength {
+		return nil, errorf(CodeInvalidArgument, "idempotency key must be at most %d bytes", MaxIdempotencyKeyLength)
+	}
+
+	requestHash, err := HashRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &db.IdempotencyParams{
+		Username:    username,
+		Key:         key,
+		RequestHash: requestHash,
+	}, nil
+}
+
+// HashRequest returns a fingerprint of the request used to detect reused idempotency keys.
+func HashRequest(req interface{}) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"github.com/stretchr/testify/require"
+	db "practice-docker/db/sqlc"
+	"practice-docker/fx"
+	"practice-docker/mail"
+	"practice-docker/throttle"
+	"practice-docker/token"
+	"practice-docker/util"
+	"testing"
+	"time"
+)
+
+// testExchangeRates has no rate for CAD, so transfers into CAD accounts fail.
+var testExchangeRates = map[string]string{
+	util.USD + "/" + util.EUR: "0.9",
+}
+
+var testCurrencies = []util.Currency{
+	{Code: util.USD, Exponent: 2, Enabled: true},
+	{Code: util.EUR, Exponent: 2, Enabled: true},
+	{Code: util.CAD, Exponent: 2, Enabled: true},
+}
+
+func newTestBank(t *testing.T, store db.Store) *Bank {
+	config := util.Config{
+		AccessTokenLifetime:  time.Minute,
+		RefreshTokenLifetime: time.Hour,
+	}
+
+	tokenMaker, err := token.NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	rates, err := fx.NewStaticProvider(testExchangeRates)
+	require.NoError(t, err)
+
+	return NewBank(config, tokenMaker, Dependencies{
+		Store:      store,
+		Rates:      rates,
+		Currencies: util.NewStaticCurrencyRegistry(testCurrencies...),
+		Mailer:     mail.NewMemoryMailer(),
+		Logins:     throttle.NewLoginThrottle(throttle.NewMemoryAttemptStore(), throttle.Policy{}),
+	})
+}
+
+func randomAccount(owner string, currency string) db.Accounts {
+	return db.Accounts{
+		ID:       util.RandomInt(1, 1000),
+		Owner:    owner,
+		Balance:  util.RandomMoney(),
+		Currency: currency,
+		Status:   db.AccountStatusActive,
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	db "practice-docker/db/sqlc"
+	"practice-docker/mail"
+	"practice-docker/util"
+	"time"
+)
+
+// defaultPasswordResetTokenLifetime is used when PASSWORD_RESET_TOKEN_LIFETIME is not set.
+const defaultPasswordResetTokenLifetime = 15 * time.Minute
+
+// passwordResetTokenSize is the number of random bytes of a reset token.
+const passwordResetTokenSize = 32
+
+// ErrInvalidPasswordResetToken is returned for a reset token that is unknown, used or expired.
+var ErrInvalidPasswordResetToken = errors.New("password reset token is invalid or has expired")
+
+// RequestPasswordReset mails a single-use password reset token to the user with the email.
+// An unknown email is not an error, so the caller can't tell which emails are registered.
+func (bank *Bank) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := bank.store.GetUserByEmail(ctx, email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	resetToken, err := newPasswordResetToken()
+	if err != nil {
+		return err
+	}
+
+	lifetime := bank.config.PasswordResetTokenLifetime
+	if lifetime <= 0 {
+		lifetime = defaultPasswordResetTokenLifetime
+	}
+
+	// Only the hash is stored, so the tokens can't be read from the database.
+	reset, err := bank.store.CreatePasswordReset(ctx, db.CreatePasswordResetParams{
+		Username:  user.Username,
+		TokenHash: HashPasswordResetToken(resetToken),
+		ExpiresAt: time.Now().Add(lifetime),
+	})
+	if err != nil {
+		return err
+	}
+
+	return bank.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nUse this code to reset your password:\n\n%s\n\nIt expires at %s. "+
+				"If you did not ask to reset your password, you can ignore this email.\n",
+			user.FullName,
+			resetToken,
+			reset.ExpiresAt.UTC().Format(time.RFC1123),
+		),
+	})
+}
+
+// ConfirmPasswordReset uses the reset token to replace the password of its user.
+// The token and every other unused token of the user can't be used again.
+// Tokens issued before the reset are rejected from now on, so every session of the user is logged out.
+func (bank *Bank) ConfirmPasswordReset(ctx context.Context, resetToken string, newPassword string) (db.Users, error) {
+	hashedPassword, err := util.HashPassword(newPassword)
+	if err != nil {
+		return db.Users{}, err
+	}
+
+	// Postgres keeps microseconds, see ChangePassword.
+	user, err := bank.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		TokenHash:      HashPasswordResetToken(resetToken),
+		HashedPassword: hashedPassword,
+		Now:            time.Now().Truncate(time.Microsecond),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.Users{}, newError(CodeInvalidArgument, ErrInvalidPasswordResetToken)
+		}
+		return db.Users{}, err
+	}
+
+	return user, nil
+}
+
+// HashPasswordResetToken returns the hex encoded SHA-256 of the token, which is what the database stores.
+// A reset token is random, so it doesn't need a slow password hash.
+func HashPasswordResetToken(resetToken string) string {
+	sum := sha256.Sum256([]byte(resetToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// newPasswordResetToken returns a random URL safe token.
+func newPasswordResetToken() (string, error) {
+	b := make([]byte, passwordResetTokenSize)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
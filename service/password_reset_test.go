@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockDB "practice-docker/db/mock"
+	db "practice-docker/db/sqlc"
+	"practice-docker/mail"
+	"practice-docker/util"
+	"strings"
+	"testing"
+)
+
+func TestBank_PasswordReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockDB.NewMockStore(ctrl)
+	user := db.Users{Username: util.RandomOwner(), FullName: util.RandomOwner(), Email: util.RandomEmail()}
+
+	var stored db.CreatePasswordResetParams
+	store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+	store.EXPECT().CreatePasswordReset(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ interface{}, arg db.CreatePasswordResetParams) (db.PasswordResets, error) {
+			stored = arg
+			return db.PasswordResets{Username: arg.Username, TokenHash: arg.TokenHash, ExpiresAt: arg.ExpiresAt}, nil
+		})
+
+	bank := newTestBank(t, store)
+	mailer := bank.mailer.(*mail.MemoryMailer)
+
+	err := bank.RequestPasswordReset(context.Background(), user.Email)
+	require.NoError(t, err)
+
+	// the mailed token is the one whose hash was stored
+	messages := mailer.Messages()
+	require.Len(t, messages, 1)
+	require.Equal(t, user.Email, messages[0].To)
+
+	var resetToken string
+	for _, line := range strings.Split(messages[0].Body, "\n") {
+		if line != "" && HashPasswordResetToken(line) == stored.TokenHash {
+			resetToken = line
+		}
+	}
+	require.NotEmpty(t, resetToken, "the mail should contain the reset token")
+	require.NotContains(t, messages[0].Body, stored.TokenHash)
+
+	store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ interface{}, arg db.ResetPasswordTxParams) (db.Users, error) {
+			require.Equal(t, stored.TokenHash, arg.TokenHash)
+			return user, nil
+		})
+
+	_, err = bank.ConfirmPasswordReset(context.Background(), resetToken, util.RandomString(8))
+	require.NoError(t, err)
+}
+
+func TestNewPasswordResetToken(t *testing.T) {
+	token1, err := newPasswordResetToken()
+	require.NoError(t, err)
+	token2, err := newPasswordResetToken()
+	require.NoError(t, err)
+
+	require.Len(t, token1, 43)
+	require.NotEqual(t, token1, token2)
+	require.Len(t, HashPasswordResetToken(token1), 64)
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	db "practice-docker/db/sqlc"
+	"time"
+)
+
+type CreateScheduledTransferParams struct {
+	Username      string
+	FromAccountID int64
+	ToAccountID   int64
+	// Amount is in minor units of Currency, which must be the currency of both accounts.
+	Amount    int64
+	Currency  string
+	Frequency db.TransferFrequency
+	// StartAt is the first occurrence. It defaults to now.
+	StartAt time.Time
+	// EndAt is optional. No occurrence runs after it.
+	EndAt time.Time
+}
+
+// CreateScheduledTransfer schedules transfers from an account of the user to an account in the same currency.
+func (bank *Bank) CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (db.ScheduledTransfers, error) {
+	now := time.Now()
+	if arg.StartAt.IsZero() {
+		arg.StartAt = now
+	}
+
+	if arg.StartAt.Before(now.Add(-time.Minute)) {
+		return db.ScheduledTransfers{}, errorf(CodeInvalidArgument, "start_at must not be in the past")
+	}
+
+	if !arg.EndAt.IsZero() && arg.EndAt.Before(arg.StartAt) {
+		return db.ScheduledTransfers{}, errorf(CodeInvalidArgument, "end_at must not be before start_at")
+	}
+
+	// a schedule sends transfers, so it needs what sending a transfer needs
+	err := bank.CheckCanSendTransfers(ctx, arg.Username)
+	if err != nil {
+		return db.ScheduledTransfers{}, err
+	}
+
+	// check if the from account exists and is owned by the user
+	fromAccount, err := bank.ValidAccount(ctx, arg.FromAccountID, arg.Currency)
+	if err != nil {
+		return db.ScheduledTransfers{}, err
+	}
+
+	if fromAccount.Owner != arg.Username {
+		return db.ScheduledTransfers{}, errorf(CodeForbidden, "from account doesn't belong to the authenticated user")
+	}
+
+	// scheduled transfers are not converted, both accounts must be in the same currency
+	_, err = bank.ValidAccount(ctx, arg.ToAccountID, arg.Currency)
+	if err != nil {
+		return db.ScheduledTransfers{}, err
+	}
+
+	scheduled, err := bank.store.CreateScheduledTransfer(ctx, db.CreateScheduledTransferParams{
+		Owner:         arg.Username,
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		Frequency:     arg.Frequency,
+		StartAt:       arg.StartAt,
+		NextRunAt:     arg.StartAt,
+		EndAt:         sql.NullTime{Time: arg.EndAt, Valid: !arg.EndAt.IsZero()},
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "foreign_key_violation": // error code 23503
+				return db.ScheduledTransfers{}, newError(CodeFailedPrecondition, err)
+			}
+		}
+		return db.ScheduledTransfers{}, err
+	}
+
+	return scheduled, nil
+}
+
+type ListScheduledTransfersParams struct {
+	Owner    string
+	PageID   int32
+	PageSize int32
+}
+
+// ListScheduledTransfers returns a page of the scheduled transfers of the owner.
+func (bank *Bank) ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]db.ScheduledTransfers, error) {
+	return bank.store.ListScheduledTransfers(ctx, db.ListScheduledTransfersParams{
+		Owner:  arg.Owner,
+		Limit:  arg.PageSize,
+		Offset: (arg.PageID - 1) * arg.PageSize,
+	})
+}
+
+// GetScheduledTransfer returns the scheduled transfer if it belongs to the user.
+func (bank *Bank) GetScheduledTransfer(ctx context.Context, username string, id int64) (db.ScheduledTransfers, error) {
+	scheduled, err := bank.store.GetScheduledTransfer(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return scheduled, newError(CodeNotFound, err)
+		}
+		return scheduled, err
+	}
+
+	if scheduled.Owner != username {
+		return scheduled, errorf(CodeForbidden, "scheduled transfer doesn't belong to the authenticated user")
+	}
+
+	return scheduled, nil
+}
+
+type UpdateScheduledTransferParams struct {
+	Username string
+	ID       int64
+	// Amount and EndAt are left unchanged when zero.
+	Amount int64
+	EndAt  time.Time
+	// IsActive pauses or resumes the schedule. It is left unchanged when nil.
+	IsActive *bool
+}
+
+// UpdateScheduledTransfer changes the amount or the end of a scheduled transfer of the user, or pauses or resumes it.
+func (bank *Bank) UpdateScheduledTransfer(ctx context.Context, arg UpdateScheduledTransferParams) (db.ScheduledTransfers, error) {
+	scheduled, err := bank.GetScheduledTransfer(ctx, arg.Username, arg.ID)
+	if err != nil {
+		return scheduled, err
+	}
+
+	if !arg.EndAt.IsZero() && arg.EndAt.Before(scheduled.StartAt) {
+		return scheduled, errorf(CodeInvalidArgument, "end_at must not be before start_at")
+	}
+
+	// A one-off transfer that already ran cannot be resumed, it would pay again.
+	resume := arg.IsActive != nil && *arg.IsActive && !scheduled.IsActive
+	if resume && scheduled.Frequency == db.TransferFrequencyOnce && scheduled.RunCount > 0 {
+		return scheduled, errorf(CodeConflict, "scheduled transfer has already run")
+	}
+
+	updateArg := db.UpdateScheduledTransferParams{
+		ID:     scheduled.ID,
+		Amount: sql.NullInt64{Int64: arg.Amount, Valid: arg.Amount > 0},
+		EndAt:  sql.NullTime{Time: arg.EndAt, Valid: !arg.EndAt.IsZero()},
+	}
+	if arg.IsActive != nil {
+		updateArg.IsActive = sql.NullBool{Bool: *arg.IsActive, Valid: true}
+	}
+
+	// The occurrences missed while the schedule was paused are skipped, the worker would pay them all at once.
+	if resume && scheduled.Frequency != db.TransferFrequencyOnce {
+		if !arg.EndAt.IsZero() {
+			scheduled.EndAt = updateArg.EndAt
+		}
+
+		runCount, nextRunAt := occurrenceAfter(scheduled, time.Now())
+		if scheduled.EndAt.Valid && nextRunAt.After(scheduled.EndAt.Time) {
+			return scheduled, errorf(CodeConflict, "scheduled transfer has no occurrence left before end_at")
+		}
+
+		updateArg.RunCount = sql.NullInt32{Int32: runCount, Valid: true}
+		updateArg.NextRunAt = sql.NullTime{Time: nextRunAt, Valid: true}
+	}
+
+	return bank.store.UpdateScheduledTransfer(ctx, updateArg)
+}
+
+// DeleteScheduledTransfer deletes a scheduled transfer of the user.
+func (bank *Bank) DeleteScheduledTransfer(ctx context.Context, username string, id int64) error {
+	scheduled, err := bank.GetScheduledTransfer(ctx, username, id)
+	if err != nil {
+		return err
+	}
+
+	return bank.store.DeleteScheduledTransfer(ctx, scheduled.ID)
+}
+
+// RunScheduledTransfer sends the next occurrence of the scheduled transfer like CreateTransfer would for its owner,
+// and returns how the schedule moves on. The transfer is keyed by the occurrence, so if the schedule is not moved
+// on, running it again replays the stored transfer instead of paying the occurrence again.
+// An occurrence the accounts or the owner can never pay is recorded in LastError and skipped. Any other error is
+// returned, and the occurrence should be retried.
+func (bank *Bank) RunScheduledTransfer(
+	ctx context.Context,
+	scheduled db.ScheduledTransfers,
+	now time.Time,
+) (db.UpdateScheduledTransferRunParams, error) {
+	arg := db.UpdateScheduledTransferRunParams{
+		RunCount:       scheduled.RunCount + 1,
+		LastRunAt:      sql.NullTime{Time: now, Valid: true},
+		LastTransferID: scheduled.LastTransferID,
+	}
+
+	result, err := bank.sendScheduledTransfer(ctx, scheduled)
+	if err != nil {
+		if ErrorCode(err) == CodeInternal {
+			return arg, err
+		}
+		arg.LastError = sql.NullString{String: err.Error(), Valid: true}
+	} else {
+		arg.LastTransferID = sql.NullInt64{Int64: result.Transfer.ID, Valid: true}
+	}
+
+	arg.NextRunAt, arg.IsActive = nextRun(scheduled, arg.RunCount)
+	return arg, nil
+}
+
+// sendScheduledTransfer sends the next occurrence of the scheduled transfer in the currency of its from account.
+func (bank *Bank) sendScheduledTransfer(ctx context.Context, scheduled db.ScheduledTransfers) (db.TransferTxResult, error) {
+	err := bank.CheckCanSendTransfers(ctx, scheduled.Owner)
+	if err != nil {
+		return db.TransferTxResult{}, err
+	}
+
+	fromAccount, err := bank.FindAccount(ctx, scheduled.FromAccountID)
+	if err != nil {
+		return db.TransferTxResult{}, err
+	}
+
+	return bank.sendTransfer(ctx, CreateTransferParams{
+		Username:       scheduled.Owner,
+		FromAccountID:  scheduled.FromAccountID,
+		ToAccountID:    scheduled.ToAccountID,
+		Amount:         scheduled.Amount,
+		Currency:       fromAccount.Currency,
+		IdempotencyKey: fmt.Sprintf("scheduled-transfer:%d:%d", scheduled.ID, scheduled.RunCount+1),
+	}, fromAccount)
+}
+
+// nextRun returns when the schedule runs after runCount occurrences and whether it stays active.
+func nextRun(scheduled db.ScheduledTransfers, runCount int32) (time.Time, bool) {
+	if scheduled.Frequency == db.TransferFrequencyOnce {
+		return scheduled.NextRunAt, false
+	}
+
+	// an ended schedule still points at its next occurrence, in case end_at is moved later
+	next := occurrence(scheduled.StartAt, scheduled.Frequency, int(runCount))
+	active := !scheduled.EndAt.Valid || !next.After(scheduled.EndAt.Time)
+
+	return next, active
+}
+
+// occurrenceAfter returns the run count and the time of the first occurrence of a recurring schedule after now.
+// An occurrence that is not due yet is returned as it is.
+func occurrenceAfter(scheduled db.ScheduledTransfers, now time.Time) (int32, time.Time) {
+	runCount := scheduled.RunCount
+	next := occurrence(scheduled.StartAt, scheduled.Frequency, int(runCount))
+	for !next.After(now) {
+		runCount++
+		next = occurrence(scheduled.StartAt, scheduled.Frequency, int(runCount))
+	}
+
+	return runCount, next
+}
+
+// occurrence returns the time of the n-th occurrence after start, counting start as the 0th.
+// Monthly occurrences keep the day of start, or use the last day of shorter months.
+func occurrence(start time.Time, frequency db.TransferFrequency, n int) time.Time {
+	switch frequency {
+	case db.TransferFrequencyDaily:
+		return start.AddDate(0, 0, n)
+	case db.TransferFrequencyWeekly:
+		return start.AddDate(0, 0, 7*n)
+	case db.TransferFrequencyMonthly:
+		return addMonths(start, n)
+	default:
+		return start
+	}
+}
+
+// addMonths adds n months to t without overflowing into the month after.
+func addMonths(t time.Time, n int) time.Time {
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+
+	// day 0 of the following month is the last day of the target month
+	lastDay := time.Date(year, month+time.Month(n)+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return time.Date(year, month+time.Month(n), day, hour, min, sec, t.Nanosecond(), t.Location())
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockDB "practice-docker/db/mock"
+	db "practice-docker/db/sqlc"
+	"practice-docker/util"
+	"testing"
+	"time"
+)
+
+func randomScheduledTransfer(frequency db.TransferFrequency) db.ScheduledTransfers {
+	start := time.Date(2024, time.January, 31, 9, 0, 0, 0, time.UTC)
+	return db.ScheduledTransfers{
+		ID:            util.RandomInt(1, 1000),
+		Owner:         util.RandomOwner(),
+		FromAccountID: util.RandomInt(1, 1000),
+		ToAccountID:   util.RandomInt(1001, 2000),
+		Amount:        util.RandomMoney(),
+		Frequency:     frequency,
+		StartAt:       start,
+		NextRunAt:     start,
+		IsActive:      true,
+	}
+}
+
+func TestOccurrence(t *testing.T) {
+	start := time.Date(2024, time.January, 31, 9, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		frequency db.TransferFrequency
+		n         int
+		want      time.Time
+	}{
+		{"Daily", db.TransferFrequencyDaily, 1, time.Date(2024, time.February, 1, 9, 0, 0, 0, time.UTC)},
+		{"Weekly", db.TransferFrequencyWeekly, 2, time.Date(2024, time.February, 14, 9, 0, 0, 0, time.UTC)},
+		{"MonthlyLeapYear", db.TransferFrequencyMonthly, 1, time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC)},
+		{"MonthlyKeepsDay", db.TransferFrequencyMonthly, 2, time.Date(2024, time.March, 31, 9, 0, 0, 0, time.UTC)},
+		{"MonthlyShortMonth", db.TransferFrequencyMonthly, 3, time.Date(2024, time.April, 30, 9, 0, 0, 0, time.UTC)},
+		{"MonthlyNextYear", db.TransferFrequencyMonthly, 13, time.Date(2025, time.February, 28, 9, 0, 0, 0, time.UTC)},
+		{"Once", db.TransferFrequencyOnce, 1, start},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, occurrence(start, tc.frequency, tc.n))
+		})
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	once := randomScheduledTransfer(db.TransferFrequencyOnce)
+	_, active := nextRun(once, 1)
+	require.False(t, active)
+
+	monthly := randomScheduledTransfer(db.TransferFrequencyMonthly)
+	next, active := nextRun(monthly, 1)
+	require.True(t, active)
+	require.Equal(t, time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC), next)
+
+	// the schedule ends once the next occurrence is after end_at
+	monthly.EndAt = sql.NullTime{Time: time.Date(2024, time.February, 15, 0, 0, 0, 0, time.UTC), Valid: true}
+	_, active = nextRun(monthly, 1)
+	require.False(t, active)
+}
+
+func TestOccurrenceAfter(t *testing.T) {
+	daily := randomScheduledTransfer(db.TransferFrequencyDaily)
+	daily.RunCount = 2
+
+	// the occurrences up to now are skipped
+	now := daily.StartAt.AddDate(0, 0, 5)
+	runCount, next := occurrenceAfter(daily, now)
+	require.Equal(t, int32(6), runCount)
+	require.Equal(t, daily.StartAt.AddDate(0, 0, 6), next)
+
+	// an occurrence that is not due yet is kept
+	now = daily.StartAt.AddDate(0, 0, 1)
+	runCount, next = occurrenceAfter(daily, now)
+	require.Equal(t, int32(2), runCount)
+	require.Equal(t, daily.StartAt.AddDate(0, 0, 2), next)
+}
+
+func TestBank_UpdateScheduledTransferPauseResume(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockDB.NewMockStore(ctrl)
+	bank := newTestBank(t, store)
+
+	// the schedule has run twice and its third occurrence is due in an hour
+	start := time.Now().UTC().Truncate(time.Second).Add(time.Hour - 2*24*time.Hour)
+	scheduled := randomScheduledTransfer(db.TransferFrequencyDaily)
+	scheduled.StartAt = start
+	scheduled.RunCount = 2
+	scheduled.NextRunAt = start.AddDate(0, 0, 2)
+
+	store.EXPECT().
+		GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).
+		Times(2).
+		DoAndReturn(func(_ context.Context, _ int64) (db.ScheduledTransfers, error) {
+			return scheduled, nil
+		})
+
+	store.EXPECT().
+		UpdateScheduledTransfer(gomock.Any(), gomock.Any()).
+		Times(2).
+		DoAndReturn(func(_ context.Context, arg db.UpdateScheduledTransferParams) (db.ScheduledTransfers, error) {
+			scheduled.IsActive = arg.IsActive.Bool
+			if arg.RunCount.Valid {
+				scheduled.RunCount = arg.RunCount.Int32
+			}
+			if arg.NextRunAt.Valid {
+				scheduled.NextRunAt = arg.NextRunAt.Time
+			}
+			return scheduled, nil
+		})
+
+	paused := false
+	_, err := bank.UpdateScheduledTransfer(context.Background(), UpdateScheduledTransferParams{
+		Username: scheduled.Owner,
+		ID:       scheduled.ID,
+		IsActive: &paused,
+	})
+	require.NoError(t, err)
+	require.False(t, scheduled.IsActive)
+
+	// the schedule stays paused for three days, over three of its occurrences
+	start = start.AddDate(0, 0, -3)
+	scheduled.StartAt = start
+	scheduled.NextRunAt = start.AddDate(0, 0, 2)
+
+	resumed := true
+	updated, err := bank.UpdateScheduledTransfer(context.Background(), UpdateScheduledTransferParams{
+		Username: scheduled.Owner,
+		ID:       scheduled.ID,
+		IsActive: &resumed,
+	})
+	require.NoError(t, err)
+
+	// the missed occurrences are skipped instead of all being due at once
+	require.True(t, updated.IsActive)
+	require.Equal(t, int32(5), updated.RunCount)
+	require.Equal(t, start.AddDate(0, 0, 5), updated.NextRunAt)
+	require.True(t, updated.NextRunAt.After(time.Now()))
+}
+
+func TestBank_UpdateScheduledTransferResumeAfterEnd(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockDB.NewMockStore(ctrl)
+	bank := newTestBank(t, store)
+
+	// the schedule ended while it was paused
+	scheduled := randomScheduledTransfer(db.TransferFrequencyWeekly)
+	scheduled.IsActive = false
+	scheduled.EndAt = sql.NullTime{Time: scheduled.StartAt.AddDate(0, 1, 0), Valid: true}
+
+	store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+	store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+
+	resumed := true
+	_, err := bank.UpdateScheduledTransfer(context.Background(), UpdateScheduledTransferParams{
+		Username: scheduled.Owner,
+		ID:       scheduled.ID,
+		IsActive: &resumed,
+	})
+	require.Error(t, err)
+	require.Equal(t, CodeConflict, ErrorCode(err))
+}
+
+func TestBank_RunScheduledTransfer(t *testing.T) {
+	now := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		config     util.Config
+		buildStubs func(store *mockDB.MockStore, scheduled db.ScheduledTransfers)
+		checkRun   func(t *testing.T, scheduled db.ScheduledTransfers, run db.UpdateScheduledTransferRunParams, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockDB.MockStore, scheduled db.ScheduledTransfers) {
+				fromAccount := randomAccount(scheduled.Owner, util.USD)
+				fromAccount.ID = scheduled.FromAccountID
+				toAccount := randomAccount(util.RandomOwner(), util.USD)
+				toAccount.ID = scheduled.ToAccountID
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+						require.Equal(t, scheduled.Amount, arg.Amount)
+						require.Equal(t, scheduled.Owner, arg.Idempotency.Username)
+						require.Equal(t, fmt.Sprintf("scheduled-transfer:%d:1", scheduled.ID), arg.Idempotency.Key)
+						return db.TransferTxResult{Transfer: db.Transfers{ID: 42}}, nil
+					})
+			},
+			checkRun: func(t *testing.T, scheduled db.ScheduledTransfers, run db.UpdateScheduledTransferRunParams, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int32(1), run.RunCount)
+				require.True(t, run.IsActive)
+				require.Equal(t, time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC), run.NextRunAt)
+				require.Equal(t, sql.NullTime{Time: now, Valid: true}, run.LastRunAt)
+				require.Equal(t, sql.NullInt64{Int64: 42, Valid: true}, run.LastTransferID)
+				require.False(t, run.LastError.Valid)
+			},
+		},
+		{
+			name:   "EmailNotVerified",
+			config: util.Config{RequireVerifiedEmailForTransfers: true},
+			buildStubs: func(store *mockDB.MockStore, scheduled db.ScheduledTransfers) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(scheduled.Owner)).
+					Times(1).
+					Return(db.Users{Username: scheduled.Owner}, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkRun: func(t *testing.T, scheduled db.ScheduledTransfers, run db.UpdateScheduledTransferRunParams, err error) {
+				// the occurrence is skipped and recorded
+				require.NoError(t, err)
+				require.Equal(t, int32(1), run.RunCount)
+				require.True(t, run.IsActive)
+				require.Equal(t, ErrEmailNotVerified.Error(), run.LastError.String)
+				require.Equal(t, scheduled.LastTransferID, run.LastTransferID)
+			},
+		},
+		{
+			name: "FromAccountNotOwned",
+			buildStubs: func(store *mockDB.MockStore, scheduled db.ScheduledTransfers) {
+				fromAccount := randomAccount(util.RandomOwner(), util.USD)
+				fromAccount.ID = scheduled.FromAccountID
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkRun: func(t *testing.T, scheduled db.ScheduledTransfers, run db.UpdateScheduledTransferRunParams, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int32(1), run.RunCount)
+				require.True(t, run.LastError.Valid)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockDB.MockStore, scheduled db.ScheduledTransfers) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(scheduled.FromAccountID)).
+					Times(1).
+					Return(db.Accounts{}, sql.ErrConnDone)
+			},
+			checkRun: func(t *testing.T, scheduled db.ScheduledTransfers, run db.UpdateScheduledTransferRunParams, err error) {
+				// the caller retries the occurrence
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockDB.NewMockStore(ctrl)
+			scheduled := randomScheduledTransfer(db.TransferFrequencyMonthly)
+			tc.buildStubs(store, scheduled)
+
+			bank := NewBank(tc.config, nil, Dependencies{Store: store})
+			run, err := bank.RunScheduledTransfer(context.Background(), scheduled, now)
+			tc.checkRun(t, scheduled, run, err)
+		})
+	}
+}
@@ -0,0 +1,376 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/big"
+	db "practice-docker/db/sqlc"
+	"practice-docker/fx"
+	"time"
+)
+
+type CreateTransferParams struct {
+	Username      string
+	FromAccountID int64
+	ToAccountID   int64
+	// Amount is in minor units of Currency, which must be the currency of the from account.
+	Amount   int64
+	Currency string
+	// IdempotencyKey is optional. A retried request with the same key returns the first result.
+	IdempotencyKey string
+}
+
+// transferRequestHash is the part of a transfer that is fingerprinted for idempotency keys.
+// It has the same JSON encoding as the body of POST /transfers.
+type transferRequestHash struct {
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+}
+
+// CreateTransfer moves money from an account of the user to any account.
+// The amount is converted when the to account is in another currency.
+func (bank *Bank) CreateTransfer(ctx context.Context, arg CreateTransferParams) (db.TransferTxResult, error) {
+	err := bank.CheckCanSendTransfers(ctx, arg.Username)
+	if err != nil {
+		return db.TransferTxResult{}, err
+	}
+
+	// check if the from account exists and is owned by the user
+	fromAccount, err := bank.ValidAccount(ctx, arg.FromAccountID, arg.Currency)
+	if err != nil {
+		return db.TransferTxResult{}, err
+	}
+
+	return bank.sendTransfer(ctx, arg, fromAccount)
+}
+
+// sendTransfer is CreateTransfer once the from account has been looked up.
+func (bank *Bank) sendTransfer(ctx context.Context, arg CreateTransferParams, fromAccount db.Accounts) (db.TransferTxResult, error) {
+	if fromAccount.Owner != arg.Username {
+		return db.TransferTxResult{}, errorf(CodeForbidden, "from account doesn't belong to the authenticated user")
+	}
+
+	// check if the to account exists, it may be in another currency
+	toAccount, err := bank.FindAccount(ctx, arg.ToAccountID)
+	if err != nil {
+		return db.TransferTxResult{}, err
+	}
+
+	txArg := db.TransferTxParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+	}
+
+	// convert the amount into the currency of the to account
+	if toAccount.Currency != fromAccount.Currency {
+		toAmount, rate, err := bank.convertAmount(ctx, arg.Amount, fromAccount.Currency, toAccount.Currency)
+		if err != nil {
+			if errors.Is(err, fx.ErrRateNotFound) {
+				return db.TransferTxResult{}, newError(CodeInvalidArgument, err)
+			}
+			return db.TransferTxResult{}, err
+		}
+
+		if toAmount <= 0 {
+			return db.TransferTxResult{}, errorf(CodeInvalidArgument,
+				"amount is too small to convert from %s to %s", fromAccount.Currency, toAccount.Currency)
+		}
+
+		txArg.ToAmount = toAmount
+		txArg.ExchangeRate = fx.FormatRate(rate)
+	}
+
+	txArg.Idempotency, err = NewIdempotencyParams(arg.Username, arg.IdempotencyKey, transferRequestHash{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		Currency:      arg.Currency,
+	})
+	if err != nil {
+		return db.TransferTxResult{}, err
+	}
+
+	result, err := bank.store.TransferTx(ctx, txArg)
+	if err != nil {
+		return db.TransferTxResult{}, transferTxError(err)
+	}
+
+	return result, nil
+}
+
+// transferTxError classifies the errors of TransferTx.
+func transferTxError(err error) error {
+	switch {
+	// the idempotency key was already used for a different transfer
+	case errors.Is(err, db.ErrIdempotencyKeyMismatch):
+		return newError(CodeConflict, err)
+	// one of the accounts is frozen or closed
+	case errors.Is(err, db.ErrAccountFrozen), errors.Is(err, db.ErrAccountClosed):
+		return newError(CodeFailedPrecondition, err)
+	// the from account cannot cover the amount
+	case errors.Is(err, db.ErrInsufficientFunds):
+		return newError(CodeInsufficientFunds, err)
+	// one of the accounts was deleted in the meantime
+	case errors.Is(err, sql.ErrNoRows):
+		return newError(CodeNotFound, err)
+	}
+	return err
+}
+
+// convertAmount converts an amount in minor units of one currency into minor units of another.
+func (bank *Bank) convertAmount(ctx context.Context, amount int64, from string, to string) (int64, *big.Rat, error) {
+	rate, err := bank.rates.Rate(ctx, from, to)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	fromCurrency, err := bank.currencies.Get(ctx, from)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	toCurrency, err := bank.currencies.Get(ctx, to)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return fx.ConvertMinorUnits(amount, rate, fromCurrency.Exponent, toCurrency.Exponent), rate, nil
+}
+
+type GetTransferResult struct {
+	Transfer db.Transfers
+	// FromAccount and ToAccount are empty when the account no longer exists.
+	FromAccount db.Accounts
+	ToAccount   db.Accounts
+}
+
+// GetTransfer returns the transfer if the user owns the account on either side of it.
+func (bank *Bank) GetTransfer(ctx context.Context, username string, transferID int64) (GetTransferResult, error) {
+	result, err := bank.FindTransfer(ctx, transferID)
+	if err != nil {
+		return result, err
+	}
+
+	if result.FromAccount.Owner != username && result.ToAccount.Owner != username {
+		return GetTransferResult{}, errorf(CodeForbidden, "transfer doesn't belong to the authenticated user")
+	}
+
+	return result, nil
+}
+
+// FindTransfer returns any transfer with its accounts, whoever owns them.
+func (bank *Bank) FindTransfer(ctx context.Context, transferID int64) (GetTransferResult, error) {
+	transfer, err := bank.store.GetTransfer(ctx, transferID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return GetTransferResult{}, newError(CodeNotFound, err)
+		}
+		return GetTransferResult{}, err
+	}
+
+	fromAccount, toAccount, err := bank.transferAccounts(ctx, transfer)
+	if err != nil {
+		return GetTransferResult{}, err
+	}
+
+	return GetTransferResult{
+		Transfer:    transfer,
+		FromAccount: fromAccount,
+		ToAccount:   toAccount,
+	}, nil
+}
+
+// transferAccounts returns both accounts of the transfer.
+// An account that is not set or no longer exists is returned empty.
+func (bank *Bank) transferAccounts(ctx context.Context, transfer db.Transfers) (db.Accounts, db.Accounts, error) {
+	var accounts [2]db.Accounts
+	for i, accountID := range []sql.NullInt64{transfer.FromAccountID, transfer.ToAccountID} {
+		if !accountID.Valid {
+			continue
+		}
+
+		account, err := bank.store.GetAccount(ctx, accountID.Int64)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return db.Accounts{}, db.Accounts{}, err
+		}
+
+		accounts[i] = account
+	}
+
+	return accounts[0], accounts[1], nil
+}
+
+type ReverseTransferParams struct {
+	Username string
+	// IsAdmin allows reversing a transfer the user didn't receive.
+	IsAdmin    bool
+	TransferID int64
+	// Amount is the refund in the currency of the sender. Zero refunds the rest of the transfer.
+	Amount int64
+	// IdempotencyKey is optional. A retried request with the same key returns the first result.
+	IdempotencyKey string
+}
+
+// reverseTransferHash is the part of a reversal that is fingerprinted for idempotency keys.
+type reverseTransferHash struct {
+	TransferID int64 `json:"transfer_id"`
+	Amount     int64 `json:"amount"`
+}
+
+// ReverseTransfer refunds all or part of a transfer from the recipient to the sender.
+// Only the recipient gives money back, unless an admin steps in.
+func (bank *Bank) ReverseTransfer(ctx context.Context, arg ReverseTransferParams) (db.TransferTxResult, error) {
+	transfer, err := bank.store.GetTransfer(ctx, arg.TransferID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.TransferTxResult{}, newError(CodeNotFound, err)
+		}
+		return db.TransferTxResult{}, err
+	}
+
+	_, toAccount, err := bank.transferAccounts(ctx, transfer)
+	if err != nil {
+		return db.TransferTxResult{}, err
+	}
+
+	if toAccount.Owner != arg.Username && !arg.IsAdmin {
+		return db.TransferTxResult{}, errorf(CodeForbidden, "transfer wasn't received by the authenticated user")
+	}
+
+	txArg := db.ReverseTransferTxParams{
+		TransferID: transfer.ID,
+		Amount:     arg.Amount,
+	}
+
+	txArg.Idempotency, err = NewIdempotencyParams(arg.Username, arg.IdempotencyKey, reverseTransferHash{
+		TransferID: transfer.ID,
+		Amount:     arg.Amount,
+	})
+	if err != nil {
+		return db.TransferTxResult{}, err
+	}
+
+	result, err := bank.store.ReverseTransferTx(ctx, txArg)
+	if err != nil {
+		return db.TransferTxResult{}, reverseTransferTxError(err)
+	}
+
+	return result, nil
+}
+
+// reverseTransferTxError classifies the errors of ReverseTransferTx.
+func reverseTransferTxError(err error) error {
+	switch {
+	// the transfer is a reversal itself, or nothing is left to refund
+	case errors.Is(err, db.ErrTransferIsReversal), errors.Is(err, db.ErrTransferAlreadyReversed):
+		return newError(CodeConflict, err)
+	// the refund converts to nothing in the currency of the recipient
+	case errors.Is(err, db.ErrReversalTooSmall):
+		return newError(CodeInvalidArgument, err)
+	// the refund is larger than what is left
+	case errors.Is(err, db.ErrReversalExceedsRemaining):
+		return newError(CodeInsufficientFunds, err)
+	}
+	// the idempotency key, the status of the accounts and the balance of the recipient are checked like for transfers
+	return transferTxError(err)
+}
+
+// TransferFilter narrows the transfers of a user. A zero field does not filter.
+type TransferFilter struct {
+	// Direction is "in" or "out".
+	Direction             string
+	CounterpartyAccountID int64
+	// MinAmount and MaxAmount are in the currency of the user's side, the received amount of an incoming transfer.
+	MinAmount int64
+	MaxAmount int64
+	FromTime  time.Time
+	ToTime    time.Time
+}
+
+func (filter TransferFilter) validate() error {
+	if filter.MinAmount > 0 && filter.MaxAmount > 0 && filter.MinAmount > filter.MaxAmount {
+		return errorf(CodeInvalidArgument, "min_amount must not be greater than max_amount")
+	}
+
+	if !filter.FromTime.IsZero() && !filter.ToTime.IsZero() && !filter.FromTime.Before(filter.ToTime) {
+		return errorf(CodeInvalidArgument, "from_time must be before to_time")
+	}
+
+	return nil
+}
+
+type ListTransfersParams struct {
+	Owner    string
+	Filter   TransferFilter
+	PageID   int32
+	PageSize int32
+}
+
+// ListTransfers returns a page of the transfers of every account the owner has.
+func (bank *Bank) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]db.ListUserTransfersRow, error) {
+	err := arg.Filter.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return bank.store.ListUserTransfers(ctx, db.ListUserTransfersParams{
+		Owner:                 arg.Owner,
+		Direction:             sql.NullString{String: arg.Filter.Direction, Valid: arg.Filter.Direction != ""},
+		CounterpartyAccountID: sql.NullInt64{Int64: arg.Filter.CounterpartyAccountID, Valid: arg.Filter.CounterpartyAccountID > 0},
+		MinAmount:             sql.NullInt64{Int64: arg.Filter.MinAmount, Valid: arg.Filter.MinAmount > 0},
+		MaxAmount:             sql.NullInt64{Int64: arg.Filter.MaxAmount, Valid: arg.Filter.MaxAmount > 0},
+		FromTime:              sql.NullTime{Time: arg.Filter.FromTime, Valid: !arg.Filter.FromTime.IsZero()},
+		ToTime:                sql.NullTime{Time: arg.Filter.ToTime, Valid: !arg.Filter.ToTime.IsZero()},
+		Limit:                 arg.PageSize,
+		Offset:                (arg.PageID - 1) * arg.PageSize,
+	})
+}
+
+type ListTransfersAfterParams struct {
+	Owner  string
+	Filter TransferFilter
+	// CursorCreatedAt and CursorID are the position of the last transfer of the previous page.
+	// A zero cursor starts at the first transfer.
+	CursorCreatedAt time.Time
+	CursorID        int64
+	Limit           int32
+}
+
+// ListTransfersAfter returns the transfers of every account the owner has that come after the cursor.
+func (bank *Bank) ListTransfersAfter(ctx context.Context, arg ListTransfersAfterParams) ([]db.ListUserTransfersRow, error) {
+	err := arg.Filter.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	transfers, err := bank.store.ListUserTransfersAfter(ctx, db.ListUserTransfersAfterParams{
+		Owner:                 arg.Owner,
+		Direction:             sql.NullString{String: arg.Filter.Direction, Valid: arg.Filter.Direction != ""},
+		CounterpartyAccountID: sql.NullInt64{Int64: arg.Filter.CounterpartyAccountID, Valid: arg.Filter.CounterpartyAccountID > 0},
+		MinAmount:             sql.NullInt64{Int64: arg.Filter.MinAmount, Valid: arg.Filter.MinAmount > 0},
+		MaxAmount:             sql.NullInt64{Int64: arg.Filter.MaxAmount, Valid: arg.Filter.MaxAmount > 0},
+		FromTime:              sql.NullTime{Time: arg.Filter.FromTime, Valid: !arg.Filter.FromTime.IsZero()},
+		ToTime:                sql.NullTime{Time: arg.Filter.ToTime, Valid: !arg.Filter.ToTime.IsZero()},
+		CursorCreatedAt:       arg.CursorCreatedAt,
+		CursorID:              arg.CursorID,
+		Limit:                 arg.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// both queries return the same columns
+	rows := make([]db.ListUserTransfersRow, len(transfers))
+	for i, transfer := range transfers {
+		rows[i] = db.ListUserTransfersRow(transfer)
+	}
+
+	return rows, nil
+}
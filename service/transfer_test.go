@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockDB "practice-docker/db/mock"
+	db "practice-docker/db/sqlc"
+	"practice-docker/util"
+	"testing"
+)
+
+func TestBank_CreateTransfer(t *testing.T) {
+	owner := util.RandomOwner()
+	account1 := randomAccount(owner, util.USD)
+	account2 := randomAccount(util.RandomOwner(), util.USD)
+	account3 := randomAccount(util.RandomOwner(), util.EUR)
+	account4 := randomAccount(util.RandomOwner(), util.CAD)
+
+	amount := int64(1000)
+
+	testCases := []struct {
+		name       string
+		arg        CreateTransferParams
+		buildStubs func(store *mockDB.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name: "OK",
+			arg: CreateTransferParams{
+				Username:      owner,
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+				Currency:      util.USD,
+			},
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Eq(db.TransferTxParams{
+						FromAccountID: account1.ID,
+						ToAccountID:   account2.ID,
+						Amount:        amount,
+					})).
+					Times(1)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "CrossCurrency",
+			arg: CreateTransferParams{
+				Username:      owner,
+				FromAccountID: account1.ID,
+				ToAccountID:   account3.ID,
+				Amount:        amount,
+				Currency:      util.USD,
+			},
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Eq(db.TransferTxParams{
+						FromAccountID: account1.ID,
+						ToAccountID:   account3.ID,
+						Amount:        amount,
+						ToAmount:      900,
+						ExchangeRate:  "0.90000000",
+					})).
+					Times(1)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "FromAccountNotFound",
+			arg: CreateTransferParams{
+				Username:      owner,
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+				Currency:      util.USD,
+			},
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(db.Accounts{}, sql.ErrNoRows)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.Equal(t, CodeNotFound, ErrorCode(err))
+			},
+		},
+		{
+			name: "Forbidden",
+			arg: CreateTransferParams{
+				Username:      util.RandomOwner(),
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+				Currency:      util.USD,
+			},
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.Equal(t, CodeForbidden, ErrorCode(err))
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			arg: CreateTransferParams{
+				Username:      owner,
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+				Currency:      util.EUR,
+			},
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.Equal(t, CodeCurrencyMismatch, ErrorCode(err))
+			},
+		},
+		{
+			name: "RateNotFound",
+			arg: CreateTransferParams{
+				Username:      owner,
+				FromAccountID: account1.ID,
+				ToAccountID:   account4.ID,
+				Amount:        amount,
+				Currency:      util.USD,
+			},
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account4.ID)).Times(1).Return(account4, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.Equal(t, CodeInvalidArgument, ErrorCode(err))
+			},
+		},
+		{
+			name: "IdempotencyKeyTooLong",
+			arg: CreateTransferParams{
+				Username:       owner,
+				FromAccountID:  account1.ID,
+				ToAccountID:    account2.ID,
+				Amount:         amount,
+				Currency:       util.USD,
+				IdempotencyKey: util.RandomString(MaxIdempotencyKeyLength + 1),
+			},
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.Equal(t, CodeInvalidArgument, ErrorCode(err))
+			},
+		},
+		{
+			name: "AccountFrozen",
+			arg: CreateTransferParams{
+				Username:      owner,
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+				Currency:      util.USD,
+			},
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrAccountFrozen)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.Equal(t, CodeFailedPrecondition, ErrorCode(err))
+				require.ErrorIs(t, err, db.ErrAccountFrozen)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			arg: CreateTransferParams{
+				Username:      owner,
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+				Currency:      util.USD,
+			},
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.Equal(t, CodeInsufficientFunds, ErrorCode(err))
+			},
+		},
+		{
+			name: "InternalError",
+			arg: CreateTransferParams{
+				Username:      owner,
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+				Currency:      util.USD,
+			},
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(db.Accounts{}, sql.ErrConnDone)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.Equal(t, CodeInternal, ErrorCode(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockDB.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			bank := newTestBank(t, store)
+			_, err := bank.CreateTransfer(context.Background(), tc.arg)
+			tc.checkError(t, err)
+		})
+	}
+}
+
+func TestBank_ReverseTransfer(t *testing.T) {
+	owner := util.RandomOwner()
+	account1 := randomAccount(util.RandomOwner(), util.USD)
+	account2 := randomAccount(owner, util.USD)
+
+	transfer := db.Transfers{
+		ID:            util.RandomInt(1, 1000),
+		FromAccountID: sql.NullInt64{Int64: account1.ID, Valid: true},
+		ToAccountID:   sql.NullInt64{Int64: account2.ID, Valid: true},
+		Amount:        1000,
+	}
+
+	testCases := []struct {
+		name       string
+		arg        ReverseTransferParams
+		txErr      error
+		buildStubs func(store *mockDB.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name: "OK",
+			arg:  ReverseTransferParams{Username: owner, TransferID: transfer.ID},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "Admin",
+			arg:  ReverseTransferParams{Username: util.RandomOwner(), IsAdmin: true, TransferID: transfer.ID},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "NotRecipient",
+			arg:  ReverseTransferParams{Username: account1.Owner, TransferID: transfer.ID},
+			buildStubs: func(store *mockDB.MockStore) {
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.Equal(t, CodeForbidden, ErrorCode(err))
+			},
+		},
+		{
+			name:  "AlreadyReversed",
+			arg:   ReverseTransferParams{Username: owner, TransferID: transfer.ID},
+			txErr: db.ErrTransferAlreadyReversed,
+			checkError: func(t *testing.T, err error) {
+				require.Equal(t, CodeConflict, ErrorCode(err))
+			},
+		},
+		{
+			name:  "TooSmall",
+			arg:   ReverseTransferParams{Username: owner, TransferID: transfer.ID, Amount: 1},
+			txErr: db.ErrReversalTooSmall,
+			checkError: func(t *testing.T, err error) {
+				require.Equal(t, CodeInvalidArgument, ErrorCode(err))
+			},
+		},
+		{
+			name:  "ExceedsRemaining",
+			arg:   ReverseTransferParams{Username: owner, TransferID: transfer.ID, Amount: 5000},
+			txErr: db.ErrReversalExceedsRemaining,
+			checkError: func(t *testing.T, err error) {
+				require.Equal(t, CodeInsufficientFunds, ErrorCode(err))
+			},
+		},
+		{
+			name:  "AccountFrozen",
+			arg:   ReverseTransferParams{Username: owner, TransferID: transfer.ID},
+			txErr: db.ErrAccountFrozen,
+			checkError: func(t *testing.T, err error) {
+				require.Equal(t, CodeFailedPrecondition, ErrorCode(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockDB.NewMockStore(ctrl)
+			store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+			// without stubs of its own a case reverses the transfer and gets txErr
+			if tc.buildStubs != nil {
+				tc.buildStubs(store)
+			} else {
+				store.EXPECT().
+					ReverseTransferTx(gomock.Any(), gomock.Eq(db.ReverseTransferTxParams{
+						TransferID: transfer.ID,
+						Amount:     tc.arg.Amount,
+					})).
+					Times(1).
+					Return(db.TransferTxResult{}, tc.txErr)
+			}
+
+			bank := newTestBank(t, store)
+			_, err := bank.ReverseTransfer(context.Background(), tc.arg)
+			tc.checkError(t, err)
+		})
+	}
+}
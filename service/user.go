@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"log"
+	db "practice-docker/db/sqlc"
+	"practice-docker/throttle"
+	"practice-docker/token"
+	"practice-docker/util"
+	"time"
+)
+
+type CreateUserParams struct {
+	Username string
+	Password string
+	FullName string
+	Email    string
+}
+
+// CreateUser hashes the password and creates the user.
+func (bank *Bank) CreateUser(ctx context.Context, arg CreateUserParams) (db.Users, error) {
+	hashedPassword, err := util.HashPassword(arg.Password)
+	if err != nil {
+		return db.Users{}, err
+	}
+
+	user, err := bank.store.CreateUser(ctx, db.CreateUserParams{
+		Username:       arg.Username,
+		HashedPassword: hashedPassword,
+		FullName:       arg.FullName,
+		Email:          arg.Email,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation": // error code 23505
+				return db.Users{}, newError(CodeAlreadyExists, err)
+			}
+		}
+		return db.Users{}, err
+	}
+
+	// The user is created either way, so a mail that can't be sent is only logged. It can be sent again with ResendVerificationEmail.
+	err = bank.sendVerificationEmail(ctx, user)
+	if err != nil {
+		log.Println("Failed to send the verification email: ", err)
+	}
+
+	return user, nil
+}
+
+type LoginUserParams struct {
+	Username string
+	Password string
+	// UserAgent and ClientIP are stored with the session.
+	UserAgent string
+	ClientIP  string
+}
+
+type LoginUserResult struct {
+	User           db.Users
+	Session        db.Sessions
+	AccessToken    string
+	AccessPayload  *token.Payload
+	RefreshToken   string
+	RefreshPayload *token.Payload
+}
+
+// LoginUser checks the password of the user and starts a session with a new access and refresh token.
+// Failed attempts are throttled by username and client IP, see throttle.LoginThrottle.
+func (bank *Bank) LoginUser(ctx context.Context, arg LoginUserParams) (LoginUserResult, error) {
+	var result LoginUserResult
+
+	// The attempt is counted before the password is checked, so parallel guesses are throttled as well.
+	err := bank.logins.Attempt(ctx, arg.Username, arg.ClientIP)
+	if err != nil {
+		var throttled *throttle.ThrottledError
+		if errors.As(err, &throttled) {
+			return result, newError(CodeResourceExhausted, err)
+		}
+		return result, err
+	}
+
+	user, err := bank.store.GetUser(ctx, arg.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return result, newError(CodeNotFound, err)
+		}
+		return result, err
+	}
+
+	err = util.CheckPassword(arg.Password, user.HashedPassword)
+	if err != nil {
+		return result, newError(CodeUnauthenticated, err)
+	}
+
+	err = bank.logins.Success(ctx, user.Username, arg.ClientIP)
+	if err != nil {
+		return result, err
+	}
+
+	return bank.startSession(ctx, user, arg.UserAgent, arg.ClientIP)
+}
+
+// startSession creates a new access and refresh token for the user and stores the session.
+func (bank *Bank) startSession(ctx context.Context, user db.Users, userAgent, clientIP string) (LoginUserResult, error) {
+	var result LoginUserResult
+
+	accessToken, accessPayload, err := bank.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		token.TokenTypeAccess,
+		bank.config.AccessTokenLifetime,
+	)
+	if err != nil {
+		return result, err
+	}
+
+	refreshToken, refreshPayload, err := bank.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		token.TokenTypeRefresh,
+		bank.config.RefreshTokenLifetime,
+	)
+	if err != nil {
+		return result, err
+	}
+
+	// Store the session so the refresh token can be checked and blocked later.
+	session, err := bank.store.CreateSession(ctx, db.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		UserAgent:    userAgent,
+		ClientIp:     clientIP,
+		IsBlocked:    false,
+		ExpiresAt:    refreshPayload.ExpiredAt,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result = LoginUserResult{
+		User:           user,
+		Session:        session,
+		AccessToken:    accessToken,
+		AccessPayload:  accessPayload,
+		RefreshToken:   refreshToken,
+		RefreshPayload: refreshPayload,
+	}
+	return result, nil
+}
+
+// Authenticate verifies an access token and checks that it is still good for a request.
+// The token is rejected if it was revoked by a logout or issued before the password of its user was changed.
+func (bank *Bank) Authenticate(ctx context.Context, accessToken string) (*token.Payload, error) {
+	payload, err := bank.tokenMaker.VerifyToken(accessToken)
+	if err != nil {
+		return nil, newError(CodeUnauthenticated, err)
+	}
+
+	// A refresh token lives much longer, it is only good for renewing the access token.
+	err = payload.CheckType(token.TokenTypeAccess)
+	if err != nil {
+		return nil, newError(CodeUnauthenticated, err)
+	}
+
+	revoked, err := bank.revocations.IsRevoked(ctx, payload.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, errorf(CodeUnauthenticated, "token has been revoked")
+	}
+
+	passwordChangedAt, err := bank.store.GetUserPasswordChangedAt(ctx, payload.Username)
+	if err != nil {
+		// a deleted user has no valid tokens
+		if err == sql.ErrNoRows {
+			return nil, newError(CodeUnauthenticated, err)
+		}
+		return nil, err
+	}
+
+	if payload.IssuedAt.Before(passwordChangedAt) {
+		return nil, errorf(CodeUnauthenticated, "token was issued before the password was changed")
+	}
+
+	return payload, nil
+}
+
+type RenewAccessTokenResult struct {
+	AccessToken   string
+	AccessPayload *token.Payload
+}
+
+// RenewAccessToken verifies a refresh token, checks its session and issues a new access token.
+// The token carries the current role of the user, not the one the session was started with.
+func (bank *Bank) RenewAccessToken(ctx context.Context, refreshToken string) (RenewAccessTokenResult, error) {
+	var result RenewAccessTokenResult
+
+	refreshPayload, err := bank.tokenMaker.VerifyToken(refreshToken)
+	if err != nil {
+		return result, newError(CodeUnauthenticated, err)
+	}
+
+	// An access token can't be used to renew itself.
+	err = refreshPayload.CheckType(token.TokenTypeRefresh)
+	if err != nil {
+		return result, newError(CodeUnauthenticated, err)
+	}
+
+	// The session is stored under the ID of the refresh token.
+	session, err := bank.store.GetSession(ctx, refreshPayload.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return result, newError(CodeNotFound, err)
+		}
+		return result, err
+	}
+
+	// Check if the session is still usable.
+	if session.IsBlocked {
+		return result, errorf(CodeUnauthenticated, "blocked session")
+	}
+
+	if session.Username != refreshPayload.Username {
+		return result, errorf(CodeUnauthenticated, "incorrect session user")
+	}
+
+	if session.RefreshToken != refreshToken {
+		return result, errorf(CodeUnauthenticated, "mismatched session token")
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		return result, errorf(CodeUnauthenticated, "expired session")
+	}
+
+	// The role may have changed since the login.
+	user, err := bank.store.GetUser(ctx, refreshPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return result, newError(CodeUnauthenticated, err)
+		}
+		return result, err
+	}
+
+	// Sessions started before a password change can't be renewed.
+	if refreshPayload.IssuedAt.Before(user.PasswordChangedAt) {
+		return result, errorf(CodeUnauthenticated, "session was started before the password was changed")
+	}
+
+	accessToken, accessPayload, err := bank.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		token.TokenTypeAccess,
+		bank.config.AccessTokenLifetime,
+	)
+	if err != nil {
+		return result, err
+	}
+
+	result = RenewAccessTokenResult{
+		AccessToken:   accessToken,
+		AccessPayload: accessPayload,
+	}
+	return result, nil
+}
+
+// Logout revokes the access token of the request.
+// If a refresh token of the user is given, its session is blocked and the token revoked as well,
+// so no access token can be renewed with it.
+func (bank *Bank) Logout(ctx context.Context, accessPayload *token.Payload, refreshToken string) error {
+	if refreshToken != "" {
+		refreshPayload, err := bank.tokenMaker.VerifyToken(refreshToken)
+		if err != nil {
+			return newError(CodeUnauthenticated, err)
+		}
+
+		err = refreshPayload.CheckType(token.TokenTypeRefresh)
+		if err != nil {
+			return newError(CodeUnauthenticated, err)
+		}
+
+		if refreshPayload.Username != accessPayload.Username {
+			return errorf(CodeUnauthenticated, "refresh token doesn't belong to the authenticated user")
+		}
+
+		// a session that is gone cannot be renewed either
+		_, err = bank.store.BlockSession(ctx, refreshPayload.ID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		err = bank.revocations.Revoke(ctx, refreshPayload)
+		if err != nil {
+			return err
+		}
+	}
+
+	return bank.revocations.Revoke(ctx, accessPayload)
+}
+
+type ChangePasswordParams struct {
+	Username        string
+	CurrentPassword string
+	NewPassword     string
+	// UserAgent and ClientIP are stored with the new session.
+	UserAgent string
+	ClientIP  string
+}
+
+// ChangePassword checks the current password of the user, replaces it with the new one and starts a new session.
+// Tokens issued before the change are rejected from now on, so every other session is logged out.
+func (bank *Bank) ChangePassword(ctx context.Context, arg ChangePasswordParams) (LoginUserResult, error) {
+	user, err := bank.store.GetUser(ctx, arg.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return LoginUserResult{}, newError(CodeNotFound, err)
+		}
+		return LoginUserResult{}, err
+	}
+
+	err = util.CheckPassword(arg.CurrentPassword, user.HashedPassword)
+	if err != nil {
+		return LoginUserResult{}, newError(CodeUnauthenticated, err)
+	}
+
+	hashedPassword, err := util.HashPassword(arg.NewPassword)
+	if err != nil {
+		return LoginUserResult{}, err
+	}
+
+	// Postgres keeps microseconds, truncate so the stored time is exactly the one the new tokens are compared to.
+	user, err = bank.store.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		Username:          user.Username,
+		HashedPassword:    hashedPassword,
+		PasswordChangedAt: time.Now().Truncate(time.Microsecond),
+	})
+	if err != nil {
+		return LoginUserResult{}, err
+	}
+
+	return bank.startSession(ctx, user, arg.UserAgent, arg.ClientIP)
+}
+
+type ListUsersParams struct {
+	PageID   int32
+	PageSize int32
+}
+
+// ListUsers returns a page of every user. It is only for admins.
+func (bank *Bank) ListUsers(ctx context.Context, arg ListUsersParams) ([]db.Users, error) {
+	return bank.store.ListUsers(ctx, db.ListUsersParams{
+		Limit:  arg.PageSize,
+		Offset: (arg.PageID - 1) * arg.PageSize,
+	})
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"github.com/lib/pq"
+	"net/url"
+	db "practice-docker/db/sqlc"
+	"practice-docker/event"
+)
+
+// webhookSecretBytes is the length of the random part of a webhook secret.
+const webhookSecretBytes = 32
+
+// CreateWebhook registers a url of the owner that events are posted to.
+// The returned webhook is the only place its secret is handed out.
+func (bank *Bank) CreateWebhook(ctx context.Context, owner string, rawURL string) (db.Webhooks, error) {
+	endpoint, err := url.Parse(rawURL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return db.Webhooks{}, errorf(CodeInvalidArgument, "url must be an http or https url")
+	}
+
+	// The deliveries and their responses can be read back, so the url must not reach into our network.
+	err = event.CheckWebhookURL(ctx, bank.resolver, rawURL)
+	if err != nil {
+		return db.Webhooks{}, newError(CodeInvalidArgument, err)
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return db.Webhooks{}, err
+	}
+
+	webhook, err := bank.store.CreateWebhook(ctx, db.CreateWebhookParams{
+		Owner:  owner,
+		Url:    rawURL,
+		Secret: secret,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "foreign_key_violation": // error code 23503
+				return db.Webhooks{}, newError(CodeFailedPrecondition, err)
+			}
+		}
+		return db.Webhooks{}, err
+	}
+
+	return webhook, nil
+}
+
+type ListWebhooksParams struct {
+	Owner    string
+	PageID   int32
+	PageSize int32
+}
+
+// ListWebhooks returns a page of the webhooks of the owner.
+func (bank *Bank) ListWebhooks(ctx context.Context, arg ListWebhooksParams) ([]db.Webhooks, error) {
+	return bank.store.ListWebhooks(ctx, db.ListWebhooksParams{
+		Owner:  arg.Owner,
+		Limit:  arg.PageSize,
+		Offset: (arg.PageID - 1) * arg.PageSize,
+	})
+}
+
+// GetWebhook returns the webhook if it belongs to the user.
+func (bank *Bank) GetWebhook(ctx context.Context, username string, id int64) (db.Webhooks, error) {
+	webhook, err := bank.store.GetWebhook(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return webhook, newError(CodeNotFound, err)
+		}
+		return webhook, err
+	}
+
+	if webhook.Owner != username {
+		return webhook, errorf(CodeForbidden, "webhook doesn't belong to the authenticated user")
+	}
+
+	return webhook, nil
+}
+
+// DeleteWebhook deletes a webhook of the user with its pending deliveries.
+func (bank *Bank) DeleteWebhook(ctx context.Context, username string, id int64) error {
+	webhook, err := bank.GetWebhook(ctx, username, id)
+	if err != nil {
+		return err
+	}
+
+	return bank.store.DeleteWebhook(ctx, webhook.ID)
+}
+
+type ListWebhookDeliveriesParams struct {
+	Username  string
+	WebhookID int64
+	PageID    int32
+	PageSize  int32
+}
+
+// ListWebhookDeliveries returns a page of the deliveries of a webhook of the user, the newest first.
+func (bank *Bank) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]db.WebhookDeliveries, error) {
+	webhook, err := bank.GetWebhook(ctx, arg.Username, arg.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	return bank.store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{
+		WebhookID: webhook.ID,
+		Limit:     arg.PageSize,
+		Offset:    (arg.PageID - 1) * arg.PageSize,
+	})
+}
+
+// newWebhookSecret returns a random secret to sign the payloads of a webhook with.
+func newWebhookSecret() (string, error) {
+	b := make([]byte, webhookSecretBytes)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return "whsec_" + hex.EncodeToString(b), nil
+}
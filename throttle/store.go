@@ -0,0 +1,118 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Failures is the count of recent failed attempts of a key, e.g. a username or a client IP.
+type Failures struct {
+	Count         int32
+	LastFailureAt time.Time
+}
+
+// AttemptStore keeps track of failed login attempts.
+type AttemptStore interface {
+	// Failures returns the failures of the key. A key without failures returns the zero Failures.
+	Failures(ctx context.Context, key string) (Failures, error)
+
+	// RecordAttempt counts an attempt of the key at now as a failure and returns the failures before it,
+	// which the attempt is judged by. Concurrent attempts are counted one after the other, so each one
+	// sees the attempts before it. The count starts again at one when the last failure happened before windowStart.
+	RecordAttempt(ctx context.Context, key string, now time.Time, windowStart time.Time) (Failures, error)
+
+	// TakeBack uncounts the latest attempt of the key, because it was throttled or succeeded.
+	// The last failure of the key is the one before the attempt again.
+	TakeBack(ctx context.Context, key string) error
+
+	// Reset forgets the failures of the key.
+	Reset(ctx context.Context, key string) error
+
+	// DeleteStale removes the failures of keys whose last failure happened before the given time.
+	DeleteStale(ctx context.Context, before time.Time) (int64, error)
+}
+
+// MemoryAttemptStore is an in-memory implementation of AttemptStore.
+// It is meant for tests and single instance deployments.
+type MemoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]attempts
+}
+
+// attempts are the failures of a key and the failure before the latest one, which TakeBack restores.
+type attempts struct {
+	Failures
+	previousFailureAt time.Time
+}
+
+// NewMemoryAttemptStore creates a new MemoryAttemptStore.
+func NewMemoryAttemptStore() AttemptStore {
+	return &MemoryAttemptStore{
+		attempts: make(map[string]attempts),
+	}
+}
+
+func (store *MemoryAttemptStore) Failures(_ context.Context, key string) (Failures, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.attempts[key].Failures, nil
+}
+
+func (store *MemoryAttemptStore) RecordAttempt(_ context.Context, key string, now time.Time, windowStart time.Time) (Failures, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	previous := store.attempts[key].Failures
+	count := previous.Count
+	if previous.LastFailureAt.Before(windowStart) {
+		count = 0
+	}
+
+	store.attempts[key] = attempts{
+		Failures:          Failures{Count: count + 1, LastFailureAt: now},
+		previousFailureAt: previous.LastFailureAt,
+	}
+
+	if count == 0 {
+		return Failures{}, nil
+	}
+	return previous, nil
+}
+
+func (store *MemoryAttemptStore) TakeBack(_ context.Context, key string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	attempts, ok := store.attempts[key]
+	if ok && attempts.Count > 0 {
+		attempts.Count--
+		attempts.LastFailureAt = attempts.previousFailureAt
+		store.attempts[key] = attempts
+	}
+	return nil
+}
+
+func (store *MemoryAttemptStore) Reset(_ context.Context, key string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.attempts, key)
+	return nil
+}
+
+func (store *MemoryAttemptStore) DeleteStale(_ context.Context, before time.Time) (int64, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var deleted int64
+	for key, attempts := range store.attempts {
+		if attempts.LastFailureAt.Before(before) {
+			delete(store.attempts, key)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,60 @@
+package throttle
+
+import (
+	"context"
+	"database/sql"
+	db "practice-docker/db/sqlc"
+	"time"
+)
+
+// PostgresAttemptStore is an AttemptStore backed by the login_failures table,
+// so failures are counted across every server instance.
+type PostgresAttemptStore struct {
+	store db.Store
+}
+
+// NewPostgresAttemptStore creates a new PostgresAttemptStore.
+func NewPostgresAttemptStore(store db.Store) AttemptStore {
+	return &PostgresAttemptStore{store: store}
+}
+
+func (p *PostgresAttemptStore) Failures(ctx context.Context, key string) (Failures, error) {
+	row, err := p.store.GetLoginFailures(ctx, key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Failures{}, nil
+		}
+		return Failures{}, err
+	}
+
+	return Failures{Count: row.Failures, LastFailureAt: row.LastFailureAt}, nil
+}
+
+func (p *PostgresAttemptStore) RecordAttempt(ctx context.Context, key string, now time.Time, windowStart time.Time) (Failures, error) {
+	row, err := p.store.RecordLoginAttempt(ctx, db.RecordLoginAttemptParams{
+		Key:         key,
+		Now:         now,
+		WindowStart: windowStart,
+	})
+	if err != nil {
+		return Failures{}, err
+	}
+
+	// the attempt is the first one of the window, no failure came before it
+	if row.Failures == 1 {
+		return Failures{}, nil
+	}
+	return Failures{Count: row.Failures - 1, LastFailureAt: row.PreviousFailureAt.Time}, nil
+}
+
+func (p *PostgresAttemptStore) TakeBack(ctx context.Context, key string) error {
+	return p.store.TakeBackLoginAttempt(ctx, key)
+}
+
+func (p *PostgresAttemptStore) Reset(ctx context.Context, key string) error {
+	return p.store.DeleteLoginFailures(ctx, key)
+}
+
+func (p *PostgresAttemptStore) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	return p.store.DeleteStaleLoginFailures(ctx, before)
+}
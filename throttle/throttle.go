@@ -0,0 +1,194 @@
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+// Policy sets how failed login attempts slow down and lock out further attempts.
+type Policy struct {
+	// Window is how long a failure is remembered. The count starts again after a window without failures.
+	// It is never shorter than Lockout, so a lockout lasts as long as it says, and a failure right after
+	// a lockout locks out again.
+	Window time.Duration
+	// Delay is the wait after the first failure. It doubles with every further failure up to MaxDelay.
+	Delay    time.Duration
+	MaxDelay time.Duration
+	// Lockout is the wait once a username or client IP reached its maximum number of failures.
+	Lockout time.Duration
+	// MaxFailuresPerUsername and MaxFailuresPerIP are the failures that lock out a username or a client IP.
+	// A client IP may be shared by many users, so its limit is usually higher.
+	MaxFailuresPerUsername int32
+	MaxFailuresPerIP       int32
+}
+
+// DefaultPolicy is used for the fields of a Policy that are not set.
+var DefaultPolicy = Policy{
+	Window:                 15 * time.Minute,
+	Delay:                  time.Second,
+	MaxDelay:               30 * time.Second,
+	Lockout:                15 * time.Minute,
+	MaxFailuresPerUsername: 5,
+	MaxFailuresPerIP:       20,
+}
+
+// ThrottledError is returned while a username or client IP has to wait before it may try again.
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// LoginThrottle slows down password guessing by username and by client IP.
+// Every failure makes the next attempt wait longer, until the maximum number of failures locks it out.
+type LoginThrottle struct {
+	store  AttemptStore
+	policy Policy
+	now    func() time.Time
+}
+
+// NewLoginThrottle creates a LoginThrottle that keeps the failures in store.
+func NewLoginThrottle(store AttemptStore, policy Policy) *LoginThrottle {
+	if policy.Window <= 0 {
+		policy.Window = DefaultPolicy.Window
+	}
+	if policy.Delay <= 0 {
+		policy.Delay = DefaultPolicy.Delay
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = DefaultPolicy.MaxDelay
+	}
+	if policy.Lockout <= 0 {
+		policy.Lockout = DefaultPolicy.Lockout
+	}
+	if policy.MaxFailuresPerUsername <= 0 {
+		policy.MaxFailuresPerUsername = DefaultPolicy.MaxFailuresPerUsername
+	}
+	if policy.MaxFailuresPerIP <= 0 {
+		policy.MaxFailuresPerIP = DefaultPolicy.MaxFailuresPerIP
+	}
+	if policy.Window < policy.Lockout {
+		policy.Window = policy.Lockout
+	}
+
+	return &LoginThrottle{
+		store:  store,
+		policy: policy,
+		now:    time.Now,
+	}
+}
+
+// Attempt counts a login attempt of the username from the client IP before its password is checked,
+// and returns a ThrottledError when the username or the client IP has to wait before the attempt.
+// Counting first means concurrent attempts can't all pass before a failure is counted: each one is judged
+// by the attempts counted before it. A throttled attempt is taken back, so it doesn't make the wait longer.
+// An attempt that is let through counts as a failure unless Success is called for it.
+func (throttle *LoginThrottle) Attempt(ctx context.Context, username string, clientIP string) error {
+	now := throttle.now()
+	keys := throttle.keys(username, clientIP)
+
+	var retryAfter time.Duration
+	for _, key := range keys {
+		failures, err := throttle.store.RecordAttempt(ctx, key.name, now, now.Add(-throttle.policy.Window))
+		if err != nil {
+			return err
+		}
+
+		wait := throttle.wait(failures, key.maxFailures, now)
+		if wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	if retryAfter > 0 {
+		for _, key := range keys {
+			err := throttle.store.TakeBack(ctx, key.name)
+			if err != nil {
+				return err
+			}
+		}
+		return &ThrottledError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// Success forgets the failures of the username and takes back the attempt of the client IP.
+// The other failures of the client IP are kept, so logging in to an own account does not reset
+// the guesses made at other accounts.
+func (throttle *LoginThrottle) Success(ctx context.Context, username string, clientIP string) error {
+	err := throttle.store.Reset(ctx, usernameKey(username))
+	if err != nil {
+		return err
+	}
+
+	if clientIP != "" {
+		return throttle.store.TakeBack(ctx, ipKey(clientIP))
+	}
+	return nil
+}
+
+// wait returns how long the key has to wait at now after its failures.
+func (throttle *LoginThrottle) wait(failures Failures, maxFailures int32, now time.Time) time.Duration {
+	if failures.Count == 0 || failures.LastFailureAt.Before(now.Add(-throttle.policy.Window)) {
+		return 0
+	}
+
+	var delay time.Duration
+	if failures.Count >= maxFailures {
+		delay = throttle.policy.Lockout
+	} else {
+		// the delay doubles with every failure, the exponent is capped so it can't overflow
+		exponent := math.Min(float64(failures.Count-1), 30)
+		delay = time.Duration(math.Min(
+			float64(throttle.policy.Delay)*math.Pow(2, exponent),
+			float64(throttle.policy.MaxDelay),
+		))
+	}
+
+	return failures.LastFailureAt.Add(delay).Sub(now)
+}
+
+type throttleKey struct {
+	name        string
+	maxFailures int32
+}
+
+func (throttle *LoginThrottle) keys(username string, clientIP string) []throttleKey {
+	keys := []throttleKey{{usernameKey(username), throttle.policy.MaxFailuresPerUsername}}
+	if clientIP != "" {
+		keys = append(keys, throttleKey{ipKey(clientIP), throttle.policy.MaxFailuresPerIP})
+	}
+	return keys
+}
+
+func usernameKey(username string) string {
+	return "username:" + username
+}
+
+func ipKey(clientIP string) string {
+	return "ip:" + clientIP
+}
+
+// Sweep deletes the failures that are older than the window every interval until the context is done.
+// Such failures no longer slow anyone down.
+func (throttle *LoginThrottle) Sweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := throttle.store.DeleteStale(ctx, throttle.now().Add(-throttle.policy.Window))
+			if err != nil {
+				log.Println("Failed to delete stale login failures: ", err)
+			}
+		}
+	}
+}
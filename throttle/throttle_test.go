@@ -0,0 +1,174 @@
+package throttle
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"practice-docker/util"
+	"testing"
+	"time"
+)
+
+// newTestThrottle creates a throttle whose clock is moved by the returned function.
+func newTestThrottle(policy Policy) (*LoginThrottle, func(time.Duration)) {
+	throttle := NewLoginThrottle(NewMemoryAttemptStore(), policy)
+
+	now := time.Now()
+	throttle.now = func() time.Time { return now }
+	return throttle, func(d time.Duration) { now = now.Add(d) }
+}
+
+func requireRetryAfter(t *testing.T, err error, retryAfter time.Duration) {
+	var throttled *ThrottledError
+	require.ErrorAs(t, err, &throttled)
+	require.Equal(t, retryAfter, throttled.RetryAfter)
+}
+
+func TestLoginThrottle_ProgressiveDelay(t *testing.T) {
+	throttle, advance := newTestThrottle(Policy{
+		Delay:                  time.Second,
+		MaxDelay:               4 * time.Second,
+		MaxFailuresPerUsername: 10,
+	})
+	ctx := context.Background()
+	username := util.RandomOwner()
+
+	// the first attempt is let through and fails
+	require.NoError(t, throttle.Attempt(ctx, username, "10.0.0.1"))
+
+	// the delay doubles with every failure, up to the maximum
+	for _, delay := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		requireRetryAfter(t, throttle.Attempt(ctx, username, "10.0.0.1"), delay)
+
+		// a throttled attempt doesn't make the wait longer
+		advance(delay / 2)
+		requireRetryAfter(t, throttle.Attempt(ctx, username, "10.0.0.1"), delay/2)
+
+		advance(delay / 2)
+		require.NoError(t, throttle.Attempt(ctx, username, "10.0.0.1"))
+	}
+
+	// the delay applies to the username from any client IP
+	requireRetryAfter(t, throttle.Attempt(ctx, username, "10.0.0.2"), 4*time.Second)
+
+	// a successful login forgets the failures of the username
+	advance(4 * time.Second)
+	require.NoError(t, throttle.Attempt(ctx, username, "10.0.0.2"))
+	require.NoError(t, throttle.Success(ctx, username, "10.0.0.2"))
+	require.NoError(t, throttle.Attempt(ctx, username, "10.0.0.2"))
+}
+
+func TestLoginThrottle_Lockout(t *testing.T) {
+	throttle, advance := newTestThrottle(Policy{
+		Window:                 time.Minute,
+		Delay:                  time.Millisecond,
+		MaxDelay:               time.Millisecond,
+		Lockout:                10 * time.Minute,
+		MaxFailuresPerUsername: 3,
+	})
+	ctx := context.Background()
+	username := util.RandomOwner()
+
+	for i := 0; i < 3; i++ {
+		advance(time.Second)
+		require.NoError(t, throttle.Attempt(ctx, username, ""))
+	}
+
+	// the window is extended to the lockout, so the lockout lasts
+	requireRetryAfter(t, throttle.Attempt(ctx, username, ""), 10*time.Minute)
+	advance(9 * time.Minute)
+	requireRetryAfter(t, throttle.Attempt(ctx, username, ""), time.Minute)
+	advance(time.Minute)
+
+	// a failure right after the lockout locks out again
+	require.NoError(t, throttle.Attempt(ctx, username, ""))
+	requireRetryAfter(t, throttle.Attempt(ctx, username, ""), 10*time.Minute)
+
+	// after a window without failures they count from one again
+	advance(10*time.Minute + time.Second)
+	require.NoError(t, throttle.Attempt(ctx, username, ""))
+	requireRetryAfter(t, throttle.Attempt(ctx, username, ""), time.Millisecond)
+}
+
+func TestLoginThrottle_ClientIP(t *testing.T) {
+	throttle, advance := newTestThrottle(Policy{
+		Delay:                  time.Millisecond,
+		MaxDelay:               time.Millisecond,
+		Lockout:                time.Minute,
+		MaxFailuresPerUsername: 100,
+		MaxFailuresPerIP:       3,
+	})
+	ctx := context.Background()
+
+	// guesses at different usernames from one client IP add up
+	for i := 0; i < 2; i++ {
+		advance(time.Second)
+		require.NoError(t, throttle.Attempt(ctx, util.RandomOwner(), "10.0.0.1"))
+	}
+
+	// logging in to an own account doesn't reset the guesses made at other accounts
+	username := util.RandomOwner()
+	advance(time.Second)
+	require.NoError(t, throttle.Attempt(ctx, username, "10.0.0.1"))
+	require.NoError(t, throttle.Success(ctx, username, "10.0.0.1"))
+
+	advance(time.Second)
+	require.NoError(t, throttle.Attempt(ctx, util.RandomOwner(), "10.0.0.1"))
+
+	requireRetryAfter(t, throttle.Attempt(ctx, util.RandomOwner(), "10.0.0.1"), time.Minute)
+	require.NoError(t, throttle.Attempt(ctx, util.RandomOwner(), "10.0.0.2"))
+}
+
+func TestLoginThrottle_ConcurrentAttempts(t *testing.T) {
+	throttle, _ := newTestThrottle(Policy{})
+	ctx := context.Background()
+	username := util.RandomOwner()
+
+	// parallel guesses are counted one after the other, so only the first one is let through
+	n := 10
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			errs <- throttle.Attempt(ctx, username, "10.0.0.1")
+		}()
+	}
+
+	passed := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			passed++
+			continue
+		}
+		var throttled *ThrottledError
+		require.ErrorAs(t, err, &throttled)
+	}
+	require.Equal(t, 1, passed)
+
+	// the throttled attempts are taken back
+	failures, err := throttle.store.Failures(ctx, usernameKey(username))
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failures.Count)
+}
+
+func TestMemoryAttemptStore_DeleteStale(t *testing.T) {
+	store := NewMemoryAttemptStore()
+	ctx := context.Background()
+	now := time.Now()
+
+	_, err := store.RecordAttempt(ctx, "ip:10.0.0.1", now.Add(-time.Hour), now.Add(-2*time.Hour))
+	require.NoError(t, err)
+	_, err = store.RecordAttempt(ctx, "ip:10.0.0.2", now, now.Add(-time.Hour))
+	require.NoError(t, err)
+
+	deleted, err := store.DeleteStale(ctx, now.Add(-time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+
+	failures, err := store.Failures(ctx, "ip:10.0.0.1")
+	require.NoError(t, err)
+	require.Zero(t, failures.Count)
+
+	failures, err = store.Failures(ctx, "ip:10.0.0.2")
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failures.Count)
+}
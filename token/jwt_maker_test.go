@@ -14,14 +14,16 @@ func TestJWTMaker_CreateToken(t *testing.T) {
 	require.NoErrorf(t, err, "cannot create jwt maker")
 
 	username := util.RandomOwner()
+	role := util.AdminRole
 	duration := time.Minute
 
 	issueAt := time.Now()
 	expireAt := issueAt.Add(duration)
 
-	token, err := maker.CreateToken(username, duration)
+	token, createdPayload, err := maker.CreateToken(username, role, TokenTypeRefresh, duration)
 	require.NoErrorf(t, err, "cannot create token")
 	require.NotEmptyf(t, token, "token should not be empty")
+	require.NotEmptyf(t, createdPayload, "payload should not be empty")
 
 	payload, err := maker.VerifyToken(token)
 	require.NoErrorf(t, err, "cannot verify token")
@@ -29,8 +31,13 @@ func TestJWTMaker_CreateToken(t *testing.T) {
 
 	require.NotZerof(t, payload.ID, "id should not be zero")
 	require.Equalf(t, username, payload.Username, "username should be the same")
+	require.Equalf(t, role, payload.Role, "role should be the same")
+	require.Equalf(t, TokenTypeRefresh, payload.Type, "type should be the same")
+	require.NoError(t, payload.CheckType(TokenTypeRefresh))
+	require.ErrorIs(t, payload.CheckType(TokenTypeAccess), ErrWrongTokenType)
 	require.WithinDurationf(t, issueAt, payload.IssuedAt, time.Second, "issuedAt should be the same")
 	require.WithinDurationf(t, expireAt, payload.ExpiredAt, time.Second, "expiredAt should be the same")
+	require.Equalf(t, createdPayload.ID, payload.ID, "id should be the same")
 }
 
 func TestJWTMaker_VerifyToken_ExpiredJWTToken(t *testing.T) {
@@ -38,7 +45,7 @@ func TestJWTMaker_VerifyToken_ExpiredJWTToken(t *testing.T) {
 	require.NoErrorf(t, err, "cannot create jwt maker")
 
 	// create token with -1 minute duration
-	token, err := maker.CreateToken(util.RandomOwner(), -time.Minute)
+	token, _, err := maker.CreateToken(util.RandomOwner(), util.UserRole, TokenTypeAccess, -time.Minute)
 	require.NoErrorf(t, err, "cannot create token")
 	require.NotEmptyf(t, token, "token should not be empty")
 
@@ -50,7 +57,7 @@ func TestJWTMaker_VerifyToken_ExpiredJWTToken(t *testing.T) {
 }
 
 func TestJWTMaker_VerifyToken_InvalidJWTTokenAlgNone(t *testing.T) {
-	payload, err := NewPayload(util.RandomOwner(), time.Minute)
+	payload, err := NewPayload(util.RandomOwner(), util.UserRole, TokenTypeAccess, time.Minute)
 	require.NoErrorf(t, err, "cannot create payload")
 
 	// create token with none algorithm
@@ -4,8 +4,9 @@ import "time"
 
 // Maker is a factory interface for token creation.
 type Maker interface {
-	// CreateToken creates a new token for a specific username and duration.
-	CreateToken(username string, duration time.Duration) (string, error)
+	// CreateToken creates a new token for a specific username, role, token type and duration.
+	// The payload is returned so callers can record the token ID and expiry.
+	CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error)
 
 	// VerifyToken checks if the token is valid or not.
 	VerifyToken(token string) (*Payload, error)
@@ -12,14 +12,16 @@ func TestPasetoMaker_CreateToken(t *testing.T) {
 	require.NoErrorf(t, err, "cannot create paseto maker")
 
 	username := util.RandomOwner()
+	role := util.AdminRole
 	duration := time.Minute
 
 	issueAt := time.Now()
 	expireAt := issueAt.Add(duration)
 
-	token, err := maker.CreateToken(username, duration)
+	token, createdPayload, err := maker.CreateToken(username, role, TokenTypeRefresh, duration)
 	require.NoErrorf(t, err, "cannot create token")
 	require.NotEmptyf(t, token, "token should not be empty")
+	require.NotEmptyf(t, createdPayload, "payload should not be empty")
 
 	payload, err := maker.VerifyToken(token)
 	require.NoErrorf(t, err, "cannot verify token")
@@ -27,8 +29,13 @@ func TestPasetoMaker_CreateToken(t *testing.T) {
 
 	require.NotZerof(t, payload.ID, "id should not be zero")
 	require.Equalf(t, username, payload.Username, "username should be the same")
+	require.Equalf(t, role, payload.Role, "role should be the same")
+	require.Equalf(t, TokenTypeRefresh, payload.Type, "type should be the same")
+	require.NoError(t, payload.CheckType(TokenTypeRefresh))
+	require.ErrorIs(t, payload.CheckType(TokenTypeAccess), ErrWrongTokenType)
 	require.WithinDurationf(t, issueAt, payload.IssuedAt, time.Second, "issuedAt should be the same")
 	require.WithinDurationf(t, expireAt, payload.ExpiredAt, time.Second, "expiredAt should be the same")
+	require.Equalf(t, createdPayload.ID, payload.ID, "id should be the same")
 }
 
 func TestPasetoMaker_VerifyToken_ExpiredPasetoToken(t *testing.T) {
@@ -36,7 +43,7 @@ func TestPasetoMaker_VerifyToken_ExpiredPasetoToken(t *testing.T) {
 	require.NoErrorf(t, err, "cannot create paseto maker")
 
 	// create token with -1 minute duration
-	token, err := maker.CreateToken(util.RandomOwner(), -time.Minute)
+	token, _, err := maker.CreateToken(util.RandomOwner(), util.UserRole, TokenTypeAccess, -time.Minute)
 	require.NoErrorf(t, err, "cannot create token")
 	require.NotEmptyf(t, token, "token should not be empty")
 
@@ -26,12 +26,23 @@ var (
 	ErrTokenInvalidId            = errors.New("token has invalid id")
 	ErrTokenInvalidClaims        = errors.New("token has invalid claims")
 	ErrInvalidType               = errors.New("invalid type for claim")
+	ErrWrongTokenType            = errors.New("token is of the wrong type")
+)
+
+// TokenType tells access tokens and refresh tokens apart, so one can't be used in place of the other.
+type TokenType string
+
+const (
+	TokenTypeAccess  TokenType = "access"
+	TokenTypeRefresh TokenType = "refresh"
 )
 
 // Payload is the payload of a token.
 type Payload struct {
 	ID        uuid.UUID `json:"id"`
 	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	Type      TokenType `json:"type"`
 	Issuer    string    `json:"issuer"`
 	Subject   string    `json:"subject"`
 	Audience  []string  `json:"audience"`
@@ -73,8 +84,16 @@ func (p Payload) Valid() error {
 	return nil
 }
 
-// NewPayload creates a new payload for a specific username and duration.
-func NewPayload(username string, duration time.Duration) (payload *Payload, err error) {
+// CheckType returns ErrWrongTokenType unless the token is of the given type.
+func (p *Payload) CheckType(tokenType TokenType) error {
+	if p.Type != tokenType {
+		return ErrWrongTokenType
+	}
+	return nil
+}
+
+// NewPayload creates a new payload for a specific username, role, token type and duration.
+func NewPayload(username string, role string, tokenType TokenType, duration time.Duration) (payload *Payload, err error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return
@@ -83,6 +102,8 @@ func NewPayload(username string, duration time.Duration) (payload *Payload, err
 	payload = &Payload{
 		ID:        tokenID,
 		Username:  username,
+		Role:      role,
+		Type:      tokenType,
 		IssuedAt:  time.Now(),
 		ExpiredAt: time.Now().Add(duration),
 	}
@@ -0,0 +1,85 @@
+package token
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"log"
+	"sync"
+	"time"
+)
+
+// RevocationStore keeps track of tokens that were revoked before they expired.
+type RevocationStore interface {
+	// Revoke marks the token as revoked until it expires.
+	Revoke(ctx context.Context, payload *Payload) error
+
+	// IsRevoked checks if the token with the given ID was revoked.
+	IsRevoked(ctx context.Context, tokenID uuid.UUID) (bool, error)
+
+	// DeleteExpired removes revocations of tokens that expired before the given time.
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// MemoryRevocationStore is an in-memory implementation of RevocationStore.
+// It is meant for tests and single instance deployments.
+type MemoryRevocationStore struct {
+	mu      sync.RWMutex
+	revoked map[uuid.UUID]time.Time
+}
+
+// NewMemoryRevocationStore creates a new MemoryRevocationStore.
+func NewMemoryRevocationStore() RevocationStore {
+	return &MemoryRevocationStore{
+		revoked: make(map[uuid.UUID]time.Time),
+	}
+}
+
+func (store *MemoryRevocationStore) Revoke(_ context.Context, payload *Payload) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.revoked[payload.ID] = payload.ExpiredAt
+	return nil
+}
+
+func (store *MemoryRevocationStore) IsRevoked(_ context.Context, tokenID uuid.UUID) (bool, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	_, ok := store.revoked[tokenID]
+	return ok, nil
+}
+
+func (store *MemoryRevocationStore) DeleteExpired(_ context.Context, before time.Time) (int64, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var deleted int64
+	for id, expiredAt := range store.revoked {
+		if expiredAt.Before(before) {
+			delete(store.revoked, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// SweepRevocations deletes the revocations of expired tokens every interval until the context is done.
+// An expired token is rejected anyway, so its revocation is no longer needed.
+func SweepRevocations(ctx context.Context, store RevocationStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := store.DeleteExpired(ctx, time.Now())
+			if err != nil {
+				log.Println("Failed to delete expired revocations: ", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package token
+
+import (
+	"context"
+	"github.com/google/uuid"
+	db "practice-docker/db/sqlc"
+	"time"
+)
+
+// PostgresRevocationStore is a RevocationStore backed by the revoked_tokens table,
+// so revocations are shared by every server instance.
+type PostgresRevocationStore struct {
+	store db.Store
+}
+
+// NewPostgresRevocationStore creates a new PostgresRevocationStore.
+func NewPostgresRevocationStore(store db.Store) RevocationStore {
+	return &PostgresRevocationStore{store: store}
+}
+
+func (p *PostgresRevocationStore) Revoke(ctx context.Context, payload *Payload) error {
+	return p.store.CreateRevokedToken(ctx, db.CreateRevokedTokenParams{
+		ID:        payload.ID,
+		Username:  payload.Username,
+		ExpiresAt: payload.ExpiredAt,
+	})
+}
+
+func (p *PostgresRevocationStore) IsRevoked(ctx context.Context, tokenID uuid.UUID) (bool, error) {
+	return p.store.IsTokenRevoked(ctx, tokenID)
+}
+
+func (p *PostgresRevocationStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	return p.store.DeleteExpiredRevokedTokens(ctx, before)
+}
@@ -0,0 +1,66 @@
+package token
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"practice-docker/util"
+	"testing"
+	"time"
+)
+
+func TestMemoryRevocationStore(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	ctx := context.Background()
+
+	payload, err := NewPayload(util.RandomOwner(), util.UserRole, TokenTypeAccess, time.Minute)
+	require.NoErrorf(t, err, "cannot create payload")
+
+	revoked, err := store.IsRevoked(ctx, payload.ID)
+	require.NoError(t, err)
+	require.Falsef(t, revoked, "token should not be revoked")
+
+	err = store.Revoke(ctx, payload)
+	require.NoError(t, err)
+
+	revoked, err = store.IsRevoked(ctx, payload.ID)
+	require.NoError(t, err)
+	require.Truef(t, revoked, "token should be revoked")
+
+	// the token has not expired yet, so the revocation is kept
+	deleted, err := store.DeleteExpired(ctx, time.Now())
+	require.NoError(t, err)
+	require.Zero(t, deleted)
+
+	deleted, err = store.DeleteExpired(ctx, payload.ExpiredAt.Add(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+
+	revoked, err = store.IsRevoked(ctx, payload.ID)
+	require.NoError(t, err)
+	require.Falsef(t, revoked, "revocation should be deleted")
+}
+
+func TestSweepRevocations(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	payload, err := NewPayload(util.RandomOwner(), util.UserRole, TokenTypeAccess, -time.Minute)
+	require.NoErrorf(t, err, "cannot create payload")
+
+	err = store.Revoke(ctx, payload)
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		SweepRevocations(ctx, store, time.Millisecond)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		revoked, err := store.IsRevoked(context.Background(), payload.ID)
+		return err == nil && !revoked
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-done
+}
@@ -8,13 +8,74 @@ import (
 // Config stores all configuration for the application.
 // The values are read by viper from the config file or environment variables.
 type Config struct {
-	DBDriver            string        `mapstructure:"DB_DRIVER"`
-	DBSource            string        `mapstructure:"DB_SOURCE"`
-	ServerAddress       string        `mapstructure:"SERVER_ADDRESS"`
-	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenLifetime time.Duration `mapstructure:"ACCESS_TOKEN_LIFETIME"`
+	DBDriver             string        `mapstructure:"DB_DRIVER"`
+	DBSource             string        `mapstructure:"DB_SOURCE"`
+	ServerAddress        string        `mapstructure:"SERVER_ADDRESS"`
+	GRPCServerAddress    string        `mapstructure:"GRPC_SERVER_ADDRESS"`
+	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	AccessTokenLifetime  time.Duration `mapstructure:"ACCESS_TOKEN_LIFETIME"`
+	RefreshTokenLifetime time.Duration `mapstructure:"REFRESH_TOKEN_LIFETIME"`
+	// RevocationSweepInterval is how often revocations of expired tokens are deleted.
+	RevocationSweepInterval time.Duration `mapstructure:"REVOCATION_SWEEP_INTERVAL"`
+	// TrustedProxies are the IPs or CIDRs whose X-Forwarded-For is believed, e.g. "127.0.0.1,10.0.0.0/8".
+	// Both servers default to DefaultTrustedProxies.
+	TrustedProxies []string `mapstructure:"TRUSTED_PROXIES"`
+	// ExchangeRateFile is a JSON file of "FROM/TO" pairs to rates, e.g. {"USD/EUR": "0.92"}.
+	// Without it only transfers between accounts of the same currency are possible.
+	ExchangeRateFile string `mapstructure:"EXCHANGE_RATE_FILE"`
+	// CurrencyCacheTTL is how long the currencies table is cached before it is read again.
+	CurrencyCacheTTL time.Duration `mapstructure:"CURRENCY_CACHE_TTL"`
+	// ScheduledTransferInterval is how often the worker looks for scheduled transfers that are due.
+	ScheduledTransferInterval time.Duration `mapstructure:"SCHEDULED_TRANSFER_INTERVAL"`
+	// OutboxWebhookURL receives the events of the outbox. Without it events are kept until one is configured.
+	OutboxWebhookURL string `mapstructure:"OUTBOX_WEBHOOK_URL"`
+	// OutboxRelayInterval is how often the relay looks for outbox events to publish.
+	OutboxRelayInterval time.Duration `mapstructure:"OUTBOX_RELAY_INTERVAL"`
+	// WebhookDispatchInterval is how often pending deliveries to the webhooks of the users are sent.
+	WebhookDispatchInterval time.Duration `mapstructure:"WEBHOOK_DISPATCH_INTERVAL"`
+	// PasswordResetTokenLifetime is how long a mailed password reset token can be used.
+	PasswordResetTokenLifetime time.Duration `mapstructure:"PASSWORD_RESET_TOKEN_LIFETIME"`
+	// SMTPAddress is the "host:port" of the SMTP server. Without it mails are written to MailDir.
+	SMTPAddress  string `mapstructure:"SMTP_ADDRESS"`
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+	// MailSender is the From address of the mails to users.
+	MailSender string `mapstructure:"MAIL_SENDER"`
+	// MailDir is the directory mails are written to when SMTPAddress is not set.
+	MailDir string `mapstructure:"MAIL_DIR"`
+	// PublicBaseURL is where clients reach the HTTP server, e.g. https://bank.example.com. It is used for links in mails.
+	PublicBaseURL string `mapstructure:"PUBLIC_BASE_URL"`
+	// EmailVerificationLinkLifetime is how long the link of a verification mail can be used.
+	EmailVerificationLinkLifetime time.Duration `mapstructure:"EMAIL_VERIFICATION_LINK_LIFETIME"`
+	// RequireVerifiedEmailForAccounts blocks opening accounts until the user verified their email.
+	RequireVerifiedEmailForAccounts bool `mapstructure:"REQUIRE_VERIFIED_EMAIL_FOR_ACCOUNTS"`
+	// RequireVerifiedEmailForTransfers blocks sending transfers until the user verified their email.
+	RequireVerifiedEmailForTransfers bool `mapstructure:"REQUIRE_VERIFIED_EMAIL_FOR_TRANSFERS"`
+	// LoginFailureWindow is how long a failed login is remembered.
+	LoginFailureWindow time.Duration `mapstructure:"LOGIN_FAILURE_WINDOW"`
+	// LoginFailureDelay is the wait after the first failed login. It doubles with every failure up to LoginMaxFailureDelay.
+	LoginFailureDelay    time.Duration `mapstructure:"LOGIN_FAILURE_DELAY"`
+	LoginMaxFailureDelay time.Duration `mapstructure:"LOGIN_MAX_FAILURE_DELAY"`
+	// LoginLockoutDuration is the wait once a username or client IP reached its maximum number of failed logins.
+	LoginLockoutDuration        time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	LoginMaxFailuresPerUsername int32         `mapstructure:"LOGIN_MAX_FAILURES_PER_USERNAME"`
+	LoginMaxFailuresPerIP       int32         `mapstructure:"LOGIN_MAX_FAILURES_PER_IP"`
+	// RateLimitPeriod is how long the request budget of a route group takes to fill up again.
+	RateLimitPeriod time.Duration `mapstructure:"RATE_LIMIT_PERIOD"`
+	// The budgets of the route groups, in requests per RateLimitPeriod of every user or client IP.
+	RateLimitUsers     int32 `mapstructure:"RATE_LIMIT_USERS"`
+	RateLimitAccounts  int32 `mapstructure:"RATE_LIMIT_ACCOUNTS"`
+	RateLimitTransfers int32 `mapstructure:"RATE_LIMIT_TRANSFERS"`
+	RateLimitWebhooks  int32 `mapstructure:"RATE_LIMIT_WEBHOOKS"`
+	RateLimitAdmin     int32 `mapstructure:"RATE_LIMIT_ADMIN"`
+	// RateLimitFailClosed rejects the requests of a route group when its budgets can't be read, e.g. while the database is down.
+	// By default such requests are let through without a limit, so an outage of the limiter doesn't also block logins.
+	RateLimitFailClosed bool `mapstructure:"RATE_LIMIT_FAIL_CLOSED"`
 }
 
+// DefaultTrustedProxies is used when TrustedProxies is not set: only a proxy on the same host is trusted.
+var DefaultTrustedProxies = []string{"127.0.0.1"}
+
 // LoadConfig loads the configuration from the config file or environment variables.
 func LoadConfig(path string) (config Config, err error) {
 	viper.AddConfigPath(path)
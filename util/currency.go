@@ -1,17 +1,148 @@
 package util
 
-// CurrencyList is a list of valid currencies.
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CurrencyList is a list of common currencies.
+// The supported currencies are stored in the currencies table.
 const (
 	USD = "USD"
 	EUR = "EUR"
 	CAD = "CAD"
+	JPY = "JPY"
+	KWD = "KWD"
 )
 
-// IsSupportedCurrency checks if the currency is supported.
-func IsSupportedCurrency(currency string) bool {
-	switch currency {
-	case USD, EUR, CAD:
-		return true
+// ErrCurrencyNotFound is returned when a currency is not in the registry.
+var ErrCurrencyNotFound = errors.New("currency not found")
+
+// Currency describes an ISO 4217 currency.
+type Currency struct {
+	Code string
+	// Exponent is the number of minor-unit decimal places, e.g. 2 for USD and 0 for JPY.
+	Exponent int32
+	Enabled  bool
+}
+
+// CurrencyLoader loads every known currency.
+type CurrencyLoader func(ctx context.Context) ([]Currency, error)
+
+// CurrencyRegistry is a cached copy of the currencies table.
+// It is loaded again once the cache is older than the ttl.
+type CurrencyRegistry struct {
+	load CurrencyLoader
+	ttl  time.Duration
+
+	mu         sync.RWMutex
+	currencies map[string]Currency
+	loadedAt   time.Time
+}
+
+// NewCurrencyRegistry creates a registry that caches the loaded currencies for ttl.
+// A ttl of zero or less loads the currencies only once.
+func NewCurrencyRegistry(load CurrencyLoader, ttl time.Duration) *CurrencyRegistry {
+	return &CurrencyRegistry{
+		load: load,
+		ttl:  ttl,
 	}
-	return false
+}
+
+// NewStaticCurrencyRegistry creates a registry of a fixed list of currencies.
+func NewStaticCurrencyRegistry(currencies ...Currency) *CurrencyRegistry {
+	return NewCurrencyRegistry(func(context.Context) ([]Currency, error) {
+		return currencies, nil
+	}, 0)
+}
+
+// Get returns the currency with the given code, whether it is enabled or not.
+func (registry *CurrencyRegistry) Get(ctx context.Context, code string) (Currency, error) {
+	currencies, err := registry.cached(ctx)
+	if err != nil {
+		return Currency{}, err
+	}
+
+	currency, ok := currencies[code]
+	if !ok {
+		return Currency{}, ErrCurrencyNotFound
+	}
+
+	return currency, nil
+}
+
+// IsSupported checks if the currency is known and enabled.
+func (registry *CurrencyRegistry) IsSupported(ctx context.Context, code string) bool {
+	currency, err := registry.Get(ctx, code)
+	return err == nil && currency.Enabled
+}
+
+// Format formats an amount in minor units as a decimal string in the currency.
+// Amounts of unknown currencies are formatted without decimal places.
+func (registry *CurrencyRegistry) Format(ctx context.Context, code string, amount int64) string {
+	currency, err := registry.Get(ctx, code)
+	if err != nil {
+		return strconv.FormatInt(amount, 10)
+	}
+
+	return FormatAmount(amount, currency.Exponent)
+}
+
+// cached returns the cached currencies and loads them again when the cache expired.
+// If loading fails, the stale copy is used when there is one.
+func (registry *CurrencyRegistry) cached(ctx context.Context) (map[string]Currency, error) {
+	registry.mu.RLock()
+	currencies, loadedAt := registry.currencies, registry.loadedAt
+	registry.mu.RUnlock()
+
+	fresh := registry.ttl <= 0 || time.Since(loadedAt) < registry.ttl
+	if currencies != nil && fresh {
+		return currencies, nil
+	}
+
+	list, err := registry.load(ctx)
+	if err != nil {
+		if currencies != nil {
+			return currencies, nil
+		}
+		return nil, err
+	}
+
+	currencies = make(map[string]Currency, len(list))
+	for _, currency := range list {
+		currencies[currency.Code] = currency
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.currencies = currencies
+	registry.loadedAt = time.Now()
+	return currencies, nil
+}
+
+// FormatAmount formats an amount in minor units as a decimal string, e.g. 12345 with exponent 2 is "123.45".
+func FormatAmount(amount int64, exponent int32) string {
+	digits := strconv.FormatInt(amount, 10)
+	if exponent <= 0 {
+		return digits
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		digits = digits[1:]
+	}
+
+	// pad with leading zeros so there is at least one digit before the point
+	if len(digits) <= int(exponent) {
+		digits = strings.Repeat("0", int(exponent)-len(digits)+1) + digits
+	}
+
+	point := len(digits) - int(exponent)
+	return sign + digits[:point] + "." + digits[point:]
 }
@@ -0,0 +1,78 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestFormatAmount(t *testing.T) {
+	testCases := []struct {
+		amount   int64
+		exponent int32
+		want     string
+	}{
+		{amount: 12345, exponent: 2, want: "123.45"},
+		{amount: 5, exponent: 2, want: "0.05"},
+		{amount: 0, exponent: 2, want: "0.00"},
+		{amount: -105, exponent: 2, want: "-1.05"},
+		{amount: -5, exponent: 3, want: "-0.005"},
+		{amount: 12345, exponent: 0, want: "12345"},
+		{amount: 1234567, exponent: 3, want: "1234.567"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.want, FormatAmount(tc.amount, tc.exponent))
+	}
+}
+
+func TestCurrencyRegistry(t *testing.T) {
+	loads := 0
+	registry := NewCurrencyRegistry(func(context.Context) ([]Currency, error) {
+		loads++
+		return []Currency{
+			{Code: USD, Exponent: 2, Enabled: true},
+			{Code: KWD, Exponent: 3, Enabled: false},
+		}, nil
+	}, time.Hour)
+
+	ctx := context.Background()
+	require.True(t, registry.IsSupported(ctx, USD))
+	require.False(t, registry.IsSupported(ctx, KWD))
+	require.False(t, registry.IsSupported(ctx, JPY))
+
+	require.Equal(t, "1.500", registry.Format(ctx, KWD, 1500))
+	require.Equal(t, "1500", registry.Format(ctx, JPY, 1500))
+
+	_, err := registry.Get(ctx, JPY)
+	require.ErrorIs(t, err, ErrCurrencyNotFound)
+
+	// the table is loaded once while the cache is fresh
+	require.Equal(t, 1, loads)
+}
+
+func TestCurrencyRegistryStale(t *testing.T) {
+	fail := false
+	registry := NewCurrencyRegistry(func(context.Context) ([]Currency, error) {
+		if fail {
+			return nil, errors.New("database is down")
+		}
+		return []Currency{{Code: USD, Exponent: 2, Enabled: true}}, nil
+	}, time.Nanosecond)
+
+	ctx := context.Background()
+	require.True(t, registry.IsSupported(ctx, USD))
+
+	// the stale copy is used when the table cannot be loaded again
+	fail = true
+	time.Sleep(time.Millisecond)
+	require.True(t, registry.IsSupported(ctx, USD))
+
+	empty := NewCurrencyRegistry(func(context.Context) ([]Currency, error) {
+		return nil, errors.New("database is down")
+	}, time.Minute)
+	_, err := empty.Get(ctx, USD)
+	require.Error(t, err)
+}
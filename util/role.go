@@ -0,0 +1,7 @@
+package util
+
+// Roles of a user.
+const (
+	UserRole  = "user"
+	AdminRole = "admin"
+)
@@ -0,0 +1,121 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	db "practice-docker/db/sqlc"
+	"practice-docker/event"
+	"time"
+)
+
+const (
+	// minRetryDelay is the wait after the first failed delivery of an event.
+	minRetryDelay = 5 * time.Second
+	// maxRetryDelay caps the exponential backoff between deliveries of an event.
+	maxRetryDelay = time.Hour
+)
+
+// outboxEventLease is how long a claimed event is left to its relay.
+// It outlasts the timeout of the publisher, so an event is only published again when its relay died.
+const outboxEventLease = time.Minute
+
+// OutboxRelay publishes the events written to the outbox.
+// An event is marked as published only after the publisher accepted it, so delivery is
+// at least once. Failed deliveries are retried with exponential backoff.
+type OutboxRelay struct {
+	store     db.Store
+	publisher event.EventPublisher
+	interval  time.Duration
+	now       func() time.Time
+}
+
+// NewOutboxRelay creates a relay that looks for pending events every interval.
+func NewOutboxRelay(store db.Store, publisher event.EventPublisher, interval time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		store:     store,
+		publisher: publisher,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+// Run publishes the pending events every interval until the context is done.
+func (relay *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(relay.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := relay.RelayPending(ctx)
+			if err != nil {
+				log.Println("Failed to relay outbox events: ", err)
+			}
+		}
+	}
+}
+
+// RelayPending attempts every event that is due and returns how many attempts were made.
+// Each event is claimed and committed before it is published, so no transaction is open during the request.
+// An event that fails is rescheduled and not attempted again in the same call.
+func (relay *OutboxRelay) RelayPending(ctx context.Context) (int, error) {
+	now := relay.now()
+
+	for n := 0; ; n++ {
+		outbox, err := relay.store.ClaimDueOutboxEvent(ctx, db.ClaimDueOutboxEventParams{
+			LeaseUntil: now.Add(outboxEventLease),
+			Now:        now,
+		})
+		if err == sql.ErrNoRows {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+
+		err = relay.publish(ctx, outbox)
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// publish delivers the event and records the outcome of the attempt.
+func (relay *OutboxRelay) publish(ctx context.Context, outbox db.Outbox) error {
+	arg := db.UpdateOutboxEventDeliveryParams{
+		ID:            outbox.ID,
+		Attempts:      outbox.Attempts + 1,
+		NextAttemptAt: outbox.NextAttemptAt,
+	}
+
+	err := relay.publisher.Publish(ctx, event.Event{
+		ID:        outbox.ID,
+		Type:      outbox.EventType,
+		Payload:   outbox.Payload,
+		CreatedAt: outbox.CreatedAt,
+	})
+	if err != nil {
+		arg.NextAttemptAt = relay.now().Add(retryDelay(arg.Attempts))
+		arg.LastError = sql.NullString{String: err.Error(), Valid: true}
+	} else {
+		arg.PublishedAt = sql.NullTime{Time: relay.now(), Valid: true}
+	}
+
+	_, err = relay.store.UpdateOutboxEventDelivery(ctx, arg)
+	return err
+}
+
+// retryDelay returns the wait before the next delivery after the given number of failed attempts.
+func retryDelay(attempts int32) time.Duration {
+	delay := minRetryDelay
+	for i := int32(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+	return delay
+}
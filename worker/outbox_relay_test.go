@@ -0,0 +1,128 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockDB "practice-docker/db/mock"
+	db "practice-docker/db/sqlc"
+	"practice-docker/event"
+	"practice-docker/util"
+	"testing"
+	"time"
+)
+
+// failingPublisher rejects every event.
+type failingPublisher struct {
+	err error
+}
+
+func (publisher failingPublisher) Publish(ctx context.Context, event event.Event) error {
+	return publisher.err
+}
+
+func TestRetryDelay(t *testing.T) {
+	require.Equal(t, minRetryDelay, retryDelay(1))
+	require.Equal(t, 2*minRetryDelay, retryDelay(2))
+	require.Equal(t, 8*minRetryDelay, retryDelay(4))
+	require.Equal(t, maxRetryDelay, retryDelay(20))
+	require.Equal(t, maxRetryDelay, retryDelay(1000))
+}
+
+func TestOutboxRelay_RelayPending(t *testing.T) {
+	now := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+	outbox := db.Outbox{
+		ID:            util.RandomInt(1, 1000),
+		EventType:     db.EventTransferCompleted,
+		Payload:       []byte(`{"transfer":{"id":1}}`),
+		Attempts:      2,
+		NextAttemptAt: now.Add(-time.Minute),
+		CreatedAt:     now.Add(-time.Hour),
+	}
+
+	memory := event.NewMemoryPublisher()
+
+	testCases := []struct {
+		name      string
+		publisher event.EventPublisher
+		checkRun  func(t *testing.T, count int, err error, delivery db.UpdateOutboxEventDeliveryParams)
+	}{
+		{
+			name:      "Published",
+			publisher: memory,
+			checkRun: func(t *testing.T, count int, err error, delivery db.UpdateOutboxEventDeliveryParams) {
+				require.NoError(t, err)
+				require.Equal(t, 1, count)
+				require.Equal(t, int32(3), delivery.Attempts)
+				require.Equal(t, sql.NullTime{Time: now, Valid: true}, delivery.PublishedAt)
+				require.False(t, delivery.LastError.Valid)
+
+				events := memory.Events()
+				require.Len(t, events, 1)
+				require.Equal(t, outbox.ID, events[0].ID)
+				require.Equal(t, outbox.EventType, events[0].Type)
+				require.JSONEq(t, string(outbox.Payload), string(events[0].Payload))
+				require.Equal(t, outbox.CreatedAt, events[0].CreatedAt)
+			},
+		},
+		{
+			name:      "PublishFailed",
+			publisher: failingPublisher{err: errors.New("connection refused")},
+			checkRun: func(t *testing.T, count int, err error, delivery db.UpdateOutboxEventDeliveryParams) {
+				// the failure is recorded and the event retried later
+				require.NoError(t, err)
+				require.Equal(t, 1, count)
+				require.Equal(t, int32(3), delivery.Attempts)
+				require.False(t, delivery.PublishedAt.Valid)
+				require.Equal(t, now.Add(retryDelay(3)), delivery.NextAttemptAt)
+				require.Equal(t, "connection refused", delivery.LastError.String)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockDB.NewMockStore(ctrl)
+
+			// the first call claims the event, the second one finds nothing due
+			arg := db.ClaimDueOutboxEventParams{
+				LeaseUntil: now.Add(outboxEventLease),
+				Now:        now,
+			}
+			gomock.InOrder(
+				store.EXPECT().
+					ClaimDueOutboxEvent(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(outbox, nil),
+				store.EXPECT().
+					ClaimDueOutboxEvent(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.Outbox{}, sql.ErrNoRows),
+			)
+
+			// the outcome is recorded after the claim was committed
+			var delivery db.UpdateOutboxEventDeliveryParams
+			store.EXPECT().
+				UpdateOutboxEventDelivery(gomock.Any(), gomock.Any()).
+				Times(1).
+				DoAndReturn(func(_ context.Context, arg db.UpdateOutboxEventDeliveryParams) (db.Outbox, error) {
+					require.Equal(t, outbox.ID, arg.ID)
+					delivery = arg
+					return outbox, nil
+				})
+
+			relay := NewOutboxRelay(store, tc.publisher, time.Second)
+			relay.now = func() time.Time { return now }
+
+			count, err := relay.RelayPending(context.Background())
+			tc.checkRun(t, count, err, delivery)
+		})
+	}
+}
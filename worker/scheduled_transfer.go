@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	db "practice-docker/db/sqlc"
+	"practice-docker/service"
+	"time"
+)
+
+// occurrenceRetryDelay is how long an occurrence that failed for a reason other than the accounts or the owner waits before it is retried.
+const occurrenceRetryDelay = 5 * time.Minute
+
+// ScheduledTransferWorker runs the occurrences of scheduled transfers once they are due.
+// Several workers may run against the same database, each occurrence is claimed by one of them.
+type ScheduledTransferWorker struct {
+	store db.Store
+	// bank sends the occurrences with the same rules as the transfers of the API.
+	bank     *service.Bank
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewScheduledTransferWorker creates a worker that looks for due scheduled transfers every interval.
+func NewScheduledTransferWorker(store db.Store, bank *service.Bank, interval time.Duration) *ScheduledTransferWorker {
+	return &ScheduledTransferWorker{
+		store:    store,
+		bank:     bank,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run runs the due occurrences every interval until the context is done.
+func (worker *ScheduledTransferWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(worker.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := worker.RunDue(ctx)
+			if err != nil {
+				log.Println("Failed to run scheduled transfers: ", err)
+			}
+		}
+	}
+}
+
+// RunDue runs every occurrence that is due and returns how many were run or put off.
+// Occurrences missed while no worker was running are caught up one by one.
+// An occurrence that fails is put off by occurrenceRetryDelay, so it doesn't hold up the schedules due after it.
+func (worker *ScheduledTransferWorker) RunDue(ctx context.Context) (int, error) {
+	now := worker.now()
+
+	for n := 0; ; n++ {
+		_, err := worker.store.RunScheduledTransferTx(ctx, now, worker.runOccurrence)
+		if err == sql.ErrNoRows {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// runOccurrence sends the next occurrence of the scheduled transfer through the Bank.
+func (worker *ScheduledTransferWorker) runOccurrence(
+	ctx context.Context,
+	scheduled db.ScheduledTransfers,
+) (db.UpdateScheduledTransferRunParams, error) {
+	now := worker.now()
+
+	arg, err := worker.bank.RunScheduledTransfer(ctx, scheduled, now)
+	if err != nil {
+		// the occurrence is put off and retried, the error is recorded in the meantime
+		log.Printf("Failed to run scheduled transfer %d, retrying in %s: %v", scheduled.ID, occurrenceRetryDelay, err)
+		return db.UpdateScheduledTransferRunParams{
+			NextRunAt:      now.Add(occurrenceRetryDelay),
+			RunCount:       scheduled.RunCount,
+			IsActive:       scheduled.IsActive,
+			LastRunAt:      sql.NullTime{Time: now, Valid: true},
+			LastTransferID: scheduled.LastTransferID,
+			LastError:      sql.NullString{String: err.Error(), Valid: true},
+		}, nil
+	}
+
+	return arg, nil
+}
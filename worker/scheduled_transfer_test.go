@@ -0,0 +1,233 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockDB "practice-docker/db/mock"
+	db "practice-docker/db/sqlc"
+	"practice-docker/service"
+	"practice-docker/util"
+	"testing"
+	"time"
+)
+
+func randomScheduledTransfer(frequency db.TransferFrequency) db.ScheduledTransfers {
+	start := time.Date(2024, time.January, 31, 9, 0, 0, 0, time.UTC)
+	return db.ScheduledTransfers{
+		ID:            util.RandomInt(1, 1000),
+		Owner:         util.RandomOwner(),
+		FromAccountID: util.RandomInt(1, 1000),
+		ToAccountID:   util.RandomInt(1001, 2000),
+		Amount:        util.RandomMoney(),
+		Frequency:     frequency,
+		StartAt:       start,
+		NextRunAt:     start,
+		IsActive:      true,
+	}
+}
+
+// buildAccountStubs returns active USD accounts for both sides of the scheduled transfer.
+func buildAccountStubs(store *mockDB.MockStore, scheduled db.ScheduledTransfers) {
+	store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Eq(scheduled.FromAccountID)).
+		AnyTimes().
+		Return(db.Accounts{
+			ID:       scheduled.FromAccountID,
+			Owner:    scheduled.Owner,
+			Currency: util.USD,
+			Status:   db.AccountStatusActive,
+		}, nil)
+	store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Eq(scheduled.ToAccountID)).
+		AnyTimes().
+		Return(db.Accounts{
+			ID:       scheduled.ToAccountID,
+			Owner:    util.RandomOwner(),
+			Currency: util.USD,
+			Status:   db.AccountStatusActive,
+		}, nil)
+}
+
+func newTestWorker(store db.Store) *ScheduledTransferWorker {
+	bank := service.NewBank(util.Config{}, nil, service.Dependencies{Store: store})
+	return NewScheduledTransferWorker(store, bank, time.Minute)
+}
+
+func TestScheduledTransferWorker_RunDue(t *testing.T) {
+	now := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		scheduled  db.ScheduledTransfers
+		buildStubs func(store *mockDB.MockStore, scheduled db.ScheduledTransfers)
+		checkRun   func(t *testing.T, count int, err error, scheduled db.ScheduledTransfers, run db.UpdateScheduledTransferRunParams)
+	}{
+		{
+			name:      "OK",
+			scheduled: randomScheduledTransfer(db.TransferFrequencyMonthly),
+			buildStubs: func(store *mockDB.MockStore, scheduled db.ScheduledTransfers) {
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+						require.Equal(t, scheduled.FromAccountID, arg.FromAccountID)
+						require.Equal(t, scheduled.ToAccountID, arg.ToAccountID)
+						require.Equal(t, scheduled.Amount, arg.Amount)
+						require.Equal(t, scheduled.Owner, arg.Idempotency.Username)
+						require.Equal(t, fmt.Sprintf("scheduled-transfer:%d:1", scheduled.ID), arg.Idempotency.Key)
+						return db.TransferTxResult{Transfer: db.Transfers{ID: 42}}, nil
+					})
+			},
+			checkRun: func(t *testing.T, count int, err error, scheduled db.ScheduledTransfers, run db.UpdateScheduledTransferRunParams) {
+				require.NoError(t, err)
+				require.Equal(t, 1, count)
+				require.Equal(t, int32(1), run.RunCount)
+				require.True(t, run.IsActive)
+				require.Equal(t, time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC), run.NextRunAt)
+				require.Equal(t, sql.NullInt64{Int64: 42, Valid: true}, run.LastTransferID)
+				require.False(t, run.LastError.Valid)
+			},
+		},
+		{
+			name:      "InsufficientFunds",
+			scheduled: randomScheduledTransfer(db.TransferFrequencyDaily),
+			buildStubs: func(store *mockDB.MockStore, scheduled db.ScheduledTransfers) {
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkRun: func(t *testing.T, count int, err error, scheduled db.ScheduledTransfers, run db.UpdateScheduledTransferRunParams) {
+				// the occurrence is skipped and recorded
+				require.NoError(t, err)
+				require.Equal(t, 1, count)
+				require.True(t, run.IsActive)
+				require.Equal(t, scheduled.StartAt.AddDate(0, 0, 1), run.NextRunAt)
+				require.Equal(t, db.ErrInsufficientFunds.Error(), run.LastError.String)
+			},
+		},
+		{
+			name:      "InternalError",
+			scheduled: randomScheduledTransfer(db.TransferFrequencyOnce),
+			buildStubs: func(store *mockDB.MockStore, scheduled db.ScheduledTransfers) {
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, sql.ErrConnDone)
+			},
+			checkRun: func(t *testing.T, count int, err error, scheduled db.ScheduledTransfers, run db.UpdateScheduledTransferRunParams) {
+				// the occurrence is put off and recorded, the run count stays
+				require.NoError(t, err)
+				require.Equal(t, 1, count)
+				require.Equal(t, scheduled.RunCount, run.RunCount)
+				require.True(t, run.IsActive)
+				require.Equal(t, now.Add(occurrenceRetryDelay), run.NextRunAt)
+				require.Equal(t, scheduled.LastTransferID, run.LastTransferID)
+				require.Equal(t, sql.ErrConnDone.Error(), run.LastError.String)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockDB.NewMockStore(ctrl)
+
+			// the first call claims the schedule, the second one finds nothing due
+			var run db.UpdateScheduledTransferRunParams
+			claimed := false
+			store.EXPECT().
+				RunScheduledTransferTx(gomock.Any(), gomock.Eq(now), gomock.Any()).
+				AnyTimes().
+				DoAndReturn(func(ctx context.Context, _ time.Time, fn db.ScheduledTransferRun) (db.ScheduledTransfers, error) {
+					if claimed {
+						return db.ScheduledTransfers{}, sql.ErrNoRows
+					}
+					claimed = true
+
+					var err error
+					run, err = fn(ctx, tc.scheduled)
+					return tc.scheduled, err
+				})
+			buildAccountStubs(store, tc.scheduled)
+			tc.buildStubs(store, tc.scheduled)
+
+			worker := newTestWorker(store)
+			worker.now = func() time.Time { return now }
+
+			count, err := worker.RunDue(context.Background())
+			tc.checkRun(t, count, err, tc.scheduled, run)
+		})
+	}
+}
+
+func TestScheduledTransferWorker_RunDueFailingSchedule(t *testing.T) {
+	now := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockDB.NewMockStore(ctrl)
+
+	// the failing schedule is due first, the healthy one after it
+	failing := randomScheduledTransfer(db.TransferFrequencyDaily)
+	healthy := randomScheduledTransfer(db.TransferFrequencyDaily)
+	healthy.ID = failing.ID + 1
+	healthy.FromAccountID = failing.FromAccountID + 1
+
+	due := []db.ScheduledTransfers{failing, healthy}
+	runs := map[int64]db.UpdateScheduledTransferRunParams{}
+	store.EXPECT().
+		RunScheduledTransferTx(gomock.Any(), gomock.Eq(now), gomock.Any()).
+		Times(3).
+		DoAndReturn(func(ctx context.Context, _ time.Time, fn db.ScheduledTransferRun) (db.ScheduledTransfers, error) {
+			if len(due) == 0 {
+				return db.ScheduledTransfers{}, sql.ErrNoRows
+			}
+			scheduled := due[0]
+			due = due[1:]
+
+			run, err := fn(ctx, scheduled)
+			if err != nil {
+				return db.ScheduledTransfers{}, err
+			}
+			runs[scheduled.ID] = run
+			return scheduled, nil
+		})
+
+	buildAccountStubs(store, failing)
+	buildAccountStubs(store, healthy)
+	store.EXPECT().
+		TransferTx(gomock.Any(), gomock.Any()).
+		Times(2).
+		DoAndReturn(func(_ context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+			if arg.FromAccountID == failing.FromAccountID {
+				return db.TransferTxResult{}, sql.ErrConnDone
+			}
+			return db.TransferTxResult{Transfer: db.Transfers{ID: 42}}, nil
+		})
+
+	worker := newTestWorker(store)
+	worker.now = func() time.Time { return now }
+
+	count, err := worker.RunDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	// the failing schedule is put off with its error
+	require.Equal(t, now.Add(occurrenceRetryDelay), runs[failing.ID].NextRunAt)
+	require.Zero(t, runs[failing.ID].RunCount)
+	require.Equal(t, sql.ErrConnDone.Error(), runs[failing.ID].LastError.String)
+
+	// the healthy schedule still runs
+	require.Equal(t, int32(1), runs[healthy.ID].RunCount)
+	require.Equal(t, sql.NullInt64{Int64: 42, Valid: true}, runs[healthy.ID].LastTransferID)
+	require.False(t, runs[healthy.ID].LastError.Valid)
+}
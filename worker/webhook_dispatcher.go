@@ -0,0 +1,123 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	db "practice-docker/db/sqlc"
+	"practice-docker/event"
+	"time"
+)
+
+// maxWebhookAttempts is the number of attempts after which a delivery is marked as failed.
+const maxWebhookAttempts = 10
+
+// webhookDeliveryLease is how long a claimed delivery is left to its dispatcher.
+// It outlasts the timeout of the request, so a delivery is only sent again when its dispatcher died.
+const webhookDeliveryLease = time.Minute
+
+// WebhookDispatcher sends the queued deliveries to the webhooks of the users.
+// Each payload is signed with the secret of its webhook. Failed deliveries are retried
+// with exponential backoff until maxWebhookAttempts is reached.
+type WebhookDispatcher struct {
+	store    db.Store
+	sender   *event.WebhookSender
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewWebhookDispatcher creates a dispatcher that looks for due deliveries every interval.
+// Webhooks are only sent to public addresses, so users can't make the server call into its own network.
+func NewWebhookDispatcher(store db.Store, interval time.Duration) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		store:    store,
+		sender:   event.NewPublicWebhookSender(),
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run sends the due deliveries every interval until the context is done.
+func (dispatcher *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(dispatcher.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := dispatcher.DispatchDue(ctx)
+			if err != nil {
+				log.Println("Failed to dispatch webhooks: ", err)
+			}
+		}
+	}
+}
+
+// DispatchDue attempts every delivery that is due and returns how many attempts were made.
+// Each delivery is claimed and committed before it is sent, so no transaction is open during the request.
+func (dispatcher *WebhookDispatcher) DispatchDue(ctx context.Context) (int, error) {
+	now := dispatcher.now()
+
+	for n := 0; ; n++ {
+		delivery, err := dispatcher.store.ClaimDueWebhookDelivery(ctx, db.ClaimDueWebhookDeliveryParams{
+			LeaseUntil: now.Add(webhookDeliveryLease),
+			Now:        now,
+		})
+		if err == sql.ErrNoRows {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+
+		err = dispatcher.deliver(ctx, delivery)
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// deliver sends the delivery to its webhook and records the outcome of the attempt.
+func (dispatcher *WebhookDispatcher) deliver(ctx context.Context, delivery db.WebhookDeliveries) error {
+	webhook, err := dispatcher.store.GetWebhook(ctx, delivery.WebhookID)
+	if err != nil {
+		// the webhook was deleted since, and its deliveries with it
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	arg := db.UpdateWebhookDeliveryParams{
+		ID:            delivery.ID,
+		Status:        db.WebhookDeliveryStatusPending,
+		Attempts:      delivery.Attempts + 1,
+		NextAttemptAt: delivery.NextAttemptAt,
+	}
+
+	status, err := dispatcher.sender.Send(ctx, webhook.Url, webhook.Secret, event.Event{
+		ID:        delivery.ID,
+		Type:      delivery.EventType,
+		Payload:   delivery.Payload,
+		CreatedAt: delivery.CreatedAt,
+	})
+	if status != 0 {
+		arg.ResponseStatus = sql.NullInt32{Int32: int32(status), Valid: true}
+	}
+	if err != nil {
+		arg.LastError = sql.NullString{String: err.Error(), Valid: true}
+		if arg.Attempts >= maxWebhookAttempts {
+			arg.Status = db.WebhookDeliveryStatusFailed
+		} else {
+			arg.NextAttemptAt = dispatcher.now().Add(retryDelay(arg.Attempts))
+		}
+	} else {
+		arg.Status = db.WebhookDeliveryStatusSucceeded
+		arg.DeliveredAt = sql.NullTime{Time: dispatcher.now(), Valid: true}
+	}
+
+	_, err = dispatcher.store.UpdateWebhookDelivery(ctx, arg)
+	return err
+}
@@ -0,0 +1,240 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	mockDB "practice-docker/db/mock"
+	db "practice-docker/db/sqlc"
+	"practice-docker/event"
+	"practice-docker/util"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWebhookDispatcher_DispatchDue(t *testing.T) {
+	now := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		status        int
+		attempts      int32
+		checkDelivery func(t *testing.T, count int, err error, delivery db.UpdateWebhookDeliveryParams)
+	}{
+		{
+			name:     "Delivered",
+			status:   http.StatusOK,
+			attempts: 0,
+			checkDelivery: func(t *testing.T, count int, err error, delivery db.UpdateWebhookDeliveryParams) {
+				require.NoError(t, err)
+				require.Equal(t, 1, count)
+				require.Equal(t, db.WebhookDeliveryStatusSucceeded, delivery.Status)
+				require.Equal(t, int32(1), delivery.Attempts)
+				require.Equal(t, sql.NullInt32{Int32: http.StatusOK, Valid: true}, delivery.ResponseStatus)
+				require.Equal(t, sql.NullTime{Time: now, Valid: true}, delivery.DeliveredAt)
+			},
+		},
+		{
+			name:     "Retried",
+			status:   http.StatusInternalServerError,
+			attempts: 2,
+			checkDelivery: func(t *testing.T, count int, err error, delivery db.UpdateWebhookDeliveryParams) {
+				require.NoError(t, err)
+				require.Equal(t, 1, count)
+				require.Equal(t, db.WebhookDeliveryStatusPending, delivery.Status)
+				require.Equal(t, int32(3), delivery.Attempts)
+				require.Equal(t, now.Add(retryDelay(3)), delivery.NextAttemptAt)
+				require.Equal(t, sql.NullInt32{Int32: http.StatusInternalServerError, Valid: true}, delivery.ResponseStatus)
+				require.Equal(t, "webhook responded with status 500", delivery.LastError.String)
+				require.False(t, delivery.DeliveredAt.Valid)
+			},
+		},
+		{
+			name:     "GivenUp",
+			status:   http.StatusBadGateway,
+			attempts: maxWebhookAttempts - 1,
+			checkDelivery: func(t *testing.T, count int, err error, delivery db.UpdateWebhookDeliveryParams) {
+				require.NoError(t, err)
+				require.Equal(t, db.WebhookDeliveryStatusFailed, delivery.Status)
+				require.Equal(t, int32(maxWebhookAttempts), delivery.Attempts)
+				require.False(t, delivery.DeliveredAt.Valid)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var header http.Header
+			var body []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tc.status)
+			}))
+			defer server.Close()
+
+			webhook := db.Webhooks{
+				ID:     util.RandomInt(1, 1000),
+				Owner:  util.RandomOwner(),
+				Url:    server.URL,
+				Secret: util.RandomString(32),
+			}
+			delivery := db.WebhookDeliveries{
+				ID:            util.RandomInt(1, 1000),
+				WebhookID:     webhook.ID,
+				EventType:     db.WebhookAccountCredited,
+				Payload:       json.RawMessage(`{"account_id":1,"amount":10}`),
+				Status:        db.WebhookDeliveryStatusPending,
+				Attempts:      tc.attempts,
+				NextAttemptAt: now.Add(-time.Minute),
+			}
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockDB.NewMockStore(ctrl)
+
+			// the first call claims the delivery, the second one finds nothing due
+			lease := db.ClaimDueWebhookDeliveryParams{LeaseUntil: now.Add(webhookDeliveryLease), Now: now}
+			gomock.InOrder(
+				store.EXPECT().
+					ClaimDueWebhookDelivery(gomock.Any(), gomock.Eq(lease)).
+					Times(1).
+					Return(delivery, nil),
+				store.EXPECT().
+					ClaimDueWebhookDelivery(gomock.Any(), gomock.Eq(lease)).
+					Times(1).
+					Return(db.WebhookDeliveries{}, sql.ErrNoRows),
+			)
+
+			store.EXPECT().
+				GetWebhook(gomock.Any(), gomock.Eq(webhook.ID)).
+				Times(1).
+				Return(webhook, nil)
+
+			var update db.UpdateWebhookDeliveryParams
+			store.EXPECT().
+				UpdateWebhookDelivery(gomock.Any(), gomock.Any()).
+				Times(1).
+				DoAndReturn(func(_ context.Context, arg db.UpdateWebhookDeliveryParams) (db.WebhookDeliveries, error) {
+					require.Equal(t, delivery.ID, arg.ID)
+					update = arg
+					return db.WebhookDeliveries{}, nil
+				})
+
+			// the test server listens on a loopback address, which the dispatcher refuses otherwise
+			dispatcher := NewWebhookDispatcher(store, time.Second)
+			dispatcher.sender = event.NewWebhookSender()
+			dispatcher.now = func() time.Time { return now }
+
+			count, err := dispatcher.DispatchDue(context.Background())
+			tc.checkDelivery(t, count, err, update)
+
+			// the receiver can check that the payload comes from us
+			timestamp, err := strconv.ParseInt(header.Get("X-Event-Timestamp"), 10, 64)
+			require.NoError(t, err)
+			require.Equal(t, "sha256="+event.Sign(webhook.Secret, timestamp, body), header.Get("X-Event-Signature"))
+			require.Equal(t, strconv.FormatInt(delivery.ID, 10), header.Get("X-Event-Id"))
+		})
+	}
+}
+
+func TestWebhookDispatcher_DispatchDueNonPublicAddress(t *testing.T) {
+	now := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	// the webhook resolved to a public address when it was created, but points at the loopback now
+	webhook := db.Webhooks{
+		ID:     util.RandomInt(1, 1000),
+		Owner:  util.RandomOwner(),
+		Url:    server.URL,
+		Secret: util.RandomString(32),
+	}
+	delivery := db.WebhookDeliveries{
+		ID:            util.RandomInt(1, 1000),
+		WebhookID:     webhook.ID,
+		EventType:     db.WebhookAccountCredited,
+		Payload:       json.RawMessage(`{}`),
+		Status:        db.WebhookDeliveryStatusPending,
+		NextAttemptAt: now.Add(webhookDeliveryLease),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockDB.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().
+			ClaimDueWebhookDelivery(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(delivery, nil),
+		store.EXPECT().
+			ClaimDueWebhookDelivery(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.WebhookDeliveries{}, sql.ErrNoRows),
+	)
+	store.EXPECT().
+		GetWebhook(gomock.Any(), gomock.Eq(webhook.ID)).
+		Times(1).
+		Return(webhook, nil)
+	store.EXPECT().
+		UpdateWebhookDelivery(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.UpdateWebhookDeliveryParams) (db.WebhookDeliveries, error) {
+			require.Equal(t, db.WebhookDeliveryStatusPending, arg.Status)
+			require.False(t, arg.ResponseStatus.Valid)
+			require.Contains(t, arg.LastError.String, event.ErrNonPublicAddress.Error())
+			return db.WebhookDeliveries{}, nil
+		})
+
+	dispatcher := NewWebhookDispatcher(store, time.Second)
+	dispatcher.now = func() time.Time { return now }
+
+	count, err := dispatcher.DispatchDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	require.False(t, called)
+}
+
+func TestWebhookDispatcher_DispatchDueDeletedWebhook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	delivery := db.WebhookDeliveries{ID: util.RandomInt(1, 1000), WebhookID: util.RandomInt(1, 1000)}
+
+	store := mockDB.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().
+			ClaimDueWebhookDelivery(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(delivery, nil),
+		store.EXPECT().
+			ClaimDueWebhookDelivery(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.WebhookDeliveries{}, sql.ErrNoRows),
+	)
+	store.EXPECT().
+		GetWebhook(gomock.Any(), gomock.Eq(delivery.WebhookID)).
+		Times(1).
+		Return(db.Webhooks{}, sql.ErrNoRows)
+	store.EXPECT().
+		UpdateWebhookDelivery(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	count, err := NewWebhookDispatcher(store, time.Second).DispatchDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}